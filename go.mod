@@ -1,8 +1,13 @@
 module github.com/aschey/godocdown
 
-go 1.19
+go 1.22.0
 
 require (
 	github.com/lithammer/dedent v1.1.0
+	github.com/tetratelabs/wazero v1.9.0
 	golang.org/x/mod v0.7.0
+	golang.org/x/term v0.27.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/sys v0.28.0 // indirect
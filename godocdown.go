@@ -7,8 +7,8 @@
 //
 //	# Generate documentation, like godoc
 //	$ godocdown .
+//
+// To generate documentation from Go code instead, without running the
+// command, use the library the command is made of:
+// http://github.com/aschey/godocdown/pkg/docdown
 package godocdown
-
-import (
-	_ "github.com/aschey/godocdown/godocdown"
-)
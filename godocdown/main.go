@@ -11,6 +11,47 @@ Command godocdown generates Go documentation in a GitHub-friendly Markdown forma
 	# Generate standard Markdown                                                 
 	$ godocdown -plain .                                                         
 
+Besides directories, the packages to document can be given as package
+patterns, like std, ./... or example.com/mod/..., which are resolved with
+"go list". Vendored packages, and internal packages unless -include-internal
+is set, are left out of the packages a pattern matches.
+
+	# Generate a README.md for every package in the module
+	$ godocdown -output=README.md ./...
+
+	# Generate documentation for a package of the standard library
+	$ godocdown net/http > http.md
+
+Standard library packages are documented for the current platform (GOOS and
+GOARCH), as they have files for every platform.
+
+A module zip (like those the module proxy serves) or a tarball (.tar, .tar.gz,
+or .tgz) can be documented without checking it out. It is extracted to a
+temporary directory, and the package at the root of the module is documented.
+
+	$ godocdown -output=dedent.md dedent@v1.1.0.zip
+
+A single Go file, or - for Go source on stdin, documents just the declarations
+in it, for a quick look at a doc comment while writing it.
+
+	$ godocdown client.go
+	$ pbpaste | godocdown -
+
+With -tui, the documentation is shown in the terminal rather than written:
+styled, beside a tree of its headings (its sections, and the types,
+functions, and methods of the package) to jump to them with the arrow keys.
+Tab switches to scrolling the documentation itself, and q quits.
+
+	$ godocdown -tui ./client
+
+With -compact, the documentation is a terse reference, to embed in a README:
+functions and methods are headed by their signatures (but for commonmark,
+whose anchors come from the headings), the index and empty sections are left
+out, examples lose their collapsible sections, and blank lines are kept only
+where Markdown needs them.
+
+	$ godocdown -compact -output README.md
+
 This program is targeted at providing nice-looking documentation for GitHub. With this in
 mind, it generates GitHub Flavored Markdown (http://github.github.com/github-flavored-markdown/) by
 default. This can be changed with the use of the "plain" flag to generate standard Markdown.
@@ -29,6 +70,69 @@ Usage
 	    Write output to a file instead of stdout                                     
 	    Write to stdout with -                                                       
 	                                                                                 
+	-out-dir=""
+	    Write the documentation to a tree of pages in this directory, one for
+	    each package, following their import paths: documenting ./... from the
+	    root of a module puts the root package at the top. Each page is named
+	    -output (README.md by default), the packages link to each other, and
+	    an index of them is written to index.md (or -index). Local images the
+	    doc comments show (like ![Design](doc/design.png)) are copied next to
+	    the pages. Without -out-dir, links to them are made relative to -output
+
+	-used-by=false
+	    Below each symbol, list the other packages of its module that use it
+	    (linked to their pages, when they are documented too), to judge what
+	    a change to it affects, and to find it in use. Every package of the
+	    module is type-checked for this, but for its tests
+
+	        $ godocdown -used-by -out-dir=docs ./...
+
+	-search-index=false
+	    Write a search index of the packages and their symbols, with their
+	    synopses and doc text, to -out-dir, for searching a statically hosted
+	    site: search.json, a list of documents for lunr or elasticlunr to
+	    index (each with a unique id, the link to it as its href, and its
+	    name, kind, package, synopsis, and text), and search.js, a script that does that
+	    with lunr. To search from a page of the site:
+
+	        <input id="godocdown-search" type="search" placeholder="Search">
+	        <ul id="godocdown-results"></ul>
+	        <script src="https://unpkg.com/lunr/lunr.min.js"></script>
+	        <script src="search.js" data-extension=".html"></script>
+
+	    data-extension replaces the .md of the links, for sites that serve the
+	    pages as HTML
+
+	-navigation=""
+	    Links between the pages of documentation on more than one page (with
+	    -out-dir, -split, or -section pages), to get around without the index:
+	    a list of breadcrumbs, a trail at the top of each page from the module
+	    (linked to the index) to the package and the type or section, and
+	    pages, links to the previous and the next page at the bottom. Pages go
+	    through each package (its page, then those of its sections and types)
+	    and from package to package in the order of the index
+
+	        $ godocdown -navigation=breadcrumbs,pages -split=type -out-dir=docs ./...
+
+	-format="markdown"
+	    What to write -out-dir as: Markdown pages, or a docset, to search the
+	    documentation offline in Dash or Zeal. -out-dir is then the docset
+	    (named after it, like Client.docset), with an HTML page for each
+	    package, and a search index of the packages and their symbols, which
+	    is written with the sqlite3 command (without which godocdown fails
+	    before writing anything)
+
+	        $ godocdown -format=docset -out-dir=Client.docset ./...
+
+	    mrkdwn (Slack) and discord write a short announcement of each package
+	    instead, for a release bot to post: its import path and synopsis, and
+	    with -annotate-changes, the symbols new or changed since that revision,
+	    linked to pkg.go.dev. It is kept within the limit of a message (4000
+	    characters for Slack, 2000 for Discord) by counting the symbols that do
+	    not fit rather than listing them
+
+	        $ godocdown -format=mrkdwn -annotate-changes v1.2.0 ./client
+
 	-template=""                                                                     
 	    The template file to use                                                     
 	                                                                                 
@@ -38,6 +142,417 @@ Usage
 	-plain=false                                                                     
 	    Emit standard Markdown, rather than Github Flavored Markdown                 
 	                                                                                 
+	-ascii-tables="markdown"
+	    What to do with tables drawn in the preformatted blocks of doc comments,
+	    either boxed in with +---+ and | or in columns under a line of dashes:
+	    convert them to Markdown tables, or leave them preformatted (pre).
+	    Flavors without tables (plain and commonmark) always leave them
+
+	-heading-style="atx"
+	    How to write level 1 and 2 headings: atx ("# Title") or setext (the title
+	    underlined with === or ---), for style guides that require one
+
+	-numbered-headings=false
+	    Number the headings after the title by their level, like 2., 2.1, and
+	    2.1.3, as in a formal specification, those of the package comment
+	    (like "# FAQ") too. Links to them keep working
+
+	-index-header=""
+	    The heading of the index of symbols, instead of "Index", e.g. "API" or
+	    "## Contents". Without a "#", the heading keeps its usual level.
+	    Use - to list the index without a heading
+
+	-html="pass"
+	    What to do with raw HTML (or anything that looks like it, like chan<-)
+	    in doc comments: pass it through, escape it so it shows as written, or
+	    sanitize it, keeping a few harmless tags (like <b>, <code>, <a href>,
+	    and <img src>) and escaping the rest. Code is always left alone
+
+	-emoji="pass"
+	    What to do with emoji shortcodes, like :warning:, in doc comments. GitHub
+	    shows them as emoji, while other targets show them as written: pass them
+	    through, escape them so GitHub shows them as written too, or convert them
+	    to emoji (the common ones; others are left alone) so every target shows
+	    them. Code is always left alone
+
+	-entities="pass"
+	    What to do with HTML entities, like &copy; or &#8212;, in doc comments:
+	    pass them through for Markdown to show as characters, escape them so
+	    they show as written, or convert them to the characters, for targets
+	    that show them as written. &lt;, &gt;, and &amp; are kept, as Markdown
+	    needs them. Code is always left alone
+
+	-translations=""
+	    A YAML file translating the fixed strings of the output, for documentation
+	    in other languages. Each key is the English string: Index, Constants,
+	    Variables, Example, Examples, Output, Package, Packages, Synopsis,
+	    Import path, Subpackages, Imports, Stats, Types, Functions, Methods,
+	    Files, Lines, Refers to, Used by, or Tutorial. For example:
+
+	        Index: 索引
+	        Example: 示例
+	        Output: 输出
+
+	-locales=""
+	    A comma-separated list of locales to also generate the documentation in,
+	    like "zh,ja". Each goes next to the -output, with the locale before the
+	    extension: README.zh.md. "{locale}" in -translations and -template is
+	    replaced by the locale, and a template named like .godocdown.zh.template
+	    in the package directory is used before the default one.
+
+	-title=""
+	-header=""
+	    Replace the title (the package name) and add a header after it, such as
+	    badges, a tagline, or a logo, without writing a whole template. Both are
+	    templates with the same data as a template file, e.g.
+	    -title="The {{ .Name }} library" -header="![logo](logo.png)"
+
+	-footer=""
+	-footer-template=""
+	    What comes at the end of the documentation, like a "generated by" line,
+	    as a template (given directly, or in a file), or - for nothing. Besides
+	    the template data below, "now" is the time of the run and "version" is
+	    the version of godocdown, e.g.
+	    -footer='Generated by godocdown {{ version }} on {{ now.Format "2006-01-02" }}'
+
+	-flavor="github"
+	    The kind of Markdown to emit: github, plain, commonmark, gomarkdoc
+	    gomarkdoc matches the headings, anchors, and source links of gomarkdoc
+	    (https://github.com/princjef/gomarkdoc), to ease switching between them
+	    commonmark sticks to strict CommonMark, without HTML, heading
+	    attributes, or tables, so it converts cleanly with pandoc (e.g. to PDF
+	    or EPUB). Its links use the identifiers pandoc gives headings
+
+	-repository-url=""
+	-repository-ref="main"
+	    Link each declaration to its source in the repository (gomarkdoc flavor)
+
+	-index="docs/README.md"
+	    When documenting several packages to files (with -output), also write
+	    a page listing every package, its import path, and synopsis, linking
+	    to the documentation of each. Disable with -index=""
+
+	-subpackages=false
+	    Add a "Subpackages" section listing the packages in the directories
+	    immediately below the documented one, with their synopses and links
+
+	-recursive=false
+	    Document every package in the tree below each directory given (or
+	    the current directory), like the ./... pattern, but by walking the
+	    directories rather than with "go list", so it goes on into nested
+	    modules, where ./... stops. Directories are skipped as with
+	    -skip-dir. With -output or -out-dir, an index of the packages is
+	    written too:
+
+	        $ godocdown -recursive -output=README.md services
+
+	-go-list=false
+	    Read the packages to document from the output of "go list -json" on
+	    stdin, instead of finding them. Each package is documented with the
+	    import path and files (GoFiles, TestGoFiles, and XTestGoFiles) it was
+	    listed with, so a build system can decide exactly what is documented:
+
+	        go list -json -tags=integration ./... | godocdown -go-list -output=README.md
+
+	-include-internal=false
+	-skip-dir=""
+	    When searching directories for packages (as for -subpackages), hidden
+	    directories, directories starting with "_", testdata, and internal
+	    directories are skipped. -include-internal keeps internal packages,
+	    and -skip-dir skips directories with the given name too (repeatable)
+
+	-plugin=""
+	    A command that reads the package as JSON and changes or adds to its
+	    documentation (repeatable; see Plugins below)
+
+	-post-process=""
+	    A command to pipe the documentation through before it is written, run
+	    with the shell, like -post-process="prettier --parser markdown". It
+	    reads each page on stdin and writes what to keep to stdout, and can
+	    tell the pages apart by GODOCDOWN_OUTPUT, the path of the page (or ""
+	    for stdout). godocdown fails if the command does
+
+	-timeout=0
+	    Stop after this long, like -timeout=5m: the packages not yet
+	    generated fail, and the plugins and -post-process commands still
+	    running are killed. An interrupt (Ctrl-C) stops the run the same way
+
+	-filter=""
+	    A replacement to make in the prose of doc comments (but not in their
+	    code blocks) before they are rendered: "s/pattern/replacement/" for a
+	    regular expression (any punctuation can be the delimiter, and $1 etc.
+	    are its groups), or "text=>replacement" for literal text. Can be
+	    repeated; the replacements are made in order. For example, to link
+	    ticket IDs and hide an internal host in the configuration file:
+
+	        filter:
+	          - s|\bPROJ-(\d+)\b|[PROJ-$1](https://tracker.example.com/PROJ-$1)|
+	          - build.internal.example.com=>build.example.com
+
+	-weight=""
+	    Order a symbol before lighter ones in its section of the documentation
+	    and the index, like -weight=NewClient=10, instead of by name. Symbols
+	    are named like F, T, or T.M, weigh 0 unless given, and can be weighed
+	    with a directive instead (see Directives). Can be repeated
+
+	-exclude-dir=""
+	    Skip the directories matching a glob when searching directories for
+	    packages, relative to where the search starts (e.g. "third_party").
+	    A "**" element matches any number of directories, so "**" and "mocks"
+	    joined by a slash skips every mocks directory. Can be repeated
+
+	-package=""
+	    The package to document when a directory has more than one, like a
+	    library with a package main file that generates code, rather than the
+	    one godocdown guesses (with a warning)
+
+	-asm=false
+	    Note that a function declared without a body is implemented in
+	    assembly, and for which architectures, from the names of the .s files
+	    that define it (like sum_amd64.s and sum_arm64.s):
+
+	        Implemented in assembly (amd64, arm64).
+
+	-command-api=false
+	    Document the exported symbols of a command (package main) too, with
+	    an index, after its documentation, as for a library. Without it, a
+	    command has only its package comment documented
+
+	-skip-generated=false
+	    Leave out the files with the standard header of generated code,
+	    "// Code generated ... DO NOT EDIT.", reading no more than the header
+
+	-keep-directives=false
+	    Keep the comments meant for the compiler and other tools, like
+	    //go:generate, //go:noinline, and //nolint, in the rendered declarations
+
+	-unexported-fields="strip"
+	    What to do with the "// contains filtered or unexported fields" comment
+	    in a struct or interface with unexported members: strip (the default),
+	    keep, or any other text to replace it with (e.g. "private fields omitted")
+
+	-example-index="flat"
+	    How the index lists examples: flat (one list, in the order of
+	    -example-order), grouped (under the function, type, or method each is
+	    for, like godoc), or off
+
+	-method-order="name"
+	    The order of the functions and methods of each type: name (the
+	    default), or receiver, which puts constructors (like NewClient and
+	    MustParse) before its other functions, and methods with a value
+	    receiver before those with a pointer receiver, under a line for each
+	    kind ("Methods on `T`:" and "Methods on `*T`:") when it has both
+
+	-example-order="name"
+	    The order of the examples of each symbol, and in the index: name (the
+	    default), or source (as they are in the test files). Heavier examples
+	    come first either way, weighed with -weight (like
+	    -weight=ExampleClient_basic=10) or a directive in their doc comments:
+
+	        //godocdown:weight 10
+
+	-example-filter=""
+	    A regular expression for the examples to document, matched against
+	    their names (like ExampleClient_basic), or with a leading "!" for the
+	    examples to leave out, like -example-filter='!Stress'. An example can
+	    be left out with a directive in its doc comment too:
+
+	        //godocdown:ignore
+
+	-example-code=""
+	    Clean up the code of examples, for snippets that can be copied as they
+	    are, with a list of: comments (keep the comments of the code, but for
+	    the expected output, which is shown as the output), unwrap (show the
+	    body of an example that is a whole file, like one with helpers, after
+	    the declarations it needs, rather than the file), and gofmt (format
+	    the code with gofmt). Either way, the lines of an example between
+	    these comments are elided, and replaced with a "// ..." line:
+
+	        //godocdown:hide
+	        ...
+	        //godocdown:show
+
+	-example-programs=false
+	    Show each example as a program that can be copied and run as it is:
+	    its body in a main function, with the symbols of the package
+	    qualified, and the imports it needs. The package is type-checked with
+	    its test files to tell what the body refers to. Examples that use
+	    unexported symbols, or helpers of their test files, are shown as
+	    they are
+
+	-pin-example=""
+	    Put an example (like ExampleClient_basic) before the others, whatever
+	    -example-order says. Can be repeated, to pin examples in that order
+
+	-example-title=""
+	    A template for the titles of examples, instead of "Example (sub name)",
+	    with .Name (e.g. Foo_streaming_mode), .Symbol (Foo, T.M, or "" for the
+	    package), and .Suffix (streaming mode). For example:
+	    -example-title='Example: {{ .Symbol }}{{ with .Suffix }} — {{ . }}{{ end }}'
+
+	-code-lang="go"
+	    The language to fence declarations and examples with, for renderers
+	    that know Go by another name (like golang), or "" for none. A
+	    declaration or example can choose its own with a directive in its doc
+	    comment:
+
+	        //godocdown:lang golang
+
+	-qualify="source"
+	    How declarations qualify the types of other packages: as in the
+	    source, "full" (declaring the imports they use above them, like
+	    import "net/http"), or "short" (without the qualifier of the package
+	    itself)
+
+	-wrap-params=false
+	    Put the parameters of a function whose signature is wider than
+	    -max-width on lines of their own, one per line (names that share a
+	    type stay together)
+
+	-wrap=false
+	    Wrap every line of a declaration that is wider than -max-width, so
+	    that code blocks need no scrolling: the parameters of functions and
+	    the methods of interfaces first, then, if that is not enough, their
+	    type parameters and their results, and the type parameters of
+	    generic types:
+
+	        type Cache[
+	            K comparable,
+	            V interface{ Size() int },
+	        ] struct {
+
+	-indent="tabs"
+	    How declarations are indented: with "tabs", like gofmt, or "spaces"
+	    (-tab-width of them for each level)
+
+	-tab-width=4
+	    How wide a tab is, for aligning the fields and comments of
+	    declarations, and for -max-width
+
+	-max-width=80
+	    How wide (in columns) the signature of a function can be before
+	    -wrap-params puts its parameters on lines of their own, or a line of
+	    a declaration before -wrap wraps it
+
+	-example-output-lang=""
+	    The language to fence the output of examples with (e.g. text, console,
+	    or json), for syntax highlighting. An example can choose its own with a
+	    directive in its doc comment:
+
+	        //godocdown:output-lang json
+
+	-split=""
+	    Split the documentation into more than one page, next to -output. With
+	    "type", each exported type (with its constructors, methods, and examples)
+	    gets a page of its own, named after it (like Client.md), and the index
+	    links to them
+
+	-section=""
+	    Take a section of the package comment (from its "# Heading" to the
+	    next heading) out of the top of the documentation: -section=FAQ puts
+	    it after the documentation of the API, and -section=FAQ=FAQ.md on a
+	    page of its own next to -output, leaving a link to the page in its
+	    place. Templates can put a section elsewhere with
+	    {{ .EmitSection "FAQ" }}. Can be repeated
+
+	-quickstart=""
+	    Show the code of an example, like -quickstart=ExampleClient_basic, in
+	    a "Quick start" section right after the package comment, with the
+	    imports it needs. An example can ask for this with a directive in its
+	    doc comment instead:
+
+	        //godocdown:quickstart
+
+	-tutorial=""
+	    Write the examples of the package as the steps of a tutorial, on a
+	    page of its own next to -output, like -tutorial=TUTORIAL.md: each
+	    step is the doc comment of an example, then its code, with the
+	    imports it needs, and its output. Heavier examples come first
+	    (weighed like with -weight), then those numbered in their names,
+	    like Example_step1 and Example_step2, then the rest, in the order of
+	    the source. A step is titled by a "//godocdown:title" directive, or
+	    the first sentence of its doc comment
+
+	-stats=false
+	    Add a "Stats" section counting the exported types, functions, methods,
+	    and examples of the package, and its Go files and lines of code. The
+	    counts are in -report too, and templates can use {{ .Stats.Lines }} etc.
+
+	-check-links="off"
+	    After writing the pages, check their links: to anchors of the same
+	    page (index entries, links in doc comments), to other pages (links
+	    between packages and to split types), and to other files. Broken
+	    links are reported with the heading they are under, and with
+	    -check-links=strict, they fail the run. Links to URLs are not checked
+
+	-coverage-badge=""
+	    Write the documentation coverage of the packages (the share of their
+	    exported symbols with doc comments) to a file as the JSON of a
+	    shields.io endpoint badge, for CI to publish and a README to show:
+	    ![docs](https://img.shields.io/endpoint?url=<URL of the file>)
+
+	-platforms=""
+	    Document the package for a list of platforms (GOOS, or GOOS/GOARCH),
+	    like -platforms=linux,darwin,windows, instead of from every file.
+	    Files that build on none of them (by their names, like _plan9.go, and
+	    their //go:build lines) are left out, and a declaration that differs
+	    between them gets a code block for each variant, labeled with its
+	    platforms ("linux, darwin"), or is labeled with the only platforms
+	    it is declared on
+
+	-test-coverage=false
+	    Run the tests of the package with "go test -cover", and add a badge
+	    for their statement coverage after the title (and {{ .TestCoverage }}
+	    for templates). Failing tests fail the package. go test caches the
+	    result until the package changes, and with -cache, so does godocdown
+
+	-run-benchmarks=false
+	-bench="."
+	-benchtime="1s"
+	    Run the benchmarks of the package (those matching -bench) with
+	    "go test -bench -benchmem", and add a "Benchmarks" section with a table
+	    of their results: ns/op, B/op, and allocs/op, and the platform they
+	    ran on. -benchtime is how long to run each one for (or how many
+	    times, like 100x), and -timeout limits the whole run
+
+	-stability-summary=false
+	    Add an "Experimental APIs" section listing the symbols that are
+	    experimental or in beta. A doc comment gives the stability of its
+	    symbol with a line like "Stability: experimental" (or beta, or stable),
+	    or with a directive (see Directives), and the symbol gets a badge for it
+
+	-imports=false
+	    Add an "Imports" section listing the packages imported from outside of
+	    the standard library and the package's own module, linking to each on
+	    pkg.go.dev. For a package that uses cgo, a "C dependencies" section
+	    follows, with the headers, libraries (-l in LDFLAGS), and pkg-config
+	    packages of its preambles
+
+	-config-struct=""
+	    Make a "Configuration" reference of a struct type of the package: a
+	    table of its fields, by the key of their yaml (or json, toml, or
+	    mapstructure) tags, with their env and flag tags, types, defaults
+	    (from a default tag), and doc comments. The fields of nested structs
+	    are under their parent's key, like server.port. Can be repeated, and a
+	    type can ask for one with a directive in its doc comment:
+
+	        //godocdown:config
+	        type Config struct {
+	            // Port is the port to listen on.
+	            Port int `yaml:"port" env:"PORT" default:"8080"`
+	        }
+
+	-embeds=false
+	    Add an "Embedded assets" section listing the variables (exported or
+	    not) that //go:embed directives fill with files, with their types and
+	    patterns, for packages that ship templates or static files
+
+	-diagram=false
+	    Add a Mermaid (https://mermaid.js.org) class diagram after the index,
+	    showing which exported types embed each other and which implement the
+	    package's interfaces. GitHub renders it natively. Implementation is
+	    judged from method names and signatures, without type checking
+
 	-heading="TitleCase1Word"                                                        
 	    Heading detection method: 1Word, TitleCase, Title, TitleCase1Word, ""        
 	    For each line of the package declaration, godocdown attempts to detect if    
@@ -55,6 +570,220 @@ Usage
 	                                                                                 
 	    TitleCase1Word: The line matches either the TitleCase or 1Word pattern       
 
+# Configuration
+
+Instead of giving them on the command line every time, options can be kept in
+a YAML file named ".godocdown.yaml" in the directory godocdown is run from (or
+given with -config). Each key is the name of an option, and options that can
+be repeated take a list. Options on the command line take precedence.
+
+	flavor: gomarkdoc
+	index-header: API
+	subpackages: true
+	skip-dir:
+	  - mocks
+	  - third_party
+
+Running "godocdown init" writes a starting point: a ".godocdown.yaml" with
+every option, commented out with its default (but for the options given to
+init, which are set), and a ".godocdown.template" that renders the parts of
+the standard documentation, in order, to rearrange. It will not overwrite
+them without -force. With -readme, it adds markers to the end of -output
+(README.md by default):
+
+	<!-- godocdown:start -->
+	<!-- godocdown:end -->
+
+When the file documentation is written to has these markers, only what is
+between them is replaced, so the rest of a README can be written by hand.
+
+The "outputs" key defines more files to write for each package, from the same
+parse of it: each is named, and has an "output" file (relative to the
+directory of the package), and optionally a "template", a "flavor", and a
+"format", which is markdown, or json for the model of the package that
+plugins are sent. Otherwise, they are rendered with the options of the run.
+
+	outputs:
+	  api:
+	    output: docs/API.md
+	    flavor: gomarkdoc
+	  readme:
+	    output: README.md
+	    template: .godocdown.readme.template
+	  model:
+	    output: docs/api.json
+	    format: json
+
+	$ godocdown init -flavor gomarkdoc -readme
+
+# Directives
+
+Doc comments can direct godocdown with directive lines, which godoc hides:
+
+	// Client talks to the server.
+	//
+	//godocdown:category Networking
+	type Client struct{}
+
+	//godocdown:ignore               Leave the declaration out
+	//godocdown:include docs/x.md    Splice in a Markdown file (see below)
+	//godocdown:category Encoding    List the declaration under a category in the index
+	//godocdown:weight 10            Order the declaration before lighter ones
+	//godocdown:lang golang          Fence the code with another language
+	//godocdown:output-lang json     Fence the output of an example with a language
+	//godocdown:title Quick start    Title the package's page, or an example
+	//godocdown:stability beta       Badge the declaration as experimental, beta, or stable
+	//godocdown:term Shard: a part   Define a term for the glossary
+	//godocdown:env API_TOKEN Token  Document an environment variable of a command
+	//godocdown:exit 2 Bad flags     Document an exit code of a command
+	//godocdown:config               Make a configuration reference of a struct (see -config-struct)
+	//godocdown:quickstart           Show an example in a "Quick start" section
+
+Unknown directives, and directives with a missing or unexpected argument,
+are reported with the other warnings (see -warnings). Templates can read
+directives with {{ .Directive "Client" "category" }}, where the symbol is
+like "F", "T", or "T.M", or "" for the package comment.
+
+Term directives can be in any comment of the package, several to a comment,
+and make up a "Glossary" section after the index, in alphabetical order:
+
+	//godocdown:term RingBuffer: a fixed-size circular queue
+	//godocdown:term Shard: one of the parts a key space is split into
+
+Doc text links to the definition of a term with a doc link, like
+[RingBuffer] or [shard], unless a symbol of the package has that name.
+
+Env directives can be in any comment of the package too, and make up an
+"Environment variables" table, by name, for the variables a command reads
+that no flag describes (libraries have no such table):
+
+	//godocdown:env API_TOKEN The token to authenticate with
+	//godocdown:env API_URL The server to talk to (default https://api.example.com)
+
+Exit directives make up an "Exit codes" table, by code. A command's
+constants named like ExitUsage (or exitUsage) document its exit codes too,
+with their comments, unless a directive documents the same code:
+
+	const (
+		exitOK    = 0 // Success
+		exitUsage = 2 // The flags were wrong
+	)
+
+	//godocdown:exit 3 The server could not be reached
+
+# Including Files
+
+Long-form guides can live in Markdown files of their own: a directive in a
+doc comment splices a file (relative to the package directory) into the
+documentation at that point.
+
+	// Package server serves things.
+	//
+	//godocdown:include docs/usage.md
+	package server
+
+Like other directives, it is hidden from godoc. A line of the comment that
+reads "godocdown:include docs/usage.md" does the same, without the directive.
+Images in the file are handled like those of the doc comments.
+
+# Pre-commit Hook
+
+Running "godocdown hook" regenerates the documentation of every package with
+staged Go files, prints a diff for each one whose documentation (README.md
+by default, or -output) is stale, and exits with a non-zero status. With
+-fix, the stale documentation is written and staged instead. Only packages
+that already have the output file are checked.
+
+	$ godocdown hook -output README.markdown
+	$ godocdown hook -fix
+
+# Linting
+
+Running "godocdown lint" checks the doc comments of the given packages (the
+one in the current directory by default) against the conventions of Go, and
+reports each violation with its position, exiting with a non-zero status if
+there are any. The rules are:
+
+	package-comment    The package has a package comment
+	documented         Exported symbols have doc comments
+	name-prefix        Doc comments start with the name of their symbol
+	examples           Examples are named for symbols that exist
+	links              Links to anchors in doc comments are not broken
+	directives         godocdown directives are known and well-formed
+	references         Doc links ([Name], [Type.Method]) and functions named
+	                   in "See also" sentences refer to symbols that exist
+
+-lint-disable turns a rule off (repeatable, or a list in the configuration
+file), and with -warnings=github, violations are reported as annotations.
+
+	$ godocdown lint ./...
+	$ godocdown lint -lint-disable name-prefix ./...
+
+# Release Notes
+
+Running "godocdown release-notes" compares the exported API of the packages in
+and below the current directory against the previous tag, read straight from
+the git objects, and writes an "API changes" section ready to paste into the
+notes of a release: the packages added and removed, and for the others, the
+breaking changes (removed and changed signatures), the additions, and the
+symbols whose documentation changed. -from picks another revision to compare
+against, -to describes a revision instead of the working tree, and -output
+writes the section to a file.
+
+	$ godocdown release-notes
+	$ godocdown release-notes -from v1.2.0 -to v1.3.0 -output notes.md
+
+To point readers of the documentation itself at what is recent,
+-annotate-changes labels the symbols added since a revision "New since" it,
+and those whose declarations changed (or that are grouped with new ones)
+"Changed since" it, under their headings.
+
+	$ godocdown -annotate-changes v1.2.0 -output README.md
+
+# Shell Completion
+
+Running "godocdown completion" with bash, zsh, fish, or powershell writes a
+script that completes the subcommands and flags of godocdown in that shell,
+and the values of the flags that take one of a few, like -flavor and
+-warnings, and -lint-disable with the rules.
+
+	$ source <(godocdown completion bash)
+	$ godocdown completion zsh > "${fpath[1]}/_godocdown"
+	$ godocdown completion fish > ~/.config/fish/completions/godocdown.fish
+
+# Plugins
+
+A plugin is a command, given with -plugin (repeatable, or a list in the
+configuration file), that adds to or changes the documentation without
+changing godocdown. It is run with the shell for each package and reads the
+package as JSON on stdin: its name, import path, directory, and doc comment,
+and its constants, variables, functions, types (with their values,
+constructors, and methods), and examples, each with its doc comment and code.
+
+	{"version": 1, "name": "client", "importPath": "example.com/client",
+	 "types": [{"name": "Client", "doc": "Client talks to the server.\n",
+	 "methods": [{"name": "Close", "receiver": "*Client", ...}], ...}], ...}
+
+The plugin writes JSON on stdout, where every field is optional: "docs"
+replaces the doc comments of symbols (named like F, T, or T.M, or "" for the
+package), "ignore" leaves symbols out, "sections" adds sections (with a
+"title" and "markdown") after the usage, and "markdown" replaces the whole
+documentation, for a plugin that renders it itself. Plugins run in order, each
+seeing the changes of those before it, and godocdown fails if one fails
+(what it writes to stderr is the error) or writes something else.
+
+	{"docs": {"Client": "Client talks to the server over gRPC.\n"},
+	 "sections": [{"title": "Metrics", "markdown": "- `client_requests_total`"}]}
+
+A plugin whose path ends in .wasm is a WebAssembly module built for WASI (like
+with GOOS=wasip1 GOARCH=wasm), which works the same on every platform and
+needs no permission to execute. godocdown runs it itself, with no runtime to
+install, and gives it no access to files, the network, or the environment: it
+only reads the package on stdin and writes its response to stdout.
+
+	plugin:
+	  - plugins/metrics.wasm
+
 # Templating
 
 In addition to Markdown rendering, godocdown provides templating via text/template (http://golang.org/pkg/text/template/)
@@ -67,7 +796,10 @@ package/command, godocdown will know to use the file as a template.
 	.godocdown.template
 	.godocdown.tmpl
 
-A template file can also be specified with the "-template" parameter
+A template file can also be specified with the "-template" parameter.
+"godocdown -schema" writes a JSON Schema (https://json-schema.org) of the data
+templates are executed with, for editors to complete and check templates
+with; each method that takes arguments has their number as "x-arguments".
 
 Along with the standard template functionality, the starting data argument has the following interface:
 
@@ -80,670 +812,82 @@ Along with the standard template functionality, the starting data argument has t
 	{{ .EmitSynopsis }}                                                                               
 	// Emit the package declaration                                                                   
 	                                                                                                  
+	{{ .EmitQuickstart }}
+	// Emit the code of the quick start example (with -quickstart)
+
 	{{ .EmitUsage }}                                                                                  
 	// Emit package usage, which includes a constants section, a variables section,                   
 	// a functions section, and a types section. In addition, each type may have its own constant,    
 	// variable, and/or function/method listing.                                                      
 	                                                                                                  
-	{{ if .IsCommand  }} ... {{ end }}                                                                
-	// A boolean indicating whether the given package is a command or a plain package                 
-	                                                                                                  
-	{{ .Name }}                                                                                       
-	// The name of the package/command (string)                                                       
-	                                                                                                  
-	{{ .ImportPath }}                                                                                 
-	// The import path for the package (string)                                                       
-	// (This field will be the empty string if godocdown is unable to guess it)                       
-*/
-package main
+	{{ .EmitStats }}
+	// Emit the counts of the package's types, functions, methods, examples, files,
+	// and lines (with -stats)
 
-import (
-	"bytes"
-	Flag "flag"
-	"fmt"
-	"go/ast"
-	"go/doc"
-	"go/parser"
-	"go/printer"
-	"go/token"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"regexp"
-	"sort"
-	"strings"
-	Template "text/template"
-	Time "time"
-
-	"github.com/lithammer/dedent"
-	"golang.org/x/mod/modfile"
-)
+	{{ .Stats }}
+	// The same counts, as .Types, .Functions, .Methods, .Examples, .Files, and .Lines
 
-const (
-	punchCardWidth = 80
-	debug          = false
-)
-
-var (
-	flag            = Flag.NewFlagSet("", Flag.ExitOnError)
-	flag_signature  = flag.Bool("signature", false, string(0))
-	flag_plain      = flag.Bool("plain", false, "Emit standard Markdown, rather than Github Flavored Markdown (the default)")
-	flag_heading    = flag.String("heading", "TitleCase1Word", "Heading detection method: 1Word, TitleCase, Title, TitleCase1Word, \"\"")
-	flag_template   = flag.String("template", "", "The template file to use")
-	flag_noTemplate = flag.Bool("no-template", false, "Disable template processing")
-	flag_noFuncs    = flag.Bool("no-funcs", false, "Ignore Funcs")
-	flag_output     = ""
-	_               = func() byte {
-		flag.StringVar(&flag_output, "output", flag_output, "Write output to a file instead of stdout. Write to stdout with -")
-		flag.StringVar(&flag_output, "o", flag_output, string(0))
-		return 0
-	}()
-)
-
-var (
-	fset *token.FileSet
-
-	synopsisHeading1Word_Regexp          = regexp.MustCompile("(?m)^([A-Za-z0-9_-]+)$")
-	synopsisHeadingTitleCase_Regexp      = regexp.MustCompile("(?m)^((?:[A-Z][A-Za-z0-9_-]*)(?:[ \t]+[A-Z][A-Za-z0-9_-]*)*)$")
-	synopsisHeadingTitle_Regexp          = regexp.MustCompile("(?m)^((?:[A-Za-z0-9_-]+)(?:[ \t]+[A-Za-z0-9_-]+)*)$")
-	synopsisHeadingTitleCase1Word_Regexp = regexp.MustCompile("(?m)^((?:[A-Za-z0-9_-]+)|(?:(?:[A-Z][A-Za-z0-9_-]*)(?:[ \t]+[A-Z][A-Za-z0-9_-]*)*))$")
-
-	strip_Regexp           = regexp.MustCompile("(?m)^\\s*// contains filtered or unexported fields\\s*\n")
-	indent_Regexp          = regexp.MustCompile("(?m)^([^\\n])") // Match at least one character at the start of the line
-	synopsisHeading_Regexp = synopsisHeading1Word_Regexp
-	match_7f               = regexp.MustCompile(`(?m)[\t ]*\x7f[\t ]*$`)
-)
-
-var DefaultStyle = Style{
-	IncludeImport: true,
-
-	SynopsisHeader:  "####",
-	SynopsisHeading: synopsisHeadingTitleCase1Word_Regexp,
-
-	UsageHeader: "#### Index\n",
-
-	ConstantHeader:     "####",
-	VariableHeader:     "####",
-	FunctionHeader:     "####",
-	TypeHeader:         "####",
-	TypeFunctionHeader: "####",
-
-	IncludeSignature: false,
-}
-var RenderStyle = DefaultStyle
-
-func usage() {
-	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
-	flag.PrintDefaults()
-	executable, err := os.Stat(os.Args[0])
-	if err != nil {
-		return
-	}
-	time := executable.ModTime()
-	since := Time.Since(time)
-	fmt.Fprintf(os.Stderr, "---\n%s (%.2f)\n", time.Format("2006-01-02 15:04 MST"), since.Minutes())
-}
-
-func init() {
-	flag.Usage = usage
-}
-
-type Style struct {
-	IncludeImport bool
-
-	SynopsisHeader  string
-	SynopsisHeading *regexp.Regexp
+	{{ .EmitSections }}
+	// Emit the sections taken out of the package comment with -section
 
-	UsageHeader string
+	{{ .EmitSection "FAQ" }}
+	// Emit one of them, which is then left out of .EmitSections and .Emit
 
-	ConstantHeader     string
-	VariableHeader     string
-	FunctionHeader     string
-	TypeHeader         string
-	TypeFunctionHeader string
+	{{ .EmitEnv }}
+	// Emit the environment variables documented with "//godocdown:env" directives
 
-	IncludeSignature bool
-}
+	{{ .EmitExitCodes }}
+	// Emit the exit codes of a command, from "//godocdown:exit" directives and Exit constants
 
-type _document struct {
-	Name       string
-	pkg        *doc.Package
-	absPath    string
-	testFiles  map[string]*ast.File
-	IsCommand  bool
-	ImportPath string
-	Examples   examples
-}
+	{{ .EmitConfiguration }}
+	// Emit the configuration references of structs (with -config-struct or "//godocdown:config")
 
-func takeOut7f(input string) string {
-	return match_7f.ReplaceAllString(input, "")
-}
-
-// func _formatIndent(target, indent, preIndent string) string {
-// 	var buffer bytes.Buffer
-// 	toText(&buffer, target, indent, preIndent, punchCardWidth-2*len(indent))
-// 	s := buffer.String()
-// 	return dedent.Dedent(s)
-// }
-//
-//
-// func formatIndent(target string) string {
-// 	return _formatIndent(target, spacer(0), spacer(0))
-// }
-
-// filterExamples filters the list of examples to only includes the ones that
-// are associated with the provided type/func name
-func filterExamples(exs []*doc.Example, name string) (res []*doc.Example) {
-	for _, e := range exs {
-		root := strings.SplitN(e.Name, "_", 2)[0]
-		if root == name {
-			res = append(res, e)
-		}
-	}
-	return
-}
-
-func spacer(width int) string {
-	return strings.Repeat(" ", width)
-}
-
-func indentCode(target string) string {
-	if *flag_plain {
-		return indent(target+"\n", spacer(4))
-	}
-	if target[0] == '{' && target[len(target)-1] == '}' {
-		// example code night be wrapped in a weird enclosing brackets. clean it
-		// up.
-		target = target[1 : len(target)-1]
-	}
-	target = dedent.Dedent(target)
-	target = strings.Trim(target, "\n")
-	return fmt.Sprintf("```go\n%s\n```", target)
-}
+	{{ .EmitGlossary }}
+	// Emit the terms defined with "//godocdown:term" directives
 
-func headifySynopsis(target string) string {
-	detect := RenderStyle.SynopsisHeading
-	if detect == nil {
-		return target
-	}
-	return detect.ReplaceAllStringFunc(target, func(heading string) string {
-		return fmt.Sprintf("%s %s", RenderStyle.SynopsisHeader, heading)
-	})
-}
+	{{ .EmitPlugins }}
+	// Emit the sections added by plugins (with -plugin)
 
-func exampleNames(name string) (base, sub string) {
-	comps := strings.SplitN(name, "_", 2)
-	base = comps[0]
-	if len(comps) > 1 {
-		sub = " (" + strings.Replace(comps[1], "_", " ", -1) + ")"
-	}
-	return
-}
+	{{ .EmitStability }}
+	// Emit the list of experimental and beta symbols (with -stability-summary)
 
-func headlineSynopsis(synopsis, header string, scanner *regexp.Regexp) string {
-	return scanner.ReplaceAllStringFunc(synopsis, func(headline string) string {
-		return fmt.Sprintf("%s %s", header, headline)
-	})
-}
+	{{ .EmitBenchmarks }}
+	// Emit the table of the package's benchmark results (with -run-benchmarks)
 
-func sourceOfNode(target interface{}) string {
-	var buffer bytes.Buffer
-	mode := printer.TabIndent | printer.UseSpaces
-	err := (&printer.Config{Mode: mode, Tabwidth: 4}).Fprint(&buffer, fset, target)
-	if err != nil {
-		return ""
-	}
-	return strip_Regexp.ReplaceAllString(buffer.String(), "")
-}
+	{{ .EmitImports }}
+	// Emit the list of packages imported from outside the module (with -imports)
 
-func indentNode(target interface{}) string {
-	return indentCode(sourceOfNode(target))
-}
+	{{ .EmitEmbeds }}
+	// Emit the list of the files embedded with //go:embed (with -embeds)
 
-func indent(target string, indent string) string {
-	return indent_Regexp.ReplaceAllString(target, indent+"$1")
-}
+	{{ .EmitDiagram }}
+	// Emit the Mermaid diagram of the package's types (with -diagram)
 
-func filterText(input string) string {
-	// Why is this here?
-	// Normally, godoc will ALWAYS collapse adjacent lines separated only by whitespace.
-	// However, if you place a (normally invisible) \x7f character in the documentation,
-	// this collapse will not happen. Thankfully, Markdown does not need this sort of hack,
-	// so we remove it.
-	return takeOut7f(input)
-}
+	{{ .EmitSubpackages }}
+	// Emit the list of packages in the directories below this one (with -subpackages)
 
-func trimSpace(buffer *bytes.Buffer) {
-	tmp := bytes.TrimSpace(buffer.Bytes())
-	buffer.Reset()
-	buffer.Write(tmp)
-}
+	{{ .EmitFooter }}
+	// Emit the footer (see -footer); it is added at the end otherwise
 
-func fromSlash(path string) string {
-	return filepath.FromSlash(path)
-}
-
-func exampleSubName(name string) string {
-	_, sub := exampleNames(name)
-	return sub
-}
+	{{ if .IsCommand  }} ... {{ end }}                                                                
+	// A boolean indicating whether the given package is a command or a plain package                 
+	                                                                                                  
+	{{ .Name }}                                                                                       
+	// The name of the package/command (string)                                                       
+	                                                                                                  
+	{{ .ImportPath }}                                                                                 
+	// The import path for the package (string)                                                       
+	// (This field will be the empty string if godocdown is unable to guess it)                       
 
-/*
-	    This is how godoc does it:
-
-		// Determine paths.
-		//
-		// If we are passed an operating system path like . or ./foo or /foo/bar or c:\mysrc,
-		// we need to map that path somewhere in the fs name space so that routines
-		// like getPageInfo will see it.  We use the arbitrarily-chosen virtual path "/target"
-		// for this.  That is, if we get passed a directory like the above, we map that
-		// directory so that getPageInfo sees it as /target.
-		const target = "/target"
-		const cmdPrefix = "cmd/"
-		path := flag.Arg(0)
-		var forceCmd bool
-		var abspath, relpath string
-		if filepath.IsAbs(path) {
-			fs.Bind(target, OS(path), "/", bindReplace)
-			abspath = target
-		} else if build.IsLocalImport(path) {
-			cwd, _ := os.Getwd() // ignore errors
-			path = filepath.Join(cwd, path)
-			fs.Bind(target, OS(path), "/", bindReplace)
-			abspath = target
-		} else if strings.HasPrefix(path, cmdPrefix) {
-			path = path[len(cmdPrefix):]
-			forceCmd = true
-		} else if bp, _ := build.Import(path, "", build.FindOnly); bp.Dir != "" && bp.ImportPath != "" {
-			fs.Bind(target, OS(bp.Dir), "/", bindReplace)
-			abspath = target
-			relpath = bp.ImportPath
-		} else {
-			abspath = pathpkg.Join(pkgHandler.fsRoot, path)
-		}
-		if relpath == "" {
-			relpath = abspath
-		}
+	{{ .Directive "T.M" "weight" }}
+	// The argument of a directive of a symbol, or "" for the package (see Directives)
 */
-func buildImport(target string) (string, string, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return "", "", err
-	}
-
-	relPath := target
-	absPath := target
-	if filepath.IsAbs(target) {
-		relPath, err = filepath.Rel(cwd, target) // filepath.Join(cwd, target)
-		if err != nil {
-			return "", "", err
-		}
-	} else {
-		absPath = filepath.Join(cwd, target)
-	}
-
-	modPath := filepath.Join(cwd, "go.mod")
-	modContents, err := os.ReadFile(modPath)
-	if err != nil {
-		return "", "", err
-	}
-	modFile, err := modfile.Parse("go.mod", modContents, nil)
-	if err != nil {
-		return "", "", err
-	}
-	modName := modFile.Module.Mod.Path
-	// Ensure we use forward slashes on windows
-	importPath := strings.ReplaceAll(filepath.Join(modName, relPath), "\\", "/")
-
-	return importPath, absPath, err
-
-}
-
-func loadDocument(target string) (*_document, error) {
-
-	importPath, absPath, err := buildImport(target)
-	if err != nil {
-		return nil, err
-	}
-
-	fset = token.NewFileSet()
-	pkgSet, err := parser.ParseDir(fset, absPath, func(file os.FileInfo) bool {
-		name := file.Name()
-		if name[0] != '.' && strings.HasSuffix(name, ".go") { //} && !strings.HasSuffix(name, "_test.go") {
-			return true
-		}
-		return false
-	}, parser.ParseComments)
-	if err != nil {
-		return nil, fmt.Errorf("Could not parse \"%s\": %v", absPath, err)
-	}
-
-	if read, err := ioutil.ReadFile(filepath.Join(absPath, ".godocdown.import")); err == nil {
-		importPath = strings.TrimSpace(strings.Split(string(read), "\n")[0])
-	}
-
-	{
-		isCommand := false
-		name := ""
-		var pkg *doc.Package
-		var testFiles map[string]*ast.File
-
-		// Choose the best package for documentation. Either
-		// documentation, main, or whatever the package is.
-		for _, parsePkg := range pkgSet {
-			// we don't want to document the test files, but we do need to keep
-			// them around to extract the examples from them.
-			astFiles := make(map[string]*ast.File)
-			for k, f := range parsePkg.Files {
-				if strings.HasSuffix(k, "_test.go") {
-					astFiles[k] = f
-					delete(parsePkg.Files, k)
-				}
-			}
-
-			tmpPkg := doc.New(parsePkg, ".", 0)
-			switch tmpPkg.Name {
-			case "main":
-				if isCommand || name != "" {
-					// We've already seen "package documentation"
-					// (or something else), so favor that over main.
-					continue
-				}
-				fallthrough
-			case "documentation":
-				// We're a command, this package/file contains the documentation
-				// path is used to get the containing directory in the case of
-				// command documentation
-
-				_, name = filepath.Split(absPath)
-				isCommand = true
-				pkg = tmpPkg
-			default:
-				// Just a regular package
-				name = tmpPkg.Name
-				pkg = tmpPkg
-				testFiles = astFiles
-			}
-		}
-
-		if pkg != nil {
-			var exs examples
-			for _, f := range testFiles {
-				for _, e := range doc.Examples(f) {
-					exs = append(exs, e)
-				}
-			}
-
-			sort.Sort(exs)
-			return &_document{
-				Name:       name,
-				pkg:        pkg,
-				absPath:    absPath,
-				testFiles:  testFiles,
-				IsCommand:  isCommand,
-				ImportPath: importPath,
-				Examples:   exs,
-			}, nil
-		}
-	}
-
-	return nil, nil
-}
-
-func emitString(fn func(*bytes.Buffer)) string {
-	var buffer bytes.Buffer
-	fn(&buffer)
-	trimSpace(&buffer)
-	return buffer.String()
-}
-
-// Emit
-func (self *_document) Emit() string {
-	return emitString(func(buffer *bytes.Buffer) {
-		self.EmitTo(buffer)
-	})
-}
-
-func (self *_document) EmitTo(buffer *bytes.Buffer) {
-
-	// Header
-	self.EmitHeaderTo(buffer)
-
-	// Synopsis
-	self.EmitSynopsisTo(buffer)
-
-	// Usage
-	if !self.IsCommand {
-		self.EmitUsageTo(buffer)
-	}
-
-	trimSpace(buffer)
-}
-
-// Signature
-func (self *_document) EmitSignature() string {
-	return emitString(func(buffer *bytes.Buffer) {
-		self.EmitSignatureTo(buffer)
-	})
-}
-
-func (self *_document) EmitSignatureTo(buffer *bytes.Buffer) {
-
-	renderSignatureTo(buffer)
-
-	trimSpace(buffer)
-}
-
-// Header
-func (self *_document) EmitHeader() string {
-	return emitString(func(buffer *bytes.Buffer) {
-		self.EmitHeaderTo(buffer)
-	})
-}
-
-func (self *_document) EmitHeaderTo(buffer *bytes.Buffer) {
-	renderHeaderTo(buffer, self)
-}
-
-// Synopsis
-func (self *_document) EmitSynopsis() string {
-	return emitString(func(buffer *bytes.Buffer) {
-		self.EmitSynopsisTo(buffer)
-	})
-}
-
-func (self *_document) EmitSynopsisTo(buffer *bytes.Buffer) {
-	renderSynopsisTo(buffer, self)
-}
-
-// Usage
-func (self *_document) EmitUsage() string {
-	return emitString(func(buffer *bytes.Buffer) {
-		self.EmitUsageTo(buffer)
-	})
-}
-
-func (self *_document) EmitUsageTo(buffer *bytes.Buffer) {
-	renderUsageTo(buffer, self)
-}
-
-var templateNameList = strings.Fields(`
-	.godocdown.markdown
-	.godocdown.md
-	.godocdown.template
-	.godocdown.tmpl
-`)
-
-func findTemplate(path string) string {
-
-	for _, templateName := range templateNameList {
-		templatePath := filepath.Join(path, templateName)
-		_, err := os.Stat(templatePath)
-		if err != nil {
-			if os.IsExist(err) {
-				continue
-			}
-			continue // Other error reporting?
-		}
-		return templatePath
-	}
-	return "" // Nothing found
-}
-
-func loadTemplate(document *_document) *Template.Template {
-	if *flag_noTemplate {
-		return nil
-	}
-
-	templatePath := *flag_template
-	if templatePath == "" {
-		templatePath = findTemplate(document.absPath)
-	}
-
-	if templatePath == "" {
-		return nil
-	}
-
-	template := Template.New("").Funcs(Template.FuncMap{})
-	template, err := template.ParseFiles(templatePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing template \"%s\": %v", templatePath, err)
-		os.Exit(1)
-	}
-	return template
-}
-
-func (self *_document) Badge() string {
-	return "[![GoDocDown](https://img.shields.io/badge/docs-generated-blue.svg?longCache=true)](https://github.com/aschey/godocdown)"
-}
-
-func (*_document) ToCode(code string) string {
-	return indentCode(code)
-}
-
-func (self *_document) Synopsis() string {
-	return headifySynopsis(filterText(self.pkg.Doc))
-}
-
-func (self *_document) Import() string {
-	return fmt.Sprintf(`import "%s"`, self.ImportPath)
-}
-
-func (self *_document) Funcs() []*doc.Func {
-	return self.pkg.Funcs
-}
-
-func (self *_document) Types() []*doc.Type {
-	return self.pkg.Types
-}
-
-func (self *_document) Consts() []*doc.Value {
-	return self.pkg.Consts
-}
-
-func (self *_document) Vars() []*doc.Value {
-	return self.pkg.Vars
-}
-
-type examples []*doc.Example
+package main
 
-func (exs examples) Len() int           { return len(exs) }
-func (exs examples) Less(i, j int) bool { return exs[i].Name < exs[j].Name }
-func (exs examples) Swap(i, j int)      { exs[i], exs[j] = exs[j], exs[i] }
+import (
+	"github.com/aschey/godocdown/pkg/docdown"
+)
 
 func main() {
-	flag.Parse(os.Args[1:])
-	target := flag.Arg(0)
-	fallbackUsage := false
-	if target == "" {
-		fallbackUsage = true
-		target = "."
-	}
-
-	RenderStyle.IncludeSignature = *flag_signature
-
-	switch *flag_heading {
-	case "1Word":
-		RenderStyle.SynopsisHeading = synopsisHeading1Word_Regexp
-	case "TitleCase":
-		RenderStyle.SynopsisHeading = synopsisHeadingTitleCase_Regexp
-	case "Title":
-		RenderStyle.SynopsisHeading = synopsisHeadingTitle_Regexp
-	case "TitleCase1Word":
-		RenderStyle.SynopsisHeading = synopsisHeadingTitleCase1Word_Regexp
-	case "", "-":
-		RenderStyle.SynopsisHeading = nil
-	}
-
-	document, err := loadDocument(target)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
-	}
-	if document == nil {
-		// Nothing found.
-		if fallbackUsage {
-			usage()
-			os.Exit(2)
-		} else {
-			fmt.Fprintf(os.Stderr, "Could not find package: %s\n", target)
-			os.Exit(1)
-		}
-	}
-
-	if *flag_noFuncs {
-		document.pkg.Funcs = nil
-		for i := range document.pkg.Types {
-			document.pkg.Types[i].Funcs = nil
-			document.pkg.Types[i].Methods = nil
-		}
-	}
-
-	tpl := loadTemplate(document)
-	var buffer bytes.Buffer
-	if tpl == nil {
-		document.EmitTo(&buffer)
-		document.EmitSignatureTo(&buffer)
-
-		// tpl, err = Template.New("").Funcs(Template.FuncMap{
-		// 	"indentCode": indentCode,
-		// 	"sourceOfNode": sourceOfNode,
-		// 	"indentNode": indentNode,
-		// 	"filterText": filterText,
-		// 	"exampleSubName": exampleSubName,
-		// 	"filterExamples": filterExamples,
-		// }).Parse(tplTxt)
-		//
-		// if err != nil {
-		// 	panic(err)
-		// }
-		//
-		// err = tpl.Execute(&buffer, document)
-		// if err != nil {
-		// 	panic(err)
-		// }
-	} else {
-		err := tpl.Templates()[0].Execute(&buffer, document)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error running template: %v", err)
-			os.Exit(1)
-		}
-		document.EmitSignatureTo(&buffer)
-	}
-
-	if debug {
-		// Skip printing if we're debugging
-		return
-	}
-
-	documentation := buffer.String()
-	documentation = strings.TrimSpace(documentation)
-	if flag_output == "" || flag_output == "-" {
-		fmt.Println(documentation)
-	} else {
-		file, err := os.Create(flag_output)
-		if err != nil {
-		}
-		defer file.Close()
-		_, err = fmt.Fprintln(file, documentation)
-	}
+	docdown.Main()
 }
@@ -0,0 +1,138 @@
+package docdown
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// announcementLimits are the most characters a message can have, for each
+// format of announcement: Slack shortens longer text, and Discord refuses it
+var announcementLimits = map[string]int{
+	"mrkdwn":  4000,
+	"discord": 2000,
+}
+
+var (
+	slackEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`,
+		// Keep mentions from pinging anyone
+		"@everyone", "@\u200beveryone", "@here", "@\u200bhere")
+)
+
+// announcement formats a message for Slack (mrkdwn) or Discord
+type announcement struct {
+	format string
+}
+
+func (self announcement) escape(text string) string {
+	if self.format == "mrkdwn" {
+		return slackEscaper.Replace(text)
+	}
+	return discordEscaper.Replace(text)
+}
+
+func (self announcement) bold(text string) string {
+	if self.format == "mrkdwn" {
+		return "*" + text + "*"
+	}
+	return "**" + text + "**"
+}
+
+// link links text (which is already formatted) to url, if there is one.
+// Discord shows no preview of links in angle brackets.
+func (self announcement) link(text, url string) string {
+	switch {
+	case url == "":
+		return text
+	case self.format == "mrkdwn":
+		return "<" + url + "|" + text + ">"
+	}
+	return "[" + text + "](<" + url + ">)"
+}
+
+// renderAnnouncementTo writes the announcement of the package, with
+// -format=mrkdwn or -format=discord, for a release bot to post: its name and
+// synopsis, and with -annotate-changes, the symbols new or changed since
+// then, linked to pkg.go.dev. Symbols are left out, and counted instead, to
+// keep it within the limit of a message.
+func renderAnnouncementTo(writer io.Writer, document *Document) error {
+	format := announcement{document.style.Format}
+	limit := announcementLimits[format.format]
+
+	url := ""
+	if first, _, _ := strings.Cut(document.ImportPath, "/"); strings.Contains(first, ".") {
+		url = "https://pkg.go.dev/" + document.ImportPath
+	}
+	name := document.ImportPath
+	if name == "" {
+		name = document.Name
+	}
+	lines := []string{format.bold(format.link(format.escape(name), url))}
+	if synopsis := doc.Synopsis(document.pkg.Doc); synopsis != "" {
+		if utf8.RuneCountInString(synopsis) > limit/4 {
+			synopsis = string([]rune(synopsis)[:limit/4]) + "…"
+		}
+		lines = append(lines, format.escape(synopsis))
+	}
+
+	ref := document.style.AnnotateChanges
+	if ref != "" {
+		var added, changed []string
+		for symbol, change := range document.changes {
+			if change == "added" {
+				added = append(added, symbol)
+			} else {
+				changed = append(changed, symbol)
+			}
+		}
+		sort.Strings(added)
+		sort.Strings(changed)
+		if len(added) == 0 && len(changed) == 0 {
+			lines = append(lines, "", format.escape(fmt.Sprintf("No API changes since %s", ref)))
+		}
+
+		// What is left once the headings of the lists, and the lines that
+		// count what is left out of them, are written
+		used := 0
+		for _, line := range lines {
+			used += utf8.RuneCountInString(line) + 1
+		}
+		budget := limit - used - 2*(len(ref)+40)
+		for _, list := range []struct {
+			title   string
+			symbols []string
+		}{
+			{"New since " + ref, added},
+			{"Changed since " + ref, changed},
+		} {
+			if len(list.symbols) == 0 {
+				continue
+			}
+			lines = append(lines, "", format.bold(format.escape(list.title)))
+			shown := 0
+			for _, symbol := range list.symbols {
+				link := ""
+				if url != "" {
+					link = url + "#" + symbol
+				}
+				line := "• " + format.link("`"+symbol+"`", link)
+				if utf8.RuneCountInString(line)+1 > budget {
+					break
+				}
+				budget -= utf8.RuneCountInString(line) + 1
+				lines = append(lines, line)
+				shown++
+			}
+			if hidden := len(list.symbols) - shown; hidden > 0 {
+				lines = append(lines, format.escape(fmt.Sprintf("…and %d more", hidden)))
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(writer, "%s\n", strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,116 @@
+package docdown
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"sort"
+)
+
+// apiSymbol is an exported symbol, as far as comparing APIs is concerned
+type apiSymbol struct {
+	Name      string // Foo, or Type.Method for methods
+	Kind      string // const, var, func, type, or method
+	Signature string // The declaration, without the body
+	Doc       string
+}
+
+// api lists the exported symbols of the document by name
+func (self *Document) api() map[string]apiSymbol {
+	api := map[string]apiSymbol{}
+	add := func(name, kind string, node interface{}, text string) {
+		api[name] = apiSymbol{
+			Name:      name,
+			Kind:      kind,
+			Signature: stripDirectives(unexportedFields(sourceOfNode(self.fset, node), DefaultStyle)),
+			Doc:       text,
+		}
+	}
+	values := func(kind string, list []*doc.Value) {
+		for _, value := range list {
+			for _, spec := range value.Decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				for _, name := range spec.Names {
+					if name.IsExported() {
+						add(name.Name, kind, &ast.GenDecl{Tok: value.Decl.Tok, Specs: []ast.Spec{spec}}, value.Doc)
+					}
+				}
+			}
+		}
+	}
+	funcs := func(list []*doc.Func) {
+		for _, entry := range list {
+			if entry.Recv == "" {
+				add(entry.Name, "func", entry.Decl, entry.Doc)
+			} else {
+				add(baseType(entry.Recv)+"."+entry.Name, "method", entry.Decl, entry.Doc)
+			}
+		}
+	}
+
+	values("const", self.pkg.Consts)
+	values("var", self.pkg.Vars)
+	funcs(self.pkg.Funcs)
+	for _, entry := range self.pkg.Types {
+		add(entry.Name, "type", &ast.GenDecl{Tok: token.TYPE, Specs: entry.Decl.Specs}, entry.Doc)
+		values("const", entry.Consts)
+		values("var", entry.Vars)
+		funcs(entry.Funcs)
+		funcs(entry.Methods)
+	}
+	return api
+}
+
+// baseType strips the pointer and type parameters from a receiver
+func baseType(receiver string) string {
+	for len(receiver) > 0 && receiver[0] == '*' {
+		receiver = receiver[1:]
+	}
+	for i, c := range receiver {
+		if c == '[' {
+			return receiver[:i]
+		}
+	}
+	return receiver
+}
+
+// apiChange is a difference between two versions of an API
+type apiChange struct {
+	Name   string
+	Change string // added, removed, changed, or documented
+	Old    apiSymbol
+	New    apiSymbol
+}
+
+// Symbol is the symbol as it is now, or as it was if it was removed
+func (self apiChange) Symbol() apiSymbol {
+	if self.Change == "removed" {
+		return self.Old
+	}
+	return self.New
+}
+
+// diffAPI compares two APIs. A nil old API means everything was added.
+func diffAPI(old, new map[string]apiSymbol) []apiChange {
+	var changes []apiChange
+	for name, symbol := range new {
+		before, ok := old[name]
+		switch {
+		case !ok:
+			changes = append(changes, apiChange{Name: name, Change: "added", New: symbol})
+		case before.Signature != symbol.Signature || before.Kind != symbol.Kind:
+			changes = append(changes, apiChange{Name: name, Change: "changed", Old: before, New: symbol})
+		case before.Doc != symbol.Doc:
+			changes = append(changes, apiChange{Name: name, Change: "documented", Old: before, New: symbol})
+		}
+	}
+	for name, symbol := range old {
+		if _, ok := new[name]; !ok {
+			changes = append(changes, apiChange{Name: name, Change: "removed", Old: symbol})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
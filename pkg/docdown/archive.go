@@ -0,0 +1,188 @@
+package docdown
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// tempDirs are removed when godocdown exits
+var tempDirs []string
+
+func removeTempDirs() {
+	for _, dir := range tempDirs {
+		os.RemoveAll(dir)
+	}
+}
+
+// isArchive reports whether target is a module zip or a tarball to be
+// documented rather than a directory
+func isArchive(target string) bool {
+	for _, extension := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(target, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractArchive extracts a module zip (as served by the module proxy, with
+// every file under "path@version/") or a tarball to a temporary directory
+// and returns the root of the module in it
+func extractArchive(archive string) (string, error) {
+	dir, err := os.MkdirTemp("", "godocdown-")
+	if err != nil {
+		return "", err
+	}
+	tempDirs = append(tempDirs, dir)
+
+	var names []string
+	create := func(name string, mode os.FileMode, contents io.Reader) error {
+		name = path.Clean(strings.TrimPrefix(name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("Invalid path in %s: %s", archive, name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(target), 0777)
+		if err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()|0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, contents)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		names = append(names, name)
+		return err
+	}
+
+	if strings.HasSuffix(archive, ".zip") {
+		err = extractZip(archive, create)
+	} else {
+		err = extractTar(archive, create)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Could not extract %s: %v", archive, err)
+	}
+
+	root, module := archiveRoot(names)
+	root = filepath.Join(dir, filepath.FromSlash(root))
+	if module != "" {
+		// Module zips only have a go.mod if the module does
+		modPath := filepath.Join(root, "go.mod")
+		if _, err := os.Stat(modPath); os.IsNotExist(err) {
+			err := os.WriteFile(modPath, []byte(fmt.Sprintf("module %s\n", module)), 0666)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	logger.Debug("extracted archive", "archive", archive, "root", root)
+	return root, nil
+}
+
+// archiveRoot finds the directory the files of an archive have in common:
+// "path@version" for a module zip, which is also returned as the module
+// path, or else the one directory at the top of a tarball like
+// "project-1.0"
+func archiveRoot(names []string) (root, module string) {
+	if len(names) == 0 {
+		return "", ""
+	}
+	if at := strings.Index(names[0], "@"); at > 0 {
+		if slash := strings.Index(names[0][at:], "/"); slash > 0 {
+			prefix := names[0][:at+slash+1]
+			common := true
+			for _, name := range names {
+				if !strings.HasPrefix(name, prefix) {
+					common = false
+					break
+				}
+			}
+			if common {
+				return strings.TrimSuffix(prefix, "/"), names[0][:at]
+			}
+		}
+	}
+	for {
+		first, _, found := strings.Cut(names[0], "/")
+		if !found {
+			return root, ""
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, first+"/") {
+				return root, ""
+			}
+		}
+		root = path.Join(root, first)
+		for i, name := range names {
+			names[i] = name[len(first)+1:]
+		}
+	}
+}
+
+func extractZip(archive string, create func(string, os.FileMode, io.Reader) error) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		contents, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		err = create(entry.Name, entry.Mode(), contents)
+		contents.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTar(archive string, create func(string, os.FileMode, io.Reader) error) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var input io.Reader = file
+	if !strings.HasSuffix(archive, ".tar") {
+		compressed, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer compressed.Close()
+		input = compressed
+	}
+	reader := tar.NewReader(input)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		err = create(header.Name, header.FileInfo().Mode(), reader)
+		if err != nil {
+			return err
+		}
+	}
+}
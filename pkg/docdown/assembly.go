@@ -0,0 +1,108 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// assemblyText_Regexp matches the TEXT directive that starts a function in
+// an assembly file, with its symbol (after the middle dot of the package)
+var assemblyText_Regexp = regexp.MustCompile(`(?m)^\s*TEXT\s+[^·\s]*·([^(\s]+|\(\*?\w+\)\.\w+)\(SB\)`)
+
+// knownArches are the values of GOARCH an assembly file can be named after,
+// like sum_amd64.s
+var knownArches = map[string]bool{
+	"386": true, "amd64": true, "arm": true, "arm64": true, "loong64": true,
+	"mips": true, "mipsle": true, "mips64": true, "mips64le": true,
+	"ppc64": true, "ppc64le": true, "riscv64": true, "s390x": true, "wasm": true,
+}
+
+// assemblyFuncs maps the name of each function (or T.M, for methods) that
+// files declare without a body to the architectures of the assembly files
+// in the root of fsys that implement it (none, for files that are not named
+// after one). It has to be read before go/doc takes out the bodies.
+func assemblyFuncs(fsys fs.FS, files map[string]*ast.File) map[string][]string {
+	declared := map[string]bool{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && decl.Body == nil {
+				name := decl.Name.Name
+				if decl.Recv != nil && len(decl.Recv.List) > 0 {
+					name = receiverName(decl.Recv.List[0].Type) + "." + name
+				}
+				declared[name] = true
+			}
+		}
+	}
+	if len(declared) == 0 {
+		return nil
+	}
+	result := map[string][]string{}
+	for name := range declared {
+		result[name] = []string{}
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return result
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".s") {
+			continue
+		}
+		contents, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(entry.Name(), ".s"), "_")
+		arch := parts[len(parts)-1]
+		if len(parts) == 1 || !knownArches[arch] {
+			continue
+		}
+		for _, match := range assemblyText_Regexp.FindAllStringSubmatch(string(contents), -1) {
+			// ·(*T).M is the method M of T
+			name := strings.NewReplacer("(*", "", "(", "", ")", "").Replace(match[1])
+			if _, ok := result[name]; ok && !containsString(result[name], arch) {
+				result[name] = append(result[name], arch)
+			}
+		}
+	}
+	for _, arches := range result {
+		sort.Strings(arches)
+	}
+	return result
+}
+
+// containsString reports whether list has value
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// assemblyNote notes, with -asm, that a function is implemented in
+// assembly, and for which architectures, followed by a blank line, or is ""
+func (self *Document) assemblyNote(entry *doc.Func) string {
+	if !self.style.Assembly {
+		return ""
+	}
+	name := entry.Name
+	if entry.Recv != "" {
+		name = baseType(entry.Recv) + "." + name
+	}
+	arches, ok := self.assembly[name]
+	if !ok {
+		return ""
+	}
+	if len(arches) == 0 {
+		return fmt.Sprintf("*%s.*\n\n", self.style.text("Implemented in assembly"))
+	}
+	return fmt.Sprintf("*%s (%s).*\n\n", self.style.text("Implemented in assembly"), strings.Join(arches, ", "))
+}
@@ -0,0 +1,115 @@
+package docdown
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// image_Regexp matches the images of doc text, in Markdown (![alt](link))
+// or HTML (<img src="link">), capturing what comes before, the link, and
+// what comes after
+var image_Regexp = regexp.MustCompile(`(!\[[^\]]*\]\()([^)\s]+)(\))|(<img\b[^>]*?\bsrc=["'])([^"']+)(["'])`)
+
+// isLocalLink reports whether link is a relative path rather than a URL, an
+// absolute path, or an anchor
+func isLocalLink(link string) bool {
+	return link != "" && !strings.Contains(link, ":") && !strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "#")
+}
+
+// assetTarget is where an asset at link (relative to its package) is copied
+// to, relative to the page: the same place, unless that is outside of the
+// page's directory
+func assetTarget(link string) string {
+	clean := path.Clean(link)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return path.Join("assets", path.Base(clean))
+	}
+	return clean
+}
+
+// rewriteAssets finds the local images doc text refers to, relative to the
+// package directory, and keeps them working from the page the document is
+// written to: with CopyAssets, they are copied next to the page (see
+// copyAssets), and otherwise the links are made relative to the page
+func (self *Document) rewriteAssets(text string) string {
+	if self.page == "" {
+		return text
+	}
+	page, err := filepath.Abs(self.page)
+	if err != nil {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		lines[i] = image_Regexp.ReplaceAllStringFunc(line, func(match string) string {
+			parts := image_Regexp.FindStringSubmatch(match)
+			before, link, after := parts[1], parts[2], parts[3]
+			if before == "" {
+				before, link, after = parts[4], parts[5], parts[6]
+			}
+			if !isLocalLink(link) {
+				return match
+			}
+			source := filepath.Join(self.absPath, filepath.FromSlash(link))
+			if info, err := os.Stat(source); err != nil || !info.Mode().IsRegular() {
+				return match
+			}
+			if self.assets == nil {
+				self.assets = map[string]bool{}
+			}
+			self.assets[link] = true
+			if self.style.CopyAssets {
+				return before + assetTarget(link) + after
+			}
+			relative, err := filepath.Rel(filepath.Dir(page), source)
+			if err != nil {
+				return match
+			}
+			return before + filepath.ToSlash(relative) + after
+		})
+	}
+	return strings.Join(lines, "")
+}
+
+// assetList is the sorted list of the assets the document refers to
+func (self *Document) assetList() []string {
+	var list []string
+	for link := range self.assets {
+		list = append(list, link)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// copyAssets copies the assets at links (relative to absPath, the package
+// directory) to where rewriteAssets links to them from page
+func copyAssets(absPath, page string, links []string) error {
+	for _, link := range links {
+		source := filepath.Join(absPath, filepath.FromSlash(link))
+		target := filepath.Join(filepath.Dir(page), filepath.FromSlash(assetTarget(link)))
+		if canonicalPath(source) == canonicalPath(target) {
+			continue
+		}
+		contents, err := os.ReadFile(source)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(target, contents, 0644)
+		if err != nil {
+			return err
+		}
+		logger.Debug("copied asset", "source", source, "target", target)
+	}
+	return nil
+}
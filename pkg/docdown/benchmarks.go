@@ -0,0 +1,165 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// benchmark is a result of "go test -bench", with its numbers as go test
+// printed them. BytesPerOp and AllocsPerOp are "" without -benchmem.
+type benchmark struct {
+	Name        string
+	Iterations  string
+	NsPerOp     string
+	BytesPerOp  string
+	AllocsPerOp string
+}
+
+// benchmarks are the results of running the package's benchmarks, and the
+// platform they ran on
+type benchmarks struct {
+	List     []benchmark
+	Platform string // Like "linux/amd64"
+	CPU      string
+}
+
+// benchmark_Regexp matches a result line, like
+// "BenchmarkParse-8   	  10000	    112345 ns/op	  4096 B/op	  12 allocs/op",
+// without the GOMAXPROCS suffix of the name
+var benchmark_Regexp = regexp.MustCompile(`^(Benchmark\S*?)(?:-\d+)?\s+(\d+)\s+(\S+) ns/op(.*)$`)
+
+// parseBenchmarks reads the output of "go test -bench"
+func parseBenchmarks(output string) benchmarks {
+	var result benchmarks
+	goos, goarch := "", ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "goos: "); ok {
+			goos = value
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "goarch: "); ok {
+			goarch = value
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "cpu: "); ok {
+			result.CPU = value
+			continue
+		}
+		match := benchmark_Regexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		entry := benchmark{Name: match[1], Iterations: match[2], NsPerOp: match[3]}
+		// The other measurements come in pairs of a value and its unit
+		fields := strings.Fields(match[4])
+		for i := 0; i+1 < len(fields); i += 2 {
+			switch fields[i+1] {
+			case "B/op":
+				entry.BytesPerOp = fields[i]
+			case "allocs/op":
+				entry.AllocsPerOp = fields[i]
+			}
+		}
+		result.List = append(result.List, entry)
+	}
+	if goos != "" && goarch != "" {
+		result.Platform = goos + "/" + goarch
+	}
+	return result
+}
+
+// goTest runs "go test" with arguments on the package in absPath, returning
+// what it writes, or an error with the failures (or what went wrong)
+func goTest(ctx context.Context, absPath string, arguments ...string) (string, error) {
+	command := exec.CommandContext(ctx, "go", append([]string{"test"}, arguments...)...)
+	command.Dir = absPath
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if ctx.Err() != nil {
+			message = canceled(ctx).Error()
+		} else if message == "" {
+			// go test writes failures to stdout, after the results that
+			// came before them
+			message = string(output)
+			if start := strings.Index(message, "--- FAIL"); start >= 0 {
+				message = message[start:]
+			}
+			message = strings.TrimSpace(message)
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("%s", message)
+	}
+	return string(output), nil
+}
+
+// runBenchmarks runs the benchmarks of the package in absPath that match the
+// style's BenchmarkPattern, each for BenchmarkTime, and no tests
+func runBenchmarks(ctx context.Context, absPath string, style Style) (benchmarks, error) {
+	output, err := goTest(ctx, absPath, "-run=^$", "-bench="+style.BenchmarkPattern, "-benchtime="+style.BenchmarkTime, "-benchmem", ".")
+	if err != nil {
+		return benchmarks{}, fmt.Errorf("Could not run benchmarks: %v", err)
+	}
+	result := parseBenchmarks(output)
+	logger.Debug("ran benchmarks", "package", absPath, "count", len(result.List))
+	return result, nil
+}
+
+// renderBenchmarksTo writes the results of the benchmarks (with
+// RunBenchmarks) under BenchmarksHeader
+func renderBenchmarksTo(writer io.Writer, document *Document) {
+	if len(document.benchmarks.List) == 0 {
+		return
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.BenchmarksHeader))
+	withMemory := document.benchmarks.List[0].AllocsPerOp != ""
+	if style.tables() {
+		fmt.Fprintf(writer, "| %s | %s | ns/op |", style.text("Benchmark"), style.text("Iterations"))
+		if withMemory {
+			fmt.Fprintf(writer, " B/op | allocs/op |")
+		}
+		fmt.Fprintf(writer, "\n| --- | ---: | ---: |")
+		if withMemory {
+			fmt.Fprintf(writer, " ---: | ---: |")
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+	for _, entry := range document.benchmarks.List {
+		if style.tables() {
+			fmt.Fprintf(writer, "| `%s` | %s | %s |", entry.Name, entry.Iterations, entry.NsPerOp)
+			if withMemory {
+				fmt.Fprintf(writer, " %s | %s |", entry.BytesPerOp, entry.AllocsPerOp)
+			}
+			fmt.Fprintf(writer, "\n")
+			continue
+		}
+		fmt.Fprintf(writer, " - `%s`: %s ns/op", entry.Name, entry.NsPerOp)
+		if withMemory {
+			fmt.Fprintf(writer, ", %s B/op, %s allocs/op", entry.BytesPerOp, entry.AllocsPerOp)
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+	fmt.Fprintf(writer, "\n")
+
+	var platform []string
+	if document.benchmarks.Platform != "" {
+		platform = append(platform, document.benchmarks.Platform)
+	}
+	if document.benchmarks.CPU != "" {
+		platform = append(platform, document.benchmarks.CPU)
+	}
+	if len(platform) > 0 {
+		fmt.Fprintf(writer, "%s %s.\n\n", style.text("Measured on"), strings.Join(platform, ", "))
+	}
+}
@@ -0,0 +1,136 @@
+package docdown
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// blockElement is a spec of a grouped const or var declaration, with its
+// doc and line comments, or a comment of the group that is not attached to
+// a spec, like the heading of a sub-group
+type blockElement struct {
+	spec       ast.Spec
+	comment    *ast.CommentGroup
+	start, end int // The lines it spans
+}
+
+// blockLayouts maps the position of each grouped const and var declaration
+// in files to its elements, in order, to keep its layout when go/doc takes
+// some of its specs out. Like the directives, it has to be read before
+// go/doc drops the comments that are not attached to declarations.
+func blockLayouts(fset *token.FileSet, files map[string]*ast.File) map[token.Pos][]blockElement {
+	result := map[token.Pos][]blockElement{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || (decl.Tok != token.CONST && decl.Tok != token.VAR) || !decl.Lparen.IsValid() {
+				continue
+			}
+			attached := map[*ast.CommentGroup]bool{}
+			var elements []blockElement
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				start, end := spec.Pos(), spec.End()
+				if spec.Doc != nil {
+					attached[spec.Doc] = true
+					start = spec.Doc.Pos()
+				}
+				if spec.Comment != nil {
+					attached[spec.Comment] = true
+					end = spec.Comment.End()
+				}
+				elements = append(elements, blockElement{spec: spec, start: fset.Position(start).Line, end: fset.Position(end).Line})
+			}
+			for _, group := range file.Comments {
+				if decl.Lparen < group.Pos() && group.End() < decl.Rparen && !attached[group] {
+					elements = append(elements, blockElement{comment: group, start: fset.Position(group.Pos()).Line, end: fset.Position(group.End()).Line})
+				}
+			}
+			sort.SliceStable(elements, func(i, j int) bool {
+				return elements[i].start < elements[j].start
+			})
+			result[decl.Pos()] = elements
+		}
+	}
+	return result
+}
+
+// valueBlock is a grouped const or var declaration as it should be printed,
+// parsed again from the specs go/doc left in it, with the comments of its
+// sub-groups and the blank lines between them where the source has them.
+// It is nil if the declaration can be printed as it is.
+func (self *Document) valueBlock(node ast.Node) (*token.FileSet, *printer.CommentedNode) {
+	decl, ok := node.(*ast.GenDecl)
+	if !ok {
+		return nil, nil
+	}
+	elements := self.blocks[decl.Pos()]
+	kept := map[ast.Spec]bool{}
+	for _, spec := range decl.Specs {
+		kept[spec] = true
+	}
+	floating := false
+	for _, element := range elements {
+		floating = floating || element.comment != nil
+	}
+	if len(elements) == 0 || (len(kept) == len(elements) && !floating) {
+		return nil, nil
+	}
+
+	// A comment stays if a spec it is over does (up to the next comment)
+	keep := make([]bool, len(elements))
+	for i := len(elements) - 1; i >= 0; i-- {
+		if elements[i].spec != nil {
+			keep[i] = kept[elements[i].spec]
+			continue
+		}
+		keep[i] = len(decl.Specs) > 0
+		for j := i + 1; j < len(elements); j++ {
+			if elements[j].comment != nil || keep[j] {
+				keep[i] = keep[j]
+				break
+			}
+			keep[i] = false
+		}
+	}
+
+	var source strings.Builder
+	source.WriteString("package p\n\n" + decl.Tok.String() + " (\n")
+	previous := -1
+	for i, element := range elements {
+		if !keep[i] {
+			continue
+		}
+		if previous >= 0 {
+			// A blank line between them if there is one anywhere between
+			// them in the source
+			for j := previous; j < i; j++ {
+				if elements[j+1].start-elements[j].end > 1 {
+					source.WriteString("\n")
+					break
+				}
+			}
+		}
+		previous = i
+		if element.comment != nil {
+			for _, comment := range element.comment.List {
+				source.WriteString(comment.Text + "\n")
+			}
+			continue
+		}
+		source.WriteString(sourceOfNode(self.fset, element.spec) + "\n")
+	}
+	source.WriteString(")\n")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source.String(), parser.ParseComments)
+	if err != nil || len(file.Decls) != 1 {
+		self.style.log().Debug("could not keep the layout of a declaration", "package", self.absPath, "error", err)
+		return nil, nil
+	}
+	return fset, &printer.CommentedNode{Node: file.Decls[0], Comments: file.Comments}
+}
@@ -0,0 +1,207 @@
+package docdown
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	Flag "flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	Debug "runtime/debug"
+	"sort"
+	"strings"
+)
+
+// cache stores generated documentation on disk, keyed by a hash of
+// everything that went into generating it: the package's Go files, its
+// template and other .godocdown files, the options, and godocdown itself.
+type cache struct {
+	dir string
+}
+
+// uncachedFlags do not affect the generated documentation
+var uncachedFlags = map[string]bool{
+	"jobs":      true,
+	"cache":     true,
+	"cache-dir": true,
+	"index":     true,
+	"config":    true,
+	"timeout":   true,
+}
+
+// openCache returns the cache to use for this run, or nil if caching is
+// disabled
+func openCache() (*cache, error) {
+	if !*flag_cache {
+		return nil, nil
+	}
+	dir := *flag_cacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("Could not find the cache directory: %v", err)
+		}
+		dir = filepath.Join(userDir, "godocdown")
+	}
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create the cache directory: %v", err)
+	}
+	return &cache{dir: dir}, nil
+}
+
+// key hashes the inputs for the package in the given directory and locale,
+// including the other packages it can link to and the go.mod of its module
+func (self *cache) key(absPath string, locale string, links packageLinks) (string, error) {
+	hash := sha256.New()
+
+	if info, ok := Debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(hash, "%s\n", info)
+	}
+
+	flag.VisitAll(func(f *Flag.Flag) {
+		if !uncachedFlags[f.Name] {
+			fmt.Fprintf(hash, "-%s=%s\n", f.Name, f.Value)
+		}
+	})
+
+	fmt.Fprintf(hash, "locale=%s\n", locale)
+
+	// The module decides the import path of the package
+	fmt.Fprintf(hash, "dir=%s\n", absPath)
+	if _, root, err := findModule(absPath); err == nil {
+		goMod := filepath.Join(root, "go.mod")
+		fmt.Fprintf(hash, "go.mod=%s\n", goMod)
+		err := hashFile(hash, goMod)
+		if err != nil {
+			return "", err
+		}
+	}
+	if pkg, ok := listed[absPath]; ok {
+		fmt.Fprintf(hash, "listed=%s %q %q %q\n", pkg.ImportPath, pkg.GoFiles, pkg.TestGoFiles, pkg.XTestGoFiles)
+	}
+
+	var linked []string
+	for importPath, page := range links {
+		linked = append(linked, importPath+"="+page)
+	}
+	sort.Strings(linked)
+	fmt.Fprintf(hash, "%s\n", strings.Join(linked, "\n"))
+
+	files, err := ioutil.ReadDir(absPath)
+	if err != nil {
+		return "", err
+	}
+	var paths []string
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".go") || strings.HasPrefix(name, ".godocdown") {
+			paths = append(paths, filepath.Join(absPath, name))
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range []string{*flag_template, *flag_translations, *flag_footerTemplate} {
+		if locale == "" && strings.Contains(path, localePlaceholder) {
+			continue // Only for the other locales
+		}
+		path = localize(path, locale)
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	for _, path := range paths {
+		err := hashFile(hash, path)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasSuffix(path, ".go") {
+			for _, included := range includedPaths(absPath, path) {
+				fmt.Fprintf(hash, "include=%s\n", included)
+				hashFile(hash, included) // A missing file is only a warning
+			}
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashFile(hash io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fmt.Fprintf(hash, "%s\n", filepath.Base(path))
+	_, err = io.Copy(hash, file)
+	return err
+}
+
+func (self *cache) path(key string) string {
+	return filepath.Join(self.dir, key[:2], key)
+}
+
+// get opens the cached documentation for key, and reads the package
+// information stored with it into info
+func (self *cache) get(key string, info *packageInfo) (io.ReadCloser, bool) {
+	read, err := ioutil.ReadFile(self.path(key) + ".json")
+	if err != nil {
+		return nil, false
+	}
+	err = json.Unmarshal(read, info)
+	if err != nil {
+		return nil, false
+	}
+	file, err := os.Open(self.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return file, true
+}
+
+// cacheEntry is an entry being written to the cache. It only becomes visible
+// to readers once it is committed.
+type cacheEntry struct {
+	*os.File
+	path string
+}
+
+func (self *cache) create(key string) (*cacheEntry, error) {
+	path := self.path(key)
+	err := os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return nil, err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), key+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &cacheEntry{File: file, path: path}, nil
+}
+
+// commit stores the entry along with the package information
+func (self *cacheEntry) commit(info packageInfo) error {
+	err := self.File.Close()
+	if err == nil {
+		err = os.Rename(self.Name(), self.path)
+	}
+	if err != nil {
+		os.Remove(self.Name())
+		return err
+	}
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(self.path+".json", encoded, 0666)
+}
+
+func (self *cacheEntry) abort() {
+	self.File.Close()
+	os.Remove(self.Name())
+}
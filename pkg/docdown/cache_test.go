@@ -0,0 +1,79 @@
+package docdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles writes files (by their paths, relative to dir) with their
+// contents
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, []byte(contents), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":       "module example.com/before\n\ngo 1.21\n",
+		"pkg/pkg.go":   "// Package pkg is a package.\npackage pkg\n",
+		"pkg/notes.md": "Not an input",
+	})
+	dir := filepath.Join(root, "pkg")
+	cache := &cache{dir: t.TempDir()}
+	key := func() string {
+		t.Helper()
+		key, err := cache.key(dir, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	first := key()
+	if again := key(); again != first {
+		t.Errorf("key changed without a change: %s, then %s", first, again)
+	}
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+		same  bool
+	}{
+		{"other file", map[string]string{"pkg/notes.md": "Still not an input"}, true},
+		{"Go file", map[string]string{"pkg/pkg.go": "// Package pkg is changed.\npackage pkg\n"}, false},
+		{"config", map[string]string{"pkg/.godocdown.yaml": "flavor: plain\n"}, false},
+		{"renamed module", map[string]string{"go.mod": "module example.com/after\n\ngo 1.21\n"}, false},
+	} {
+		before := key()
+		writeFiles(t, root, test.files)
+		if after := key(); (after == before) != test.same {
+			t.Errorf("%s: key changed is %v, expected %v", test.name, after != before, !test.same)
+		}
+	}
+
+	links := packageLinks{"example.com/after/other": "../other/README.md"}
+	linked, err := cache.key(dir, "", links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked == key() {
+		t.Errorf("key did not change with the links to other packages")
+	}
+	localized, err := cache.key(dir, "fr", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localized == key() {
+		t.Errorf("key did not change with the locale")
+	}
+}
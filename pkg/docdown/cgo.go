@@ -0,0 +1,130 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// cgoFlags_Regexp matches a "#cgo" line of a cgo preamble, with its
+	// (optional) build constraints, the variable it sets, and its value
+	cgoFlags_Regexp = regexp.MustCompile(`^#cgo\s+(?:.*\s)?([A-Za-z_-]+):\s*(.*)$`)
+
+	// cgoInclude_Regexp matches an "#include" line of a cgo preamble
+	cgoInclude_Regexp = regexp.MustCompile(`^#\s*include\s*[<"]([^>"]+)[>"]`)
+)
+
+// cgoPreambles are the preambles of the cgo files in files: the comments
+// above their `import "C"`. Like the directives, they have to be read
+// before go/doc takes the comments out of the files.
+func cgoPreambles(files map[string]*ast.File) []string {
+	var preambles []string
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec, ok := spec.(*ast.ImportSpec)
+				if !ok {
+					continue
+				}
+				if importPath, _ := strconv.Unquote(spec.Path.Value); importPath != "C" {
+					continue
+				}
+				preamble := spec.Doc
+				if preamble == nil && len(decl.Specs) == 1 {
+					preamble = decl.Doc
+				}
+				if preamble != nil {
+					preambles = append(preambles, preamble.Text())
+				}
+			}
+		}
+	}
+	sort.Strings(preambles)
+	return preambles
+}
+
+// cgoDependencies is what the cgo preambles of a package need to build: the
+// headers they include, the libraries they link (with -l in LDFLAGS), and
+// the pkg-config packages they use
+type cgoDependencies struct {
+	Headers   []string
+	Libraries []string
+	Packages  []string
+}
+
+// cgoDependencies reads the dependencies of the package's cgo preambles
+func (self *Document) cgoDependencies() cgoDependencies {
+	var result cgoDependencies
+	seen := map[string]bool{}
+	add := func(list *[]string, kind, name string) {
+		if name != "" && !seen[kind+name] {
+			seen[kind+name] = true
+			*list = append(*list, name)
+		}
+	}
+	for _, preamble := range self.cgo {
+		for _, line := range strings.Split(preamble, "\n") {
+			line = strings.TrimSpace(line)
+			if match := cgoInclude_Regexp.FindStringSubmatch(line); match != nil {
+				add(&result.Headers, "header", match[1])
+				continue
+			}
+			match := cgoFlags_Regexp.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			for _, field := range strings.Fields(match[2]) {
+				switch match[1] {
+				case "LDFLAGS":
+					if library, ok := strings.CutPrefix(field, "-l"); ok {
+						add(&result.Libraries, "library", library)
+					}
+				case "pkg-config":
+					if !strings.HasPrefix(field, "-") {
+						add(&result.Packages, "package", field)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(result.Headers)
+	sort.Strings(result.Libraries)
+	sort.Strings(result.Packages)
+	return result
+}
+
+// renderCgoTo writes, under CgoHeader, the C dependencies of a package that
+// uses cgo, with -imports
+func renderCgoTo(writer io.Writer, document *Document) {
+	if !document.style.IncludeImports {
+		return
+	}
+	dependencies := document.cgoDependencies()
+	if len(dependencies.Headers)+len(dependencies.Libraries)+len(dependencies.Packages) == 0 {
+		return
+	}
+	code := func(list []string) string {
+		return "`" + strings.Join(list, "`, `") + "`"
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.CgoHeader))
+	if len(dependencies.Packages) > 0 {
+		fmt.Fprintf(writer, " - %s: %s\n", style.text("pkg-config packages"), code(dependencies.Packages))
+	}
+	if len(dependencies.Libraries) > 0 {
+		fmt.Fprintf(writer, " - %s: %s\n", style.text("Libraries"), code(dependencies.Libraries))
+	}
+	if len(dependencies.Headers) > 0 {
+		fmt.Fprintf(writer, " - %s: %s\n", style.text("Headers"), code(dependencies.Headers))
+	}
+	fmt.Fprintf(writer, "\n")
+}
@@ -0,0 +1,119 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"io"
+)
+
+// loadChanges compares the API of the package with what it was at ref, for
+// labeling the symbols that were added or changed since. If the package did
+// not exist at ref, all of its symbols are new.
+func (self *Document) loadChanges(ctx context.Context, ref string) error {
+	old, err := loadDocumentAt(ctx, self.absPath, self.ImportPath, ref, self.style)
+	if err != nil {
+		return err
+	}
+	var before map[string]apiSymbol
+	if old != nil {
+		before = old.api()
+	}
+	self.changes = map[string]string{}
+	for _, change := range diffAPI(before, self.api()) {
+		if change.Change == "added" || change.Change == "changed" {
+			self.changes[change.Name] = change.Change
+		}
+	}
+	return nil
+}
+
+// declaredNames are the exported names node declares, as api has them (Foo,
+// or Type.Method)
+func declaredNames(node ast.Node) []string {
+	var names []string
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		name := node.Name.Name
+		if node.Recv != nil && len(node.Recv.List) > 0 {
+			name = receiverType(node.Recv.List[0].Type) + "." + name
+		}
+		names = append(names, name)
+	case *ast.GenDecl:
+		for _, spec := range node.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					if name.IsExported() {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// receiverType is the name of the type of a receiver, without the pointer
+// or type parameters
+func receiverType(expr ast.Expr) string {
+	for {
+		switch node := expr.(type) {
+		case *ast.StarExpr:
+			expr = node.X
+		case *ast.ParenExpr:
+			expr = node.X
+		case *ast.IndexExpr:
+			expr = node.X
+		case *ast.IndexListExpr:
+			expr = node.X
+		case *ast.Ident:
+			return node.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// changeOf is how the declaration node changed since the style's
+// AnnotateChanges: "added" if all it declares is new, "changed" if some of
+// it is new or changed, or "" if none of it is
+func (self *Document) changeOf(node ast.Node) string {
+	if len(self.changes) == 0 {
+		return ""
+	}
+	added, changed := 0, 0
+	names := declaredNames(node)
+	for _, name := range names {
+		switch self.changes[name] {
+		case "added":
+			added++
+		case "changed":
+			changed++
+		}
+	}
+	switch {
+	case added > 0 && added == len(names):
+		return "added"
+	case added > 0 || changed > 0:
+		return "changed"
+	}
+	return ""
+}
+
+// renderChangeLabelTo writes "New since" or "Changed since" the style's
+// AnnotateChanges for a declaration that was added or changed since
+func renderChangeLabelTo(writer io.Writer, document *Document, node ast.Node) {
+	label := ""
+	switch document.changeOf(node) {
+	case "added":
+		label = document.style.text("New since")
+	case "changed":
+		label = document.style.text("Changed since")
+	default:
+		return
+	}
+	fmt.Fprintf(writer, "*%s %s*\n\n", label, document.style.AnnotateChanges)
+}
@@ -0,0 +1,48 @@
+package docdown
+
+import (
+	"strings"
+	"unicode"
+)
+
+// headingID is the identifier pandoc gives a heading with the given text,
+// which the commonmark flavor links to instead of adding anchors of its own:
+// "func (*T) Close" is "func-t-close"
+func headingID(text string) string {
+	var id strings.Builder
+	letter := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case !letter:
+			// Everything up to the first letter is left out
+			continue
+		case unicode.IsSpace(r):
+			r = '-'
+		case !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.':
+			continue
+		}
+		id.WriteRune(r)
+	}
+	if id.Len() == 0 {
+		return "section"
+	}
+	return id.String()
+}
+
+// tables reports whether the flavor has tables, which plain and commonmark
+// Markdown lack
+func (self Style) tables() bool {
+	return self.Flavor != "plain" && self.Flavor != "commonmark"
+}
+
+// commonmarkExampleHeading is the heading of an example in the commonmark
+// flavor, which names what the example is for so that its identifier is
+// unique: "Example T.M (Suffix)"
+func (self *Document) commonmarkExampleHeading(name string) string {
+	if self.style.ExampleTitle != nil {
+		return self.exampleHeading(name)
+	}
+	return self.style.text("Example") + " " + exampleTitle(name, self.style)
+}
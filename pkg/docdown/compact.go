@@ -0,0 +1,187 @@
+package docdown
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// signatureHeading reports whether the heading of the function node shows
+// its signature, in place of its code block, as it does with -compact: but
+// for commonmark, whose anchors come from the text of the headings, and for
+// functions declared differently for different platforms
+func (self *Document) signatureHeading(node ast.Node) bool {
+	return self.style.Compact && self.style.Flavor != "commonmark" && self.platformVariants(node) == nil
+}
+
+// renderSignatureHeadingTo writes the heading of a function as its
+// signature, on one line, with an anchor for links to it
+func renderSignatureHeadingTo(writer io.Writer, document *Document, header, anchor string, node ast.Node) {
+	signature := "`" + strings.Join(strings.Fields(sourceOfNode(document.fset, node)), " ") + "`"
+	if document.style.Flavor == "gomarkdoc" {
+		fmt.Fprintf(writer, "<a name=\"%s\"></a>\n%s %s\n\n", anchor, header, signature)
+		return
+	}
+	fmt.Fprintf(writer, "%s %s {#%s}\n\n", header, signature, anchor)
+}
+
+// renderCompactExampleTo writes an example without the collapsible section
+// around it: its title, its doc, its code, and its output, if it has one
+func renderCompactExampleTo(writer io.Writer, document *Document, example *doc.Example, code string) {
+	fmt.Fprintf(writer, "*%s:*\n\n%s%s\n\n", document.exampleHeading(example.Name), paragraph(document, example.Doc), code)
+	if example.Output != "" {
+		fmt.Fprintf(writer, "%s:\n\n```%s\n%s```\n\n", document.style.text("Output"), document.exampleOutputLanguage(example.Name), example.Output)
+	}
+}
+
+// compactATX_Regexp matches an ATX heading, with its level
+var compactATX_Regexp = regexp.MustCompile(`^(#{1,6})(?:[ \t]|$)`)
+
+// compactFence_Regexp matches the opening or closing line of a fenced code
+// block
+var compactFence_Regexp = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+
+// compactOrdered_Regexp matches an item of an ordered list
+var compactOrdered_Regexp = regexp.MustCompile(`^\d+[.)] `)
+
+// closesFence reports whether line closes the code block opened with fence
+func closesFence(line, fence string) bool {
+	match := compactFence_Regexp.FindStringSubmatch(line)
+	return match != nil && strings.HasPrefix(match[1], fence) && strings.TrimSpace(line) == match[1]
+}
+
+// compactWriter holds documentation back until it is closed, to write it
+// compacted (see compactMarkdown)
+type compactWriter struct {
+	writer io.Writer
+	buffer bytes.Buffer
+}
+
+func newCompactWriter(writer io.Writer) *compactWriter {
+	return &compactWriter{writer: writer}
+}
+
+func (self *compactWriter) Write(p []byte) (int, error) {
+	return self.buffer.Write(p)
+}
+
+// Close writes the documentation, compacted
+func (self *compactWriter) Close() error {
+	_, err := io.WriteString(self.writer, compactMarkdown(self.buffer.String()))
+	return err
+}
+
+// compactMarkdown takes the sections with nothing in them (but for the
+// title) out of Markdown, and the blank lines it can do without: those after
+// headings and code blocks, those before code blocks that follow a
+// paragraph, and all but one in a row. The blank lines that end HTML blocks,
+// lists, and tables are kept.
+func compactMarkdown(text string) string {
+	lines := dropEmptySections(strings.Split(text, "\n"))
+	var result []string
+	fence := ""
+	closed := false // Whether the last line closed a code block
+	for i, line := range lines {
+		if fence != "" {
+			result = append(result, line)
+			if closesFence(line, fence) {
+				fence = ""
+				closed = true
+			}
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			if match := compactFence_Regexp.FindStringSubmatch(line); match != nil {
+				fence = match[1]
+			}
+			result = append(result, line)
+			closed = false
+			continue
+		}
+		if len(result) == 0 || strings.TrimSpace(result[len(result)-1]) == "" || closed {
+			continue
+		}
+		previous := result[len(result)-1]
+		if compactATX_Regexp.MatchString(previous) {
+			continue
+		}
+		next := ""
+		for _, entry := range lines[i+1:] {
+			if strings.TrimSpace(entry) != "" {
+				next = entry
+				break
+			}
+		}
+		if compactFence_Regexp.MatchString(next) && paragraphLine(previous) {
+			continue
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// paragraphLine reports whether line is (most likely) the text of a
+// paragraph, which a code block can follow without a blank line: not HTML,
+// a table row, an item of a list, or indented code
+func paragraphLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+		return false
+	case strings.HasPrefix(trimmed, "<") || strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, ">"):
+		return false
+	case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "+ "):
+		return false
+	case compactOrdered_Regexp.MatchString(trimmed):
+		return false
+	}
+	return true
+}
+
+// dropEmptySections takes out the headings (below the title) that are
+// followed by one of the same or a higher level, or by nothing, until there
+// are none left
+func dropEmptySections(lines []string) []string {
+	for {
+		var result []string
+		dropped := false
+		fence := ""
+		for i, line := range lines {
+			if fence != "" && closesFence(line, fence) {
+				fence = ""
+				result = append(result, line)
+				continue
+			} else if match := compactFence_Regexp.FindStringSubmatch(line); fence == "" && match != nil {
+				fence = match[1]
+			}
+			match := compactATX_Regexp.FindStringSubmatch(line)
+			if fence != "" || match == nil || len(match[1]) == 1 {
+				result = append(result, line)
+				continue
+			}
+			empty := true
+			for _, next := range lines[i+1:] {
+				if strings.TrimSpace(next) == "" {
+					continue
+				}
+				if heading := compactATX_Regexp.FindStringSubmatch(next); heading == nil || len(heading[1]) > len(match[1]) {
+					empty = false
+				}
+				break
+			}
+			if empty {
+				dropped = true
+				continue
+			}
+			result = append(result, line)
+		}
+		if !dropped {
+			return result
+		}
+		lines = result
+	}
+}
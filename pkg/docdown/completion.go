@@ -0,0 +1,355 @@
+package docdown
+
+import (
+	Flag "flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completionShells are the shells "godocdown completion" writes scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// flagChoices are the values that flags taking one of a few accept (as
+// buildStyle and the others check them), for completing them
+var flagChoices = map[string][]string{
+	"ascii-tables":  {"markdown", "pre"},
+	"check-links":   {"off", "warn", "strict"},
+	"emoji":         {"pass", "escape", "convert"},
+	"entities":      {"pass", "escape", "convert"},
+	"eol":           {"lf", "crlf"},
+	"example-code":  {"comments", "unwrap", "gofmt"},
+	"example-index": {"flat", "grouped", "off"},
+	"example-order": {"name", "source"},
+	"flavor":        {"github", "plain", "commonmark", "gomarkdoc"},
+	"format":        {"markdown", "docset", "mrkdwn", "discord"},
+	"heading-style": {"atx", "setext"},
+	"html":          {"pass", "escape", "sanitize"},
+	"indent":        {"tabs", "spaces"},
+	"lint-disable":  lintRules,
+	"log-format":    {"text", "json"},
+	"method-order":  {"name", "receiver"},
+	"qualify":       {"source", "full", "short"},
+	"split":         {"type"},
+	"warnings":      {"off", "log", "github"},
+}
+
+// completionFlag is a flag as the completion scripts complete it
+type completionFlag struct {
+	Name    string
+	Usage   string
+	Value   bool // Whether it takes a value, unlike boolean flags
+	Choices []string
+}
+
+// completionCommand is godocdown ("") or one of its subcommands, with its
+// flags, for the completion scripts
+type completionCommand struct {
+	Name    string
+	Summary string
+	Flags   []completionFlag
+}
+
+// completionCommands are godocdown and its subcommands, in order
+func completionCommands() []completionCommand {
+	commands := []completionCommand{{Flags: completionFlags(flag)}}
+	list := subcommands()
+	for _, name := range subcommandNames() {
+		commands = append(commands, completionCommand{name, list[name].Summary, completionFlags(list[name].Flags())})
+	}
+	return commands
+}
+
+// completionFlags are the flags of a flag set, in order, but for the hidden
+// ones (like -signature), whose usage is a NUL
+func completionFlags(flags *Flag.FlagSet) []completionFlag {
+	var result []completionFlag
+	flags.VisitAll(func(f *Flag.Flag) {
+		if f.Usage == string(rune(0)) {
+			return
+		}
+		boolean, ok := f.Value.(interface{ IsBoolFlag() bool })
+		result = append(result, completionFlag{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			Value:   !ok || !boolean.IsBoolFlag(),
+			Choices: flagChoices[f.Name],
+		})
+	})
+	return result
+}
+
+// runCompletion implements "godocdown completion", which writes a script
+// that completes the subcommands, flags, and values of flags (like the
+// flavors and formats) of godocdown for a shell: bash, zsh, fish, or
+// powershell.
+func runCompletion(arguments []string) int {
+	if len(arguments) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: godocdown completion %s\n", strings.Join(completionShells, "|"))
+		return 2
+	}
+	commands := completionCommands()
+	switch arguments[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout, commands)
+	case "zsh":
+		writeZshCompletion(os.Stdout, commands)
+	case "fish":
+		writeFishCompletion(os.Stdout, commands)
+	case "powershell":
+		writePowerShellCompletion(os.Stdout, commands)
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid shell \"%s\": expected one of %s\n", arguments[0], strings.Join(completionShells, ", "))
+		return 2
+	}
+	return 0
+}
+
+// completionValues are the flags of the commands that take a value, by
+// name, with their choices
+func completionValues(commands []completionCommand) ([]string, map[string][]string) {
+	values := map[string][]string{}
+	for _, command := range commands {
+		for _, entry := range command.Flags {
+			if entry.Value {
+				values[entry.Name] = entry.Choices
+			}
+		}
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, values
+}
+
+// writeBashCompletion writes the completion script for bash
+func writeBashCompletion(writer io.Writer, commands []completionCommand) {
+	var names []string
+	for _, command := range commands[1:] {
+		names = append(names, command.Name)
+	}
+	fmt.Fprintf(writer, "# bash completion for godocdown, from \"godocdown completion bash\":\n")
+	fmt.Fprintf(writer, "#\n#\tsource <(godocdown completion bash)\n\n")
+	fmt.Fprintf(writer, "_godocdown() {\n")
+	fmt.Fprintf(writer, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" command=\"\" flags\n")
+	fmt.Fprintf(writer, "\tif [[ ${COMP_CWORD} -gt 1 ]]; then\n")
+	fmt.Fprintf(writer, "\t\tcase \"${COMP_WORDS[1]}\" in\n")
+	fmt.Fprintf(writer, "\t\t%s) command=\"${COMP_WORDS[1]}\" ;;\n", strings.Join(names, "|"))
+	fmt.Fprintf(writer, "\t\tesac\n\tfi\n\n")
+
+	fmt.Fprintf(writer, "\tcase \"${command}\" in\n")
+	for _, command := range append(commands[1:], commands[0]) {
+		var flags []string
+		for _, entry := range command.Flags {
+			flags = append(flags, "-"+entry.Name)
+		}
+		pattern := command.Name
+		if pattern == "" {
+			pattern = "*"
+		}
+		fmt.Fprintf(writer, "\t%s) flags=\"%s\" ;;\n", pattern, strings.Join(flags, " "))
+	}
+	fmt.Fprintf(writer, "\tesac\n\n")
+
+	values, choices := completionValues(commands)
+	fmt.Fprintf(writer, "\tcase \"${prev}\" in\n")
+	var files []string
+	for _, name := range values {
+		if len(choices[name]) == 0 {
+			files = append(files, "-"+name+"|--"+name)
+			continue
+		}
+		fmt.Fprintf(writer, "\t-%s|--%s)\n", name, name)
+		fmt.Fprintf(writer, "\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"${cur}\"))\n\t\treturn\n\t\t;;\n", strings.Join(choices[name], " "))
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(writer, "\t%s)\n", strings.Join(files, "|"))
+		fmt.Fprintf(writer, "\t\tCOMPREPLY=($(compgen -f -- \"${cur}\"))\n\t\treturn\n\t\t;;\n")
+	}
+	fmt.Fprintf(writer, "\tesac\n\n")
+
+	fmt.Fprintf(writer, "\tif [[ \"${cur}\" == -* ]]; then\n")
+	fmt.Fprintf(writer, "\t\tCOMPREPLY=($(compgen -W \"${flags}\" -- \"${cur}\"))\n")
+	fmt.Fprintf(writer, "\telif [[ \"${command}\" == completion ]]; then\n")
+	fmt.Fprintf(writer, "\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"${cur}\"))\n", strings.Join(completionShells, " "))
+	fmt.Fprintf(writer, "\telif [[ ${COMP_CWORD} -eq 1 ]]; then\n")
+	fmt.Fprintf(writer, "\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"${cur}\") $(compgen -d -- \"${cur}\"))\n", strings.Join(names, " "))
+	fmt.Fprintf(writer, "\telse\n")
+	fmt.Fprintf(writer, "\t\tCOMPREPLY=($(compgen -d -- \"${cur}\"))\n")
+	fmt.Fprintf(writer, "\tfi\n}\n\n")
+	fmt.Fprintf(writer, "complete -F _godocdown godocdown\n")
+}
+
+// zshQuote quotes a word for zsh
+func zshQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// writeZshCompletion writes the completion script for zsh
+func writeZshCompletion(writer io.Writer, commands []completionCommand) {
+	fmt.Fprintf(writer, "#compdef godocdown\n\n")
+	fmt.Fprintf(writer, "# zsh completion for godocdown, from \"godocdown completion zsh\":\n")
+	fmt.Fprintf(writer, "#\n#\tgodocdown completion zsh > \"${fpath[1]}/_godocdown\"\n\n")
+	fmt.Fprintf(writer, "_godocdown() {\n")
+	fmt.Fprintf(writer, "\tlocal -a subcommands\n\tsubcommands=(\n")
+	for _, command := range commands[1:] {
+		fmt.Fprintf(writer, "\t\t%s\n", zshQuote(command.Name+":"+command.Summary))
+	}
+	fmt.Fprintf(writer, "\t)\n\n")
+	fmt.Fprintf(writer, "\tcase \"${words[2]}\" in\n")
+	for _, command := range commands[1:] {
+		fmt.Fprintf(writer, "\t%s)\n", command.Name)
+		fmt.Fprintf(writer, "\t\tshift words\n\t\t(( CURRENT-- ))\n")
+		fmt.Fprintf(writer, "\t\t_arguments")
+		writeZshFlags(writer, command.Flags)
+		if command.Name == "completion" {
+			fmt.Fprintf(writer, " \\\n\t\t\t'1:shell:(%s)'\n", strings.Join(completionShells, " "))
+		} else {
+			fmt.Fprintf(writer, " \\\n\t\t\t'*:package:_files -/'\n")
+		}
+		fmt.Fprintf(writer, "\t\t;;\n")
+	}
+	fmt.Fprintf(writer, "\t*)\n\t\t_arguments")
+	writeZshFlags(writer, commands[0].Flags)
+	fmt.Fprintf(writer, " \\\n\t\t\t'1:command or package:{_describe command subcommands; _files -/}'")
+	fmt.Fprintf(writer, " \\\n\t\t\t'*:package:_files -/'\n")
+	fmt.Fprintf(writer, "\t\t;;\n\tesac\n}\n\n")
+	fmt.Fprintf(writer, "_godocdown \"$@\"\n")
+}
+
+// writeZshFlags writes the specifications of flags for _arguments, with the
+// brackets of their usage (which would end it) escaped
+func writeZshFlags(writer io.Writer, flags []completionFlag) {
+	escape := strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+	for _, entry := range flags {
+		spec := "-" + entry.Name + "[" + escape.Replace(entry.Usage) + "]"
+		switch {
+		case len(entry.Choices) > 0:
+			spec += ":" + entry.Name + ":(" + strings.Join(entry.Choices, " ") + ")"
+		case entry.Value:
+			spec += ":" + entry.Name + ":_files"
+		}
+		fmt.Fprintf(writer, " \\\n\t\t\t%s", zshQuote(spec))
+	}
+}
+
+// fishQuote quotes a word for fish
+func fishQuote(word string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(word) + "'"
+}
+
+// writeFishCompletion writes the completion script for fish
+func writeFishCompletion(writer io.Writer, commands []completionCommand) {
+	var names []string
+	for _, command := range commands[1:] {
+		names = append(names, command.Name)
+	}
+	fmt.Fprintf(writer, "# fish completion for godocdown, from \"godocdown completion fish\":\n")
+	fmt.Fprintf(writer, "#\n#\tgodocdown completion fish > ~/.config/fish/completions/godocdown.fish\n\n")
+	fmt.Fprintf(writer, "complete -c godocdown -f\n")
+	for _, command := range commands[1:] {
+		fmt.Fprintf(writer, "complete -c godocdown -n __fish_use_subcommand -a %s -d %s\n", command.Name, fishQuote(command.Summary))
+	}
+	fmt.Fprintf(writer, "complete -c godocdown -n 'not __fish_seen_subcommand_from completion' -a '(__fish_complete_directories)'\n")
+	fmt.Fprintf(writer, "complete -c godocdown -n '__fish_seen_subcommand_from completion' -x -a %s\n", fishQuote(strings.Join(completionShells, " ")))
+	for _, command := range commands {
+		condition := "'not __fish_seen_subcommand_from " + strings.Join(names, " ") + "'"
+		if command.Name != "" {
+			condition = "'__fish_seen_subcommand_from " + command.Name + "'"
+		}
+		for _, entry := range command.Flags {
+			fmt.Fprintf(writer, "complete -c godocdown -n %s -o %s -d %s", condition, entry.Name, fishQuote(entry.Usage))
+			switch {
+			case len(entry.Choices) > 0:
+				fmt.Fprintf(writer, " -x -a %s", fishQuote(strings.Join(entry.Choices, " ")))
+			case entry.Value:
+				fmt.Fprintf(writer, " -r -F")
+			}
+			fmt.Fprintf(writer, "\n")
+		}
+	}
+}
+
+// powerShellQuote quotes a word for PowerShell
+func powerShellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", "''") + "'"
+}
+
+// writePowerShellCompletion writes the completion script for PowerShell
+func writePowerShellCompletion(writer io.Writer, commands []completionCommand) {
+	fmt.Fprintf(writer, "# PowerShell completion for godocdown, from \"godocdown completion powershell\":\n")
+	fmt.Fprintf(writer, "#\n#\tgodocdown completion powershell | Out-String | Invoke-Expression\n\n")
+	fmt.Fprintf(writer, "Register-ArgumentCompleter -Native -CommandName godocdown -ScriptBlock {\n")
+	fmt.Fprintf(writer, "\tparam($wordToComplete, $commandAst, $cursorPosition)\n\n")
+	fmt.Fprintf(writer, "\t$subcommands = [ordered]@{\n")
+	for _, command := range commands[1:] {
+		fmt.Fprintf(writer, "\t\t%s = %s\n", powerShellQuote(command.Name), powerShellQuote(command.Summary))
+	}
+	fmt.Fprintf(writer, "\t}\n")
+	fmt.Fprintf(writer, "\t$flags = @{\n")
+	for _, command := range commands {
+		name := command.Name
+		if name == "" {
+			name = "godocdown"
+		}
+		fmt.Fprintf(writer, "\t\t%s = @(\n", powerShellQuote(name))
+		for _, entry := range command.Flags {
+			var choices []string
+			for _, choice := range entry.Choices {
+				choices = append(choices, powerShellQuote(choice))
+			}
+			fmt.Fprintf(writer, "\t\t\t@{ Name = %s; Usage = %s; Value = $%t; Choices = @(%s) }\n",
+				powerShellQuote("-"+entry.Name), powerShellQuote(entry.Usage), entry.Value, strings.Join(choices, ", "))
+		}
+		fmt.Fprintf(writer, "\t\t)\n")
+	}
+	fmt.Fprintf(writer, "\t}\n\n")
+	fmt.Fprintf(writer, "%s", strings.ReplaceAll(`	# The words before the one being completed
+	$words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+	$command = 'godocdown'
+	if ($words.Count -gt 0 -and $subcommands.Contains($words[0])) {
+		$command = $words[0]
+	}
+	$previous = if ($words.Count -gt 0) { $words[-1] } else { '' }
+
+	$flag = $flags[$command] | Where-Object { $_.Value -and ($_.Name -eq $previous -or "-$($_.Name)" -eq $previous) }
+	if ($flag) {
+		# Paths otherwise, which PowerShell completes when nothing is returned
+		$flag.Choices | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+			[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+		}
+		return
+	}
+	if ($wordToComplete -like '-*') {
+		$flags[$command] | Where-Object { $_.Name -like "$wordToComplete*" } | ForEach-Object {
+			[System.Management.Automation.CompletionResult]::new($_.Name, $_.Name, 'ParameterName', $_.Usage)
+		}
+		return
+	}
+	if ($command -eq 'completion') {
+		SHELLS | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+			[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+		}
+		return
+	}
+	if ($words.Count -eq 0) {
+		$subcommands.Keys | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+			[System.Management.Automation.CompletionResult]::new($_, $_, 'Command', $subcommands[$_])
+		}
+	}
+}
+`, "SHELLS", "@("+strings.Join(quoteAll(completionShells, powerShellQuote), ", ")+")"))
+}
+
+// quoteAll quotes each of words with quote
+func quoteAll(words []string, quote func(string) string) []string {
+	var result []string
+	for _, word := range words {
+		result = append(result, quote(word))
+	}
+	return result
+}
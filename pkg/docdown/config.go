@@ -0,0 +1,89 @@
+package docdown
+
+import (
+	Flag "flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is where options are read from, unless -config says otherwise
+const configFile = ".godocdown.yaml"
+
+// loadConfig sets the options in the config file on set, except for those
+// given on the command line, which take precedence. Each key of the file
+// is the name of a flag, and repeatable flags take a list:
+//
+//	flavor: gomarkdoc
+//	index-header: "## API"
+//	exclude-dir:
+//	  - "**/mocks"
+//	  - third_party
+//
+// The "outputs" key, which is not a flag, defines more outputs (see
+// parseOutputs).
+func loadConfig(set *Flag.FlagSet) error {
+	path := *flag_config
+	if path == "" {
+		path = configFile
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if *flag_config == "" && os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Could not read config \"%s\": %v", path, err)
+	}
+
+	var config yaml.Node
+	err = yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return fmt.Errorf("Could not parse config \"%s\": %v", path, err)
+	}
+	if len(config.Content) == 0 {
+		// An empty file
+		return nil
+	}
+	options := config.Content[0]
+	if options.Kind != yaml.MappingNode {
+		return fmt.Errorf("Could not parse config \"%s\": expected a mapping of options", path)
+	}
+
+	given := map[string]bool{}
+	set.Visit(func(f *Flag.Flag) {
+		given[f.Name] = true
+	})
+	for i := 0; i+1 < len(options.Content); i += 2 {
+		key, value := options.Content[i], options.Content[i+1]
+		if key.Value == "outputs" {
+			configOutputs, err = parseOutputs(path, value)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if (set.Lookup(key.Value) == nil && flag.Lookup(key.Value) == nil) || key.Value == "config" {
+			return fmt.Errorf("%s:%d: unknown option \"%s\"", path, key.Line, key.Value)
+		}
+		if given[key.Value] || set.Lookup(key.Value) == nil {
+			// Given on the command line, or an option of godocdown that the
+			// subcommand does not use
+			continue
+		}
+		values := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			values = value.Content
+		}
+		for _, value := range values {
+			if value.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s:%d: expected a value or a list of values for \"%s\"", path, value.Line, key.Value)
+			}
+			err := set.Set(key.Value, value.Value)
+			if err != nil {
+				return fmt.Errorf("%s:%d: invalid value for \"%s\": %v", path, value.Line, key.Value, err)
+			}
+		}
+	}
+	return nil
+}
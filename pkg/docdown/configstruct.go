@@ -0,0 +1,205 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// configField is a row of the configuration reference of a struct: a field
+// with the keys its tags give it, its type, its default, and its doc
+type configField struct {
+	Key         string
+	Env         string
+	Flag        string
+	Type        string
+	Default     string
+	Description string
+}
+
+// configStructs are the types to make configuration references of: those
+// -config-struct names, then those with a "//godocdown:config" directive
+func (self *Document) configStructs() []string {
+	var names []string
+	for _, name := range self.style.ConfigStructs {
+		if self.findType(name) == nil {
+			self.style.log().Warn("Could not find the -config-struct type", "package", self.absPath, "type", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	for _, entry := range self.pkg.Types {
+		if _, ok := self.declDirectives(entry.Decl)["config"]; ok && !containsString(names, entry.Name) {
+			names = append(names, entry.Name)
+		}
+	}
+	return names
+}
+
+// structOf is the struct type that expression is, or names (as a type of
+// the package, or a pointer to one), or nil
+func (self *Document) structOf(expression ast.Expr) *ast.StructType {
+	switch expression := expression.(type) {
+	case *ast.StructType:
+		return expression
+	case *ast.StarExpr:
+		return self.structOf(expression.X)
+	case *ast.Ident:
+		if entry := self.findType(expression.Name); entry != nil {
+			for _, spec := range entry.Decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok && spec.Name.Name == expression.Name {
+					if kind, ok := spec.Type.(*ast.StructType); ok {
+						return kind
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// configFields are the rows of the configuration reference of a struct,
+// with the fields of nested structs under their parent's key (like
+// server.port), and those of embedded ones inlined. The key of a field is
+// its yaml, json, or toml name, or its own; "-" leaves it out.
+func (self *Document) configFields(kind *ast.StructType, prefix string, seen map[*ast.StructType]bool) []configField {
+	if seen[kind] {
+		return nil
+	}
+	seen[kind] = true
+	defer delete(seen, kind)
+	var fields []configField
+	for _, field := range kind.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			if unquoted, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(unquoted)
+			}
+		}
+		lookup := func(keys ...string) string {
+			for _, key := range keys {
+				if value, ok := tag.Lookup(key); ok {
+					name, _, _ := strings.Cut(value, ",")
+					return name
+				}
+			}
+			return ""
+		}
+		key := lookup("yaml", "json", "toml", "mapstructure")
+		if key == "-" {
+			continue
+		}
+		names := field.Names
+		if len(names) == 0 {
+			// An embedded struct, unless its tag names it
+			if nested := self.structOf(field.Type); nested != nil && key == "" {
+				fields = append(fields, self.configFields(nested, prefix, seen)...)
+				continue
+			}
+			names = []*ast.Ident{{Name: baseType(sourceOfNode(self.fset, field.Type))}}
+		}
+		description := field.Doc.Text()
+		if description == "" {
+			description = field.Comment.Text()
+		}
+		for _, name := range names {
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+			fieldKey := key
+			if fieldKey == "" {
+				fieldKey = name.Name
+			}
+			if nested := self.structOf(field.Type); nested != nil {
+				fields = append(fields, self.configFields(nested, prefix+fieldKey+".", seen)...)
+				continue
+			}
+			fields = append(fields, configField{
+				Key:         prefix + fieldKey,
+				Env:         lookup("env"),
+				Flag:        lookup("flag"),
+				Type:        sourceOfNode(self.fset, field.Type),
+				Default:     tag.Get("default"),
+				Description: strings.Join(strings.Fields(description), " "),
+			})
+		}
+	}
+	return fields
+}
+
+// renderConfigurationTo writes the configuration references of the structs
+// of configStructs under ConfigurationHeader: a table of the fields of each,
+// with only the columns some field has a value for
+func renderConfigurationTo(writer io.Writer, document *Document) {
+	names := document.configStructs()
+	if len(names) == 0 {
+		return
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.ConfigurationHeader))
+	for _, name := range names {
+		kind := document.structOf(ast.NewIdent(name))
+		if kind == nil {
+			document.style.log().Warn("Could not make a configuration reference of a type that is not a struct", "package", document.absPath, "type", name)
+			continue
+		}
+		fields := document.configFields(kind, "", map[*ast.StructType]bool{})
+		if len(fields) == 0 {
+			continue
+		}
+		if len(names) > 1 {
+			fmt.Fprintf(writer, "`%s`:\n\n", name)
+		}
+		columns := []struct {
+			label string
+			value func(configField) string
+		}{
+			{"Key", func(field configField) string { return field.Key }},
+			{"Environment", func(field configField) string { return field.Env }},
+			{"Flag", func(field configField) string { return field.Flag }},
+			{"Type", func(field configField) string { return field.Type }},
+			{"Default", func(field configField) string { return field.Default }},
+			{"Description", func(field configField) string { return field.Description }},
+		}
+		header := []string{}
+		var used []int
+		for i, column := range columns {
+			for _, field := range fields {
+				if column.value(field) != "" {
+					header = append(header, style.text(column.label))
+					used = append(used, i)
+					break
+				}
+			}
+		}
+		if !style.tables() {
+			for _, field := range fields {
+				var parts []string
+				for _, i := range used[1:] {
+					if value := columns[i].value(field); value != "" && columns[i].label != "Description" {
+						parts = append(parts, fmt.Sprintf("%s `%s`", strings.ToLower(style.text(columns[i].label)), value))
+					}
+				}
+				fmt.Fprintf(writer, " - `%s` (%s): %s\n", field.Key, strings.Join(parts, ", "), field.Description)
+			}
+			fmt.Fprintf(writer, "\n")
+			continue
+		}
+		rows := [][]string{header}
+		for _, field := range fields {
+			var row []string
+			for _, i := range used {
+				value := columns[i].value(field)
+				if value != "" && columns[i].label != "Description" {
+					value = "`" + value + "`"
+				}
+				row = append(row, value)
+			}
+			rows = append(rows, row)
+		}
+		fmt.Fprintf(writer, "%s\n", markdownTable(rows))
+	}
+}
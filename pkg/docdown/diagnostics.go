@@ -0,0 +1,338 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// coverage counts how much of a package's exported API is documented
+type coverage struct {
+	Documented int     `json:"documented"`
+	Total      int     `json:"total"`
+	Percent    float64 `json:"percent"`
+}
+
+func (self *coverage) add(documented bool) {
+	self.Total++
+	if documented {
+		self.Documented++
+	}
+	self.Percent = 100 * float64(self.Documented) / float64(self.Total)
+}
+
+// warning is a problem with a package's documentation, found by the check
+// named by Rule (see lintRules)
+type warning struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	Rule    string `json:"rule"`
+}
+
+func newWarning(rule string, position token.Position, format string, arguments ...interface{}) warning {
+	return warning{
+		File:    position.Filename,
+		Line:    position.Line,
+		Column:  position.Column,
+		Message: fmt.Sprintf(format, arguments...),
+		Rule:    rule,
+	}
+}
+
+func (self warning) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", self.File, self.Line, self.Column, self.Message)
+}
+
+// check looks for problems with the documentation, returning the
+// documentation coverage and the warnings found
+func (self *Document) check() (coverage, []warning) {
+	total, warnings := self.checkCoverage()
+	warnings = append(warnings, self.checkLinks()...)
+	warnings = append(warnings, self.checkExamples()...)
+	warnings = append(warnings, self.checkDirectives()...)
+	warnings = append(warnings, self.checkReferences()...)
+	warnings = append(warnings, self.checkGlossary()...)
+	warnings = append(warnings, self.checkEnv()...)
+	warnings = append(warnings, self.checkExitCodes()...)
+	return total, warnings
+}
+
+// checkCoverage looks for undocumented parts of the exported API, returning
+// the documentation coverage and a warning for each symbol missing
+// documentation
+func (self *Document) checkCoverage() (coverage, []warning) {
+	var total coverage
+	var warnings []warning
+
+	symbol := func(kind, name string, node ast.Node, text string) {
+		total.add(text != "")
+		if text == "" {
+			position := self.fset.Position(node.Pos())
+			warnings = append(warnings, newWarning("documented", position, "exported %s %s should have a comment", kind, name))
+		}
+	}
+	values := func(kind string, list []*doc.Value) {
+		for _, value := range list {
+			symbol(kind, value.Names[0], value.Decl, value.Doc)
+		}
+	}
+	funcs := func(list []*doc.Func) {
+		for _, entry := range list {
+			kind, name := "function", entry.Name
+			if entry.Recv != "" {
+				kind, name = "method", entry.Recv+"."+entry.Name
+			}
+			symbol(kind, name, entry.Decl, entry.Doc)
+		}
+	}
+
+	kind := "package"
+	if self.IsCommand {
+		kind = "command"
+	}
+	total.add(self.pkg.Doc != "")
+	if self.pkg.Doc == "" {
+		warnings = append(warnings, newWarning("package-comment", token.Position{Filename: self.absPath}, "%s %s should have a package comment", kind, self.Name))
+	}
+	if !self.documentsAPI() {
+		return total, warnings
+	}
+
+	values("constant", self.pkg.Consts)
+	values("variable", self.pkg.Vars)
+	funcs(self.pkg.Funcs)
+	for _, entry := range self.pkg.Types {
+		symbol("type", entry.Name, entry.Decl, entry.Doc)
+		values("constant", entry.Consts)
+		values("variable", entry.Vars)
+		funcs(entry.Funcs)
+		funcs(entry.Methods)
+	}
+	return total, warnings
+}
+
+// exampleTarget splits an example name (without the "Example" prefix) into
+// the symbol and method it documents and its suffix, following the naming
+// convention in the testing package:
+//
+//	Example_suffix   // package
+//	ExampleF_suffix  // function F
+//	ExampleT_suffix  // type T
+//	ExampleT_M       // method M of type T
+func exampleTarget(name string) (symbol, method, suffix string) {
+	parts := strings.Split(name, "_")
+	symbol = parts[0]
+	parts = parts[1:]
+	if len(parts) > 0 && parts[0] != "" && !isLower(parts[0]) {
+		method = parts[0]
+		parts = parts[1:]
+	}
+	suffix = strings.Join(parts, "_")
+	return
+}
+
+func isLower(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsLower(r)
+}
+
+// checkExamples warns about examples for symbols that no longer exist
+func (self *Document) checkExamples() []warning {
+	var warnings []warning
+	for _, example := range self.Examples {
+		symbol, method, suffix := exampleTarget(example.Name)
+		position := self.fset.Position(example.Code.Pos())
+		stale := ""
+		switch {
+		case symbol == "" && method != "":
+			stale = "Example_" + method
+		case symbol == "":
+		case method == "":
+			if self.findFunc(symbol) == nil && self.findType(symbol) == nil {
+				stale = symbol
+			}
+		default:
+			if self.findMethod(symbol, method) == nil {
+				stale = symbol + "." + method
+			}
+		}
+		if stale != "" {
+			warnings = append(warnings, newWarning("examples", position, "Example%s refers to unknown symbol %s", example.Name, stale))
+		} else if suffix != "" && !isLower(suffix) {
+			warnings = append(warnings, newWarning("examples", position, "Example%s has malformed suffix: %s", example.Name, suffix))
+		}
+	}
+	return warnings
+}
+
+var docAnchorLink_Regexp = regexp.MustCompile(`\]\(#([^)\s]+)\)`)
+
+// checkLinks warns about links in the documentation to anchors that are not
+// in the generated output
+func (self *Document) checkLinks() []warning {
+	anchors := self.anchors()
+	var warnings []warning
+	check := func(node ast.Node, text string) {
+		for _, match := range docAnchorLink_Regexp.FindAllStringSubmatch(text, -1) {
+			if anchors[match[1]] {
+				continue
+			}
+			warnings = append(warnings, newWarning("links", self.docPosition(node), "broken link to #%s", match[1]))
+		}
+	}
+	check(nil, self.pkg.Doc)
+	self.walkDocs(check)
+	return warnings
+}
+
+// anchors returns the anchors for every symbol in the generated output
+func (self *Document) anchors() map[string]bool {
+	anchors := map[string]bool{}
+	for _, entry := range self.pkg.Funcs {
+		anchors[entry.Name] = true
+	}
+	for _, entry := range self.pkg.Types {
+		anchors[entry.Name] = true
+		for _, fn := range entry.Funcs {
+			anchors[fn.Name] = true
+		}
+		for _, fn := range entry.Methods {
+			anchors[fn.Name] = true
+		}
+	}
+	for _, example := range self.Examples {
+		anchors["Example"+example.Name] = true
+	}
+	return anchors
+}
+
+// walkDocs calls fn with the documentation of every exported symbol, along
+// with the node it belongs to
+// packageDocPos is the position of the package comment of the first of
+// files (by name) that has one, where go/doc's text of it starts
+func packageDocPos(files map[string]*ast.File) token.Pos {
+	pos, first := token.NoPos, ""
+	for name, file := range files {
+		if file.Doc != nil && (!pos.IsValid() || name < first) {
+			pos, first = file.Doc.Pos(), name
+		}
+	}
+	return pos
+}
+
+// docPosition is the position of the documentation of node, or of the
+// package comment for a nil node
+func (self *Document) docPosition(node ast.Node) token.Position {
+	if node != nil {
+		return self.fset.Position(node.Pos())
+	}
+	if self.docPos.IsValid() {
+		return self.fset.Position(self.docPos)
+	}
+	return token.Position{Filename: self.absPath}
+}
+
+func (self *Document) walkDocs(fn func(node ast.Node, text string)) {
+	values := func(list []*doc.Value) {
+		for _, value := range list {
+			fn(value.Decl, value.Doc)
+		}
+	}
+	funcs := func(list []*doc.Func) {
+		for _, entry := range list {
+			fn(entry.Decl, entry.Doc)
+		}
+	}
+	values(self.pkg.Consts)
+	values(self.pkg.Vars)
+	funcs(self.pkg.Funcs)
+	for _, entry := range self.pkg.Types {
+		fn(entry.Decl, entry.Doc)
+		values(entry.Consts)
+		values(entry.Vars)
+		funcs(entry.Funcs)
+		funcs(entry.Methods)
+	}
+}
+
+func (self *Document) findFunc(name string) *doc.Func {
+	for _, entry := range self.pkg.Funcs {
+		if entry.Name == name {
+			return entry
+		}
+	}
+	for _, entry := range self.pkg.Types {
+		for _, fn := range entry.Funcs {
+			if fn.Name == name {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+func (self *Document) findType(name string) *doc.Type {
+	for _, entry := range self.pkg.Types {
+		if entry.Name == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (self *Document) findMethod(typeName, name string) *doc.Func {
+	entry := self.findType(typeName)
+	if entry == nil {
+		return nil
+	}
+	for _, fn := range entry.Methods {
+		if fn.Name == name {
+			return fn
+		}
+	}
+	return nil
+}
+
+// githubEscape escapes text for use in a GitHub Actions workflow command
+func githubEscape(text string, property bool) string {
+	text = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(text)
+	if property {
+		text = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(text)
+	}
+	return text
+}
+
+// emitWarnings reports the warnings for each job in the format chosen with
+// -warnings (which buildStyle checks)
+func emitWarnings(writer io.Writer, jobs []*job) {
+	cwd, _ := os.Getwd()
+	for _, job := range jobs {
+		for _, warning := range job.info.Warnings {
+			switch *flag_warnings {
+			case "off":
+			case "log":
+				logger.Warn(warning.Message, "file", warning.File, "line", warning.Line, "column", warning.Column)
+			case "github":
+				file := warning.File
+				if relative, err := filepath.Rel(cwd, file); err == nil {
+					file = filepath.ToSlash(relative)
+				}
+				fmt.Fprintf(writer, "::warning file=%s,line=%d,col=%d::%s\n",
+					githubEscape(file, true),
+					warning.Line,
+					warning.Column,
+					githubEscape(warning.Message, false))
+			}
+		}
+	}
+}
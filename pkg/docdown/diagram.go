@@ -0,0 +1,201 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io"
+	"sort"
+	"strings"
+)
+
+// typeSpecOf finds the declaration of entry in its (possibly grouped)
+// declaration
+func typeSpecOf(entry *doc.Type) *ast.TypeSpec {
+	if entry.Decl == nil {
+		return nil
+	}
+	for _, spec := range entry.Decl.Specs {
+		if spec, ok := spec.(*ast.TypeSpec); ok && spec.Name.Name == entry.Name {
+			return spec
+		}
+	}
+	return nil
+}
+
+// typeList is the types of fields, separated by commas, with the names
+// left out
+func (self *Document) typeList(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+	var types []string
+	for _, field := range fields.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			types = append(types, sourceOfNode(self.fset, field.Type))
+		}
+	}
+	return strings.Join(types, ", ")
+}
+
+// methodKey identifies a method by its name and the types of its
+// parameters and results
+func (self *Document) methodKey(name string, function *ast.FuncType) string {
+	return fmt.Sprintf("%s(%s) (%s)", name, self.typeList(function.Params), self.typeList(function.Results))
+}
+
+// interfaceMethods is the method set of the interface named name, or false
+// if it can't be known from this package alone (e.g. it embeds an
+// interface of another package, or is a type constraint)
+func (self *Document) interfaceMethods(name string, specs map[string]*ast.TypeSpec, seen map[string]bool) (map[string]bool, bool) {
+	spec := specs[name]
+	if spec == nil || seen[name] {
+		return nil, false
+	}
+	seen[name] = true
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil, false
+	}
+	methods := map[string]bool{}
+	for _, field := range iface.Methods.List {
+		if function, ok := field.Type.(*ast.FuncType); ok && len(field.Names) > 0 {
+			methods[self.methodKey(field.Names[0].Name, function)] = true
+			continue
+		}
+		embedded, ok := field.Type.(*ast.Ident)
+		if !ok {
+			return nil, false
+		}
+		inner, ok := self.interfaceMethods(embedded.Name, specs, seen)
+		if !ok {
+			return nil, false
+		}
+		for method := range inner {
+			methods[method] = true
+		}
+	}
+	return methods, true
+}
+
+// embeddedName is the name of the package type an embedded field refers
+// to, or "" if it is from another package
+func embeddedName(expression ast.Expr) string {
+	switch expression := expression.(type) {
+	case *ast.Ident:
+		return expression.Name
+	case *ast.StarExpr:
+		return embeddedName(expression.X)
+	}
+	return ""
+}
+
+// diagram is the Mermaid class diagram of the relationships between the
+// exported types of the package: struct and interface embedding, and which
+// types implement which interfaces. Implementation is decided by matching
+// method names and signatures as written, without type checking. It is ""
+// if there are no relationships to show.
+func (self *Document) diagram() string {
+	specs := map[string]*ast.TypeSpec{}
+	for _, entry := range self.pkg.Types {
+		if spec := typeSpecOf(entry); spec != nil {
+			specs[entry.Name] = spec
+		}
+	}
+
+	var relations []string
+	interfaces := map[string]bool{}
+	for _, entry := range self.pkg.Types {
+		spec := specs[entry.Name]
+		if spec == nil {
+			continue
+		}
+		var fields *ast.FieldList
+		switch kind := spec.Type.(type) {
+		case *ast.StructType:
+			fields = kind.Fields
+		case *ast.InterfaceType:
+			fields = kind.Methods
+			interfaces[entry.Name] = true
+		}
+		if fields == nil {
+			continue
+		}
+		for _, field := range fields.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+			name := embeddedName(field.Type)
+			if specs[name] == nil {
+				continue
+			}
+			if interfaces[entry.Name] {
+				relations = append(relations, fmt.Sprintf("%s <|-- %s : embeds", name, entry.Name))
+			} else {
+				relations = append(relations, fmt.Sprintf("%s *-- %s : embeds", entry.Name, name))
+			}
+		}
+	}
+
+	for _, iface := range self.pkg.Types {
+		if !interfaces[iface.Name] {
+			continue
+		}
+		methods, ok := self.interfaceMethods(iface.Name, specs, map[string]bool{})
+		if !ok || len(methods) == 0 {
+			continue
+		}
+		for _, entry := range self.pkg.Types {
+			if interfaces[entry.Name] {
+				continue
+			}
+			has := map[string]bool{}
+			for _, method := range entry.Methods {
+				has[self.methodKey(method.Name, method.Decl.Type)] = true
+			}
+			implements := true
+			for method := range methods {
+				if !has[method] {
+					implements = false
+					break
+				}
+			}
+			if implements {
+				relations = append(relations, fmt.Sprintf("%s ..|> %s : implements", entry.Name, iface.Name))
+			}
+		}
+	}
+
+	if len(relations) == 0 {
+		return ""
+	}
+
+	var diagram strings.Builder
+	diagram.WriteString("```mermaid\nclassDiagram\n")
+	var names []string
+	for name := range interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&diagram, "    class %s {\n        <<interface>>\n    }\n", name)
+	}
+	for _, relation := range relations {
+		fmt.Fprintf(&diagram, "    %s\n", relation)
+	}
+	diagram.WriteString("```\n")
+	return diagram.String()
+}
+
+func renderDiagramTo(writer io.Writer, document *Document) {
+	if !document.style.IncludeDiagram || !document.style.tables() {
+		return
+	}
+	if diagram := document.diagram(); diagram != "" {
+		fmt.Fprintf(writer, "%s\n", diagram)
+	}
+}
@@ -0,0 +1,121 @@
+package docdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// unifiedDiff writes the differences between two texts in unified diff
+// format, with three lines of context. It is meant for documents the size
+// of a README, not for large files.
+func unifiedDiff(writer io.Writer, oldName, newName, old, new string) {
+	a := splitLines(old)
+	b := splitLines(new)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op   byte // ' ', '-', or '+'
+		text string
+		a, b int // Line numbers (0-based) in a and b
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Removed lines come before the lines added in their place,
+			// as in diff -u
+			lines = append(lines, line{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	printed := false
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk until there are more than 2*context unchanged
+		// lines in a row
+		from := start - context
+		if from < 0 {
+			from = 0
+		}
+		end, same := start, 0
+		for end < len(lines) && same <= 2*context {
+			if lines[end].op == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+			end++
+		}
+		end -= same
+		if end += context; end > len(lines) {
+			end = len(lines)
+		}
+
+		if !printed {
+			fmt.Fprintf(writer, "--- %s\n+++ %s\n", oldName, newName)
+			printed = true
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:end] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(writer, "@@ -%s +%s @@\n", hunkRange(lines[from].a, oldCount), hunkRange(lines[from].b, newCount))
+		for _, line := range lines[from:end] {
+			fmt.Fprintf(writer, "%c%s\n", line.op, line.text)
+		}
+		start = end
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,53 @@
+package docdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(from, to int) string {
+		var text strings.Builder
+		for i := from; i <= to; i++ {
+			text.WriteString(string(rune('a'+i-1)) + "\n")
+		}
+		return text.String()
+	}
+	for _, test := range []struct {
+		name     string
+		old, new string
+		diff     string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{"both empty", "", "", ""},
+		{"created", "", "a\nb\n", "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"emptied", "a\n", "", "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n"},
+		{
+			"changed line",
+			"a\nb\nc\n", "a\nB\nc\n",
+			"--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"context of three lines",
+			lines(1, 9), strings.Replace(lines(1, 9), "e\n", "E\n", 1),
+			"--- old\n+++ new\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		{
+			"two hunks",
+			lines(1, 16), strings.Replace(strings.Replace(lines(1, 16), "b\n", "", 1), "o\n", "o\nO\n", 1),
+			"--- old\n+++ new\n@@ -1,5 +1,4 @@\n a\n-b\n c\n d\n e\n@@ -13,4 +12,5 @@\n m\n n\n o\n+O\n p\n",
+		},
+		{
+			"one hunk for near changes",
+			lines(1, 9), strings.Replace(strings.Replace(lines(1, 9), "b\n", "B\n", 1), "h\n", "H\n", 1),
+			"--- old\n+++ new\n@@ -1,9 +1,9 @@\n a\n-b\n+B\n c\n d\n e\n f\n g\n-h\n+H\n i\n",
+		},
+		{"no final newline", "a", "a\n", ""},
+	} {
+		var diff strings.Builder
+		unifiedDiff(&diff, "old", "new", test.old, test.new)
+		if diff.String() != test.diff {
+			t.Errorf("%s: diff is\n%s\nexpected\n%s", test.name, diff.String(), test.diff)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// directivePrefix starts the comment lines that give godocdown directions
+// about a declaration, like "//godocdown:output-lang json". Like other
+// directives, they are left out of the documentation.
+const directivePrefix = "//godocdown:"
+
+// knownDirectives are the names of the godocdown directives, and whether
+// each takes an argument:
+//
+//	//godocdown:ignore               Leave the declaration out
+//	//godocdown:include docs/x.md    Splice in a Markdown file (see markIncludes)
+//	//godocdown:category Encoding    List the declaration under a category in the index
+//	//godocdown:weight 10            Order the declaration before lighter ones
+//	//godocdown:lang golang          Fence the code with another language
+//	//godocdown:output-lang json     Fence the output of an example with a language
+//	//godocdown:title Quick start    Title the package's page, or an example
+//	//godocdown:stability beta       Badge the declaration as experimental, beta, or stable
+//	//godocdown:term Shard: a part   Define a term for the glossary (see termDefinitions)
+//	//godocdown:env API_TOKEN Token  Document an environment variable (see envVariables)
+//	//godocdown:exit 2 Bad flags     Document an exit code (see exitCodes)
+//	//godocdown:config               Make a configuration reference of a struct
+//	//godocdown:quickstart           Show an example in a "Quick start" section
+var knownDirectives = map[string]bool{
+	"ignore":      false,
+	"include":     true,
+	"category":    true,
+	"weight":      true,
+	"lang":        true,
+	"output-lang": true,
+	"title":       true,
+	"stability":   true,
+	"term":        true,
+	"env":         true,
+	"exit":        true,
+	"config":      false,
+	"quickstart":  false,
+}
+
+// directives reads the godocdown directives in a doc comment, mapping the
+// name of each to its argument. CommentGroup.Text drops directives, so they
+// are read from the raw comments.
+func directives(group *ast.CommentGroup) map[string]string {
+	result := map[string]string{}
+	if group == nil {
+		return result
+	}
+	for _, comment := range group.List {
+		if !strings.HasPrefix(comment.Text, directivePrefix) {
+			continue
+		}
+		directive := strings.TrimPrefix(comment.Text, directivePrefix)
+		name, argument, _ := strings.Cut(directive, " ")
+		result[name] = strings.TrimSpace(argument)
+	}
+	return result
+}
+
+// declarationDirectives maps the position of each declaration in files that
+// has godocdown directives to them, and the position of each package clause
+// to the directives of the package comment above it. It has to be read
+// before go/doc takes the doc comments out of the declarations.
+func declarationDirectives(files map[string]*ast.File) map[token.Pos]map[string]string {
+	result := map[token.Pos]map[string]string{}
+	add := func(position token.Pos, group *ast.CommentGroup) {
+		if found := directives(group); len(found) > 0 {
+			result[position] = found
+		}
+	}
+	for _, file := range files {
+		add(file.Package, file.Doc)
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				add(decl.Pos(), decl.Doc)
+			case *ast.GenDecl:
+				add(decl.Pos(), decl.Doc)
+				if decl.Tok != token.TYPE || !decl.Lparen.IsValid() {
+					continue
+				}
+				// go/doc gives each type of a group a declaration of its
+				// own, at the position of its spec
+				for _, spec := range decl.Specs {
+					if spec := spec.(*ast.TypeSpec); spec.Doc != nil {
+						add(spec.Pos(), spec.Doc)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// packageDirectives reads the godocdown directives of the package comment
+func (self *Document) packageDirectives() map[string]string {
+	for _, file := range self.files {
+		if found, ok := self.directives[file.Package]; ok {
+			return found
+		}
+	}
+	return map[string]string{}
+}
+
+// symbolNode finds the declaration of a symbol of the package, named like
+// "F", "T", "T.M", or a constant or variable, or nil for an unknown symbol
+func (self *Document) symbolNode(symbol string) ast.Node {
+	if typeName, method, ok := strings.Cut(symbol, "."); ok {
+		if entry := self.findMethod(typeName, method); entry != nil {
+			return entry.Decl
+		}
+		return nil
+	}
+	if entry := self.findFunc(symbol); entry != nil {
+		return entry.Decl
+	}
+	if entry := self.findType(symbol); entry != nil {
+		return entry.Decl
+	}
+	var found ast.Node
+	values := func(list []*doc.Value) {
+		for _, value := range list {
+			for _, name := range value.Names {
+				if name == symbol && found == nil {
+					found = value.Decl
+				}
+			}
+		}
+	}
+	values(self.pkg.Consts)
+	values(self.pkg.Vars)
+	for _, entry := range self.pkg.Types {
+		values(entry.Consts)
+		values(entry.Vars)
+	}
+	return found
+}
+
+// Directive is the argument of a godocdown directive for templates, like
+// {{ .Directive "Client" "category" }}, with "" for the package comment.
+// A directive without an argument (like ignore) is "true" when present.
+func (self *Document) Directive(symbol, name string) string {
+	found := self.packageDirectives()
+	if symbol != "" {
+		node := self.symbolNode(symbol)
+		if node == nil {
+			return ""
+		}
+		found = self.declDirectives(node)
+	}
+	argument, ok := found[name]
+	if ok && argument == "" {
+		return "true"
+	}
+	return argument
+}
+
+// checkDirectives warns about unknown godocdown directives, and directives
+// with a missing or unexpected argument
+func (self *Document) checkDirectives() []warning {
+	var warnings []warning
+	check := func(position token.Pos, found map[string]string) {
+		var names []string
+		for name := range found {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			argument := found[name]
+			takesArgument, known := knownDirectives[name]
+			message := ""
+			switch {
+			case !known:
+				message = fmt.Sprintf("unknown directive %s%s", directivePrefix, name)
+			case takesArgument && argument == "":
+				message = fmt.Sprintf("directive %s%s needs an argument", directivePrefix, name)
+			case !takesArgument && argument != "":
+				message = fmt.Sprintf("directive %s%s takes no argument", directivePrefix, name)
+			case name == "stability" && stabilityColors[strings.ToLower(argument)] == "":
+				message = fmt.Sprintf("directive %sstability needs experimental, beta, or stable: %s", directivePrefix, argument)
+			case name == "weight":
+				if _, err := strconv.Atoi(argument); err != nil {
+					message = fmt.Sprintf("directive %sweight needs a number: %s", directivePrefix, argument)
+				}
+			}
+			if message != "" {
+				warnings = append(warnings, newWarning("directives", self.fset.Position(position), "%s", message))
+			}
+		}
+	}
+
+	var positions []token.Pos
+	for position := range self.directives {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i] < positions[j]
+	})
+	for _, position := range positions {
+		check(position, self.directives[position])
+	}
+	for _, example := range self.Examples {
+		if decl := self.exampleDecl(example.Name); decl != nil {
+			check(decl.Pos(), directives(decl.Doc))
+		}
+	}
+	return warnings
+}
+
+// declDirectives reads the godocdown directives of a declaration
+func (self *Document) declDirectives(node ast.Node) map[string]string {
+	if found, ok := self.directives[node.Pos()]; ok {
+		return found
+	}
+	return map[string]string{}
+}
+
+// codeOf is the code block for a declaration, or with -platforms, the code
+// blocks for each platform if it is not the same on all of them
+func (self *Document) codeOf(node ast.Node) string {
+	if variants := self.platformVariants(node); variants != nil {
+		return self.platformCode(variants)
+	}
+	return self.codeOfDecl(node)
+}
+
+// codeOfDecl is the code block for a declaration, fenced with the language
+// of its "//godocdown:lang" directive, or the style's
+func (self *Document) codeOfDecl(node ast.Node) string {
+	style := self.style
+	if language, ok := self.declDirectives(node)["lang"]; ok {
+		style.CodeLanguage = language
+	}
+	return indentNode(self.declSource(node), style)
+}
+
+// exampleDecl finds the function of the example with the given name
+func (self *Document) exampleDecl(name string) *ast.FuncDecl {
+	for _, file := range self.testFiles {
+		for _, decl := range file.Decls {
+			if function, ok := decl.(*ast.FuncDecl); ok && function.Recv == nil && function.Name.Name == "Example"+name {
+				return function
+			}
+		}
+	}
+	return nil
+}
+
+// exampleDirectives reads the godocdown directives of the example with the
+// given name. The test files are not given to go/doc, so they are read from
+// its doc comment.
+func (self *Document) exampleDirectives(name string) map[string]string {
+	if decl := self.exampleDecl(name); decl != nil {
+		return directives(decl.Doc)
+	}
+	return map[string]string{}
+}
+
+// exampleOutputLanguage is the language to fence the output of an example
+// with: from its "//godocdown:output-lang" directive, or the style's
+func (self *Document) exampleOutputLanguage(name string) string {
+	if language, ok := self.exampleDirectives(name)["output-lang"]; ok {
+		return language
+	}
+	return self.style.ExampleOutputLanguage
+}
+
+// exampleLanguage is the language to fence the code of an example with:
+// from its "//godocdown:lang" directive, or the style's
+func (self *Document) exampleLanguage(name string) string {
+	if language, ok := self.exampleDirectives(name)["lang"]; ok {
+		return language
+	}
+	return self.style.CodeLanguage
+}
+
+// category is the category of a declaration in the index, from its
+// "//godocdown:category" directive, or "" for none
+func (self *Document) category(node ast.Node) string {
+	return self.declDirectives(node)["category"]
+}
+
+// ignored reports whether a declaration has a "//godocdown:ignore" directive
+func (self *Document) ignored(node ast.Node) bool {
+	_, ok := self.declDirectives(node)["ignore"]
+	return ok
+}
+
+// dropIgnored leaves the declarations with a "//godocdown:ignore" directive
+// out of the package, along with their examples, for symbols that have to
+// be exported (for code generators or reflection, say) but should not be
+// advertised. A type takes its constructors, methods, and values with it.
+// The examples the style's ExampleFilter leaves out go too.
+func (self *Document) dropIgnored() {
+	ignored := map[string]bool{}
+	values := func(list []*doc.Value) []*doc.Value {
+		var kept []*doc.Value
+		for _, value := range list {
+			if !self.ignored(value.Decl) {
+				kept = append(kept, value)
+			}
+		}
+		return kept
+	}
+	funcs := func(list []*doc.Func, prefix string) []*doc.Func {
+		var kept []*doc.Func
+		for _, entry := range list {
+			if self.ignored(entry.Decl) {
+				ignored[prefix+entry.Name] = true
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		return kept
+	}
+
+	self.pkg.Consts = values(self.pkg.Consts)
+	self.pkg.Vars = values(self.pkg.Vars)
+	self.pkg.Funcs = funcs(self.pkg.Funcs, "")
+	var types []*doc.Type
+	for _, entry := range self.pkg.Types {
+		if self.ignored(entry.Decl) {
+			ignored[entry.Name] = true
+			for _, constructor := range entry.Funcs {
+				ignored[constructor.Name] = true
+			}
+			for _, method := range entry.Methods {
+				ignored[entry.Name+"."+method.Name] = true
+			}
+			continue
+		}
+		entry.Consts = values(entry.Consts)
+		entry.Vars = values(entry.Vars)
+		entry.Funcs = funcs(entry.Funcs, "")
+		entry.Methods = funcs(entry.Methods, entry.Name+".")
+		types = append(types, entry)
+	}
+	self.pkg.Types = types
+
+	var examples Examples
+	for _, example := range self.Examples {
+		if _, ok := self.exampleDirectives(example.Name)["ignore"]; ok || ignored[exampleSymbol(example.Name)] || self.filteredExample(example.Name) {
+			continue
+		}
+		examples = append(examples, example)
+	}
+	self.Examples = examples
+}
+
+// weight is how heavy the symbol with the given name is, from the style's
+// Weights or its "//godocdown:weight" directive: heavier symbols come first
+func (self *Document) weight(name string, node ast.Node) int {
+	if weight, ok := self.style.Weights[name]; ok {
+		return weight
+	}
+	weight, _ := strconv.Atoi(self.declDirectives(node)["weight"])
+	return weight
+}
+
+// orderByWeight puts the heavier declarations of the package first in each
+// of its lists, keeping the order of those that weigh the same
+func (self *Document) orderByWeight() {
+	values := func(list []*doc.Value) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return self.weight(list[i].Names[0], list[i].Decl) > self.weight(list[j].Names[0], list[j].Decl)
+		})
+	}
+	funcs := func(list []*doc.Func, prefix string) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return self.weight(prefix+list[i].Name, list[i].Decl) > self.weight(prefix+list[j].Name, list[j].Decl)
+		})
+	}
+
+	values(self.pkg.Consts)
+	values(self.pkg.Vars)
+	funcs(self.pkg.Funcs, "")
+	for _, entry := range self.pkg.Types {
+		values(entry.Consts)
+		values(entry.Vars)
+		funcs(entry.Funcs, "")
+		funcs(entry.Methods, entry.Name+".")
+	}
+	types := self.pkg.Types
+	sort.SliceStable(types, func(i, j int) bool {
+		return self.weight(types[i].Name, types[i].Decl) > self.weight(types[j].Name, types[j].Decl)
+	})
+}
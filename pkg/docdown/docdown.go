@@ -0,0 +1,95 @@
+// Package docdown generates Go documentation in a GitHub-friendly Markdown
+// format. It is what the godocdown command is made of, for tools that
+// generate documentation themselves, without running the command:
+//
+//	document, err := docdown.Load("./pkg/client")
+//	if err != nil {
+//		return err
+//	}
+//	markdown, err := document.Render(docdown.DefaultStyle)
+//
+// A Style says how a document is rendered: DefaultStyle is what the command
+// renders without flags, and GomarkdocStyle what it renders with
+// -flavor=gomarkdoc. LoadContext and RenderContext are Load and Render with a
+// context, to give up when it is done. Main runs the command itself.
+package docdown
+
+import (
+	"context"
+	"go/doc"
+	"slices"
+	"strings"
+)
+
+// Load parses the package at path (a directory, an import path, or a single
+// source file) for documenting. It returns an error wrapping ErrNoPackage
+// if there is no package there.
+func Load(path string) (*Document, error) {
+	return LoadContext(context.Background(), path)
+}
+
+// LoadContext is Load, which gives up with ctx's error when ctx is done
+func LoadContext(ctx context.Context, path string) (*Document, error) {
+	return parseTarget(ctx, path, Style{})
+}
+
+// Render renders the documentation of the package as Markdown, in style.
+// All of the choices of the style are made here, even which files and
+// package of the directory to document (for which the package is parsed
+// again), so a document can be rendered in any number of styles. Rendering
+// leaves the document as it is.
+func (self *Document) Render(style Style) (string, error) {
+	return self.RenderContext(context.Background(), style)
+}
+
+// RenderContext is Render, which gives up with ctx's error when ctx is done
+func (self *Document) RenderContext(ctx context.Context, style Style) (string, error) {
+	document := self.clone()
+	if !style.parsesLike(self.style) {
+		parsed, err := parseDocument(ctx, self.source, self.absPath, self.ImportPath, style)
+		if err != nil {
+			return "", err
+		}
+		document = parsed
+	}
+	document.style = style
+	document.arrange()
+	var markdown strings.Builder
+	err := renderDocumentTo(ctx, &markdown, document)
+	if err != nil {
+		return "", err
+	}
+	return markdown.String(), nil
+}
+
+// parsesLike reports whether the style parses a package like other: from
+// the same files, choosing the same package
+func (self Style) parsesLike(other Style) bool {
+	return self.Package == other.Package && self.SkipGenerated == other.SkipGenerated &&
+		slices.Equal(self.Platforms, other.Platforms)
+}
+
+// clone is a copy of the document that can be arranged and rendered in
+// another style, with lists of its own of the symbols and examples of the
+// package
+func (self *Document) clone() *Document {
+	document := *self
+	pkg := *self.pkg
+	pkg.Consts = slices.Clone(pkg.Consts)
+	pkg.Vars = slices.Clone(pkg.Vars)
+	pkg.Funcs = slices.Clone(pkg.Funcs)
+	pkg.Types = make([]*doc.Type, len(self.pkg.Types))
+	for i, entry := range self.pkg.Types {
+		typeCopy := *entry
+		typeCopy.Consts = slices.Clone(entry.Consts)
+		typeCopy.Vars = slices.Clone(entry.Vars)
+		typeCopy.Funcs = slices.Clone(entry.Funcs)
+		typeCopy.Methods = slices.Clone(entry.Methods)
+		pkg.Types[i] = &typeCopy
+	}
+	document.pkg = &pkg
+	document.Examples = slices.Clone(self.Examples)
+	document.footerEmitted = false
+	document.sectionsEmitted = nil
+	return &document
+}
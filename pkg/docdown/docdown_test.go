@@ -0,0 +1,158 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":              "module example.com/tp\n",
+		"tp.go":               "// Package tp is a test package.\npackage tp\n\n// Client is a client.\ntype Client struct{}\n\n// NewClient makes a Client.\nfunc NewClient() *Client { return nil }\n\n// Close closes it.\nfunc (self *Client) Close() {}\n\n// Greet says hello.\nfunc Greet() {}\n",
+		".godocdown.template": "Found {{ .Name }}\n",
+		"other.tmpl":          "Given {{ .Name }}\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	render := func(style Style) string {
+		t.Helper()
+		output, err := document.Render(style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	style := DefaultStyle
+	style.NoTemplate = true
+	full := render(style)
+	for _, expected := range []string{"func Greet()", "func NewClient()", "func (self *Client) Close()"} {
+		if !strings.Contains(full, expected) {
+			t.Errorf("the output is missing %q:\n%s", expected, full)
+		}
+	}
+	if strings.Contains(full, "\r") || !strings.HasSuffix(full, "\n") {
+		t.Errorf("the output does not end with a line feed:\n%q", full)
+	}
+
+	// Without functions, which leaves the document alone
+	style.NoFuncs = true
+	if output := render(style); strings.Contains(output, "Greet") || strings.Contains(output, "Close") || !strings.Contains(output, "type Client struct") {
+		t.Errorf("-no-funcs output is\n%s", output)
+	}
+	style.NoFuncs = false
+	if output := render(style); output != full {
+		t.Errorf("a second rendering is\n%s\nexpected\n%s", output, full)
+	}
+
+	style.EOL, style.FinalNewline = "crlf", false
+	if output := render(style); output != strings.TrimSuffix(strings.ReplaceAll(full, "\n", "\r\n"), "\r\n") {
+		t.Errorf("-eol=crlf -final-newline=false output is\n%q", output)
+	}
+
+	for _, test := range []struct {
+		template   string
+		noTemplate bool
+		expected   string
+	}{
+		{"", false, "Found tp\n"},
+		{filepath.Join(dir, "other.tmpl"), false, "Given tp\n"},
+		{filepath.Join(dir, "other.tmpl"), true, full},
+	} {
+		style := DefaultStyle
+		style.Template, style.NoTemplate = test.template, test.noTemplate
+		if output := render(style); output != test.expected {
+			t.Errorf("template %q (no template %v) output is\n%s\nexpected\n%s", test.template, test.noTemplate, output, test.expected)
+		}
+	}
+}
+
+func TestRenderChoices(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":   "module example.com/tp\n",
+		"tp.go":    "// Package tp is a test package.\npackage tp\n\n// Alpha is first.\nfunc Alpha() {}\n\n// Greet says hello.\nfunc Greet() {}\n",
+		"gen.go":   "// Code generated by hand. DO NOT EDIT.\n\npackage tp\n\n// Generated is generated.\nfunc Generated() {}\n",
+		"other.go": "//go:build ignore\n\n// Package other is another package.\npackage other\n\n// Other is in the other package.\nfunc Other() {}\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	render := func(style Style) string {
+		t.Helper()
+		style.NoTemplate = true
+		output, err := document.Render(style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	var log bytes.Buffer
+	style := DefaultStyle
+	style.Logger = slog.New(slog.NewTextHandler(&log, nil))
+	full := render(style)
+	if !strings.Contains(full, "func Generated()") || strings.Contains(full, "func Other()") {
+		t.Errorf("the output is\n%s", full)
+	}
+	if !strings.Contains(log.String(), "Found more than one package") {
+		t.Errorf("the choice of package was not logged: %q", log.String())
+	}
+	if strings.Index(full, "func Alpha()") > strings.Index(full, "func Greet()") {
+		t.Errorf("Alpha is not first:\n%s", full)
+	}
+
+	// The files, the package, and the order are all chosen by the style
+	for _, test := range []struct {
+		name        string
+		change      func(*Style)
+		has, hasNot string
+	}{
+		{"SkipGenerated", func(style *Style) { style.SkipGenerated = true }, "func Greet()", "func Generated()"},
+		{"Package", func(style *Style) { style.Package = "other" }, "func Other()", "func Greet()"},
+	} {
+		style := DefaultStyle
+		test.change(&style)
+		output := render(style)
+		if !strings.Contains(output, test.has) || strings.Contains(output, test.hasNot) {
+			t.Errorf("the output with %s is\n%s", test.name, output)
+		}
+	}
+	style = DefaultStyle
+	style.Weights = map[string]int{"Greet": 1}
+	if output := render(style); strings.Index(output, "func Greet()") > strings.Index(output, "func Alpha()") {
+		t.Errorf("Greet is not first with its weight:\n%s", output)
+	}
+	if output := render(DefaultStyle); output != full {
+		t.Errorf("rendering again is\n%s\nexpected\n%s", output, full)
+	}
+}
+
+func TestContext(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/tp\n",
+		"tp.go":  "// Package tp is a test package.\npackage tp\n",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("loading with a canceled context is %v, expected context.Canceled", err)
+	}
+	document, err := LoadContext(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := document.RenderContext(ctx, DefaultStyle); !errors.Is(err, context.Canceled) {
+		t.Errorf("rendering with a canceled context is %v, expected context.Canceled", err)
+	}
+}
@@ -0,0 +1,618 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// docsetDocuments is the directory the pages of a docset go in, within it
+func docsetDocuments(dir string) string {
+	return filepath.Join(dir, "Contents", "Resources", "Documents")
+}
+
+// docsetName is the name of the docset in dir, from the name of dir: Client
+// for Client.docset
+func docsetName(dir string) string {
+	return strings.TrimSuffix(filepath.Base(filepath.Clean(dir)), ".docset")
+}
+
+// docsetEntry is a row of the search index of a docset: a symbol, its kind
+// (as Dash names them), and the page and anchor it is documented at
+type docsetEntry struct {
+	name, kind, path string
+}
+
+// writeDocset finishes the docset the pages of jobs were written to, with
+// -format=docset: its Info.plist, and its search index of the packages and
+// their symbols
+func writeDocset(ctx context.Context, jobs []*job, style Style) error {
+	documents, err := filepath.Abs(docsetDocuments(style.OutDir))
+	if err != nil {
+		return err
+	}
+	var entries []docsetEntry
+	start := ""
+	for _, job := range jobs {
+		if job.err != nil || job.document == nil {
+			continue
+		}
+		page, err := filepath.Rel(documents, job.path)
+		if err != nil {
+			return err
+		}
+		page = filepath.ToSlash(page)
+		if start == "" {
+			start = page
+		}
+		entries = append(entries, docsetEntries(job.document, page)...)
+	}
+	if index := indexPath(); index != "" {
+		index, err = filepath.Abs(index)
+		if err != nil {
+			return err
+		}
+		if page, err := filepath.Rel(documents, index); err == nil && !strings.HasPrefix(page, "..") {
+			start = filepath.ToSlash(page)
+		}
+	}
+
+	err = writeOutputTo(filepath.Join(style.OutDir, "Contents", "Info.plist"), os.Stdout, func(writer io.Writer) error {
+		return writeInfoPlist(writer, docsetName(style.OutDir), start)
+	})
+	if err != nil {
+		return err
+	}
+	err = writeDocsetIndex(ctx, filepath.Join(style.OutDir, "Contents", "Resources", "docSet.dsidx"), entries)
+	if err != nil {
+		return err
+	}
+	style.log().Info("wrote the docset", "path", style.OutDir, "entries", len(entries))
+	return nil
+}
+
+// docsetEntries are the package documented on page and its symbols, for the
+// search index
+func docsetEntries(document *Document, page string) []docsetEntry {
+	var entries []docsetEntry
+	for _, symbol := range document.symbols() {
+		entries = append(entries, docsetEntry{symbol.name, symbol.kind, symbol.link(page)})
+	}
+	return entries
+}
+
+// writeInfoPlist writes the Info.plist of a docset, which Dash and Zeal
+// read its name and the page to open it at from
+func writeInfoPlist(writer io.Writer, name, start string) error {
+	identifier := strings.ToLower(strings.Join(strings.Fields(name), "-"))
+	_, err := fmt.Fprintf(writer, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>%s</string>
+	<key>CFBundleName</key>
+	<string>%s</string>
+	<key>DocSetPlatformFamily</key>
+	<string>%s</string>
+	<key>isDashDocset</key>
+	<true/>
+	<key>dashIndexFilePath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, html.EscapeString(identifier), html.EscapeString(name), html.EscapeString(identifier), html.EscapeString(start))
+	return err
+}
+
+// writeDocsetIndex writes the search index of a docset, the SQLite database
+// Dash and Zeal search, with the sqlite3 command
+func writeDocsetIndex(ctx context.Context, path string, entries []docsetEntry) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	quote := func(text string) string {
+		return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+	}
+	var script bytes.Buffer
+	script.WriteString("BEGIN;\n")
+	script.WriteString("CREATE TABLE searchIndex(id INTEGER PRIMARY KEY, name TEXT, type TEXT, path TEXT);\n")
+	script.WriteString("CREATE UNIQUE INDEX anchor ON searchIndex (name, type, path);\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&script, "INSERT OR IGNORE INTO searchIndex(name, type, path) VALUES (%s, %s, %s);\n",
+			quote(entry.name), quote(entry.kind), quote(entry.path))
+	}
+	script.WriteString("COMMIT;\n")
+
+	command := exec.CommandContext(ctx, "sqlite3", path)
+	command.Stdin = &script
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	err = command.Run()
+	if err != nil {
+		return fmt.Errorf("Error writing the search index of the docset: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// docsetStyle is the style sheet of the pages of a docset
+const docsetStyle = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; max-width: 60em; margin: 1em auto; padding: 0 1em; color: #1f2328; }
+pre { background: #f6f8fa; padding: 0.75em 1em; overflow: auto; border-radius: 6px; }
+code { font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 90%; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.25em 0.75em; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 0.25em solid #d0d7de; color: #59636e; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; margin-top: 1.5em; }
+a { color: #0969da; }
+`
+
+// htmlPage is the Markdown documentation as a page of a docset, titled with
+// its first heading
+func htmlPage(markdown string) string {
+	title := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		if match := markdownATX_Regexp.FindStringSubmatch(line); match != nil {
+			title = markdownHeadingID_Regexp.ReplaceAllString(match[2], "")
+			break
+		}
+	}
+	return fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n%s</body>\n</html>\n",
+		html.EscapeString(plainText(title)), docsetStyle, markdownHTML(markdown))
+}
+
+var (
+	markdownATX_Regexp       = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?[ \t]*#*[ \t]*$`)
+	markdownHeadingID_Regexp = regexp.MustCompile(`[ \t]*\{#([^}\s]+)\}[ \t]*$`)
+	markdownSetext_Regexp    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	markdownItem_Regexp      = regexp.MustCompile(`^( {0,3})([-*+]|\d+[.)])( +|$)`)
+	markdownTableRule_Regexp = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	markdownAutolink_Regexp  = regexp.MustCompile(`^<((?:https?|mailto|ftp):[^<>\s]+)>`)
+	markdownComment_Regexp   = regexp.MustCompile(`^<!--(?s:.*?)-->`)
+)
+
+// markdownHTML converts the Markdown godocdown writes to HTML: headings
+// (with their anchors), paragraphs, lists, block quotes, tables, code blocks,
+// and the inline Markdown of them (see inlineHTML). HTML is passed through.
+// It is not a full Markdown implementation, just enough for the
+// documentation.
+func markdownHTML(markdown string) string {
+	var out strings.Builder
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			fmt.Fprintf(&out, "<p>%s</p>\n", inlineHTML(strings.TrimSpace(strings.Join(paragraph, "\n"))))
+			paragraph = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+
+		case compactFence_Regexp.MatchString(line):
+			flush()
+			fence := compactFence_Regexp.FindStringSubmatch(line)[1]
+			language := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			var code []string
+			for i++; i < len(lines) && !closesFence(lines[i], fence); i++ {
+				code = append(code, lines[i])
+			}
+			writeCodeBlock(&out, language, code)
+
+		case len(paragraph) == 0 && (strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ")):
+			var code []string
+			for ; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) != "" && !strings.HasPrefix(lines[i], "\t") && !strings.HasPrefix(lines[i], "    ") {
+					break
+				}
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(lines[i], "\t"), "    "))
+			}
+			i--
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			writeCodeBlock(&out, "", code)
+
+		case markdownATX_Regexp.MatchString(line):
+			flush()
+			match := markdownATX_Regexp.FindStringSubmatch(line)
+			writeHeading(&out, len(match[1]), match[2])
+
+		case len(paragraph) > 0 && markdownSetext_Regexp.MatchString(line):
+			level := 1
+			if strings.HasPrefix(trimmed, "-") {
+				level = 2
+			}
+			text := strings.TrimSpace(strings.Join(paragraph, " "))
+			paragraph = nil
+			writeHeading(&out, level, text)
+
+		case len(paragraph) == 0 && strings.HasPrefix(trimmed, "<") && !markdownAutolink_Regexp.MatchString(trimmed):
+			// HTML, to the end of the block, or a heading (as gomarkdoc
+			// puts its anchors right before them)
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !markdownATX_Regexp.MatchString(lines[i]); i++ {
+				out.WriteString(lines[i] + "\n")
+			}
+			i--
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(text, " "))
+			}
+			i--
+			fmt.Fprintf(&out, "<blockquote>\n%s</blockquote>\n", markdownHTML(strings.Join(quote, "\n")))
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && markdownTableRule_Regexp.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			flush()
+			var rows []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, lines[i])
+			}
+			i--
+			writeTable(&out, rows)
+
+		case markdownItem_Regexp.MatchString(line):
+			flush()
+			i = writeList(&out, lines, i) - 1
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return out.String()
+}
+
+// writeCodeBlock writes a code block, marked with its language if it has one
+func writeCodeBlock(out *strings.Builder, language string, code []string) {
+	class := ""
+	if language != "" {
+		class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(strings.Fields(language)[0]))
+	}
+	text := strings.Join(code, "\n")
+	if text != "" {
+		text += "\n"
+	}
+	fmt.Fprintf(out, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(text))
+}
+
+// writeHeading writes a heading, with the anchor it gives with {#anchor}, or
+// that GitHub would give it
+func writeHeading(out *strings.Builder, level int, text string) {
+	id := ""
+	if match := markdownHeadingID_Regexp.FindStringSubmatch(text); match != nil {
+		id = match[1]
+		text = strings.TrimSpace(text[:len(text)-len(match[0])])
+	} else {
+		id = headingID(plainText(text))
+	}
+	fmt.Fprintf(out, "<h%d id=\"%s\">%s</h%d>\n", level, html.EscapeString(id), inlineHTML(text), level)
+}
+
+// writeTable writes the rows of a table, the first of them its header and
+// the second the rule below it
+func writeTable(out *strings.Builder, rows []string) {
+	cells := func(row string) []string {
+		row = strings.TrimSpace(row)
+		row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+		var result []string
+		var cell strings.Builder
+		code := false
+		for i := 0; i < len(row); i++ {
+			switch {
+			case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+				cell.WriteByte('|')
+				i++
+			case row[i] == '`':
+				code = !code
+				cell.WriteByte('`')
+			case row[i] == '|' && !code:
+				result = append(result, strings.TrimSpace(cell.String()))
+				cell.Reset()
+			default:
+				cell.WriteByte(row[i])
+			}
+		}
+		return append(result, strings.TrimSpace(cell.String()))
+	}
+	out.WriteString("<table>\n<thead>\n<tr>")
+	for _, cell := range cells(rows[0]) {
+		fmt.Fprintf(out, "<th>%s</th>", inlineHTML(cell))
+	}
+	out.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, row := range rows[2:] {
+		out.WriteString("<tr>")
+		for _, cell := range cells(row) {
+			fmt.Fprintf(out, "<td>%s</td>", inlineHTML(cell))
+		}
+		out.WriteString("</tr>\n")
+	}
+	out.WriteString("</tbody>\n</table>\n")
+}
+
+// writeList writes the list that starts at lines[start], with the contents
+// of each item converted in turn (so lists nest), and returns the index of
+// the line after it
+func writeList(out *strings.Builder, lines []string, start int) int {
+	first := markdownItem_Regexp.FindStringSubmatch(lines[start])
+	tag := "ul"
+	if first[2][0] >= '0' && first[2][0] <= '9' {
+		tag = "ol"
+	}
+	indent := len(first[1])
+	fmt.Fprintf(out, "<%s>\n", tag)
+	i := start
+	for i < len(lines) {
+		match := markdownItem_Regexp.FindStringSubmatch(lines[i])
+		if match == nil || len(match[1]) != indent || (match[2][0] >= '0' && match[2][0] <= '9') != (tag == "ol") {
+			break
+		}
+		width := len(match[0])
+		if match[3] == "" || len(match[3]) > 4 {
+			width = len(match[1]) + len(match[2]) + 1
+		}
+		item := []string{lines[i][len(match[0]):]}
+		loose := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line ends the list, unless more of the item
+				// follows it
+				if i+1 < len(lines) && indentation(lines[i+1]) >= width && strings.TrimSpace(lines[i+1]) != "" {
+					item = append(item, "")
+					loose = true
+					continue
+				}
+				break
+			}
+			if indentation(line) >= width {
+				item = append(item, unindent(line, width))
+				continue
+			}
+			if markdownItem_Regexp.MatchString(line) || markdownATX_Regexp.MatchString(line) || compactFence_Regexp.MatchString(line) {
+				break
+			}
+			// A lazy continuation of the paragraph
+			item = append(item, strings.TrimSpace(line))
+		}
+		content := markdownHTML(strings.Join(item, "\n"))
+		if !loose {
+			content = strings.TrimSuffix(strings.TrimPrefix(strings.Replace(content, "</p>\n", "\n", 1), "<p>"), "\n")
+		}
+		fmt.Fprintf(out, "<li>%s</li>\n", content)
+		if i < len(lines) && strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) && markdownItem_Regexp.MatchString(lines[i+1]) {
+			// Another item, after a blank line
+			i++
+		}
+	}
+	fmt.Fprintf(out, "</%s>\n", tag)
+	return i
+}
+
+// indentation is the number of columns line is indented by, with tabs to
+// the next multiple of 4
+func indentation(line string) int {
+	columns := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			columns++
+		case '\t':
+			columns += 4 - columns%4
+		default:
+			return columns
+		}
+	}
+	return columns
+}
+
+// unindent takes up to columns of indentation off line
+func unindent(line string, columns int) string {
+	removed := 0
+	for i, c := range line {
+		if removed >= columns || (c != ' ' && c != '\t') {
+			if removed > columns {
+				return strings.Repeat(" ", removed-columns) + line[i:]
+			}
+			return line[i:]
+		}
+		if c == '\t' {
+			removed += 4 - removed%4
+		} else {
+			removed++
+		}
+	}
+	return ""
+}
+
+// markdownPunctuation are the characters a backslash escapes in Markdown
+const markdownPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// inlineHTML converts the inline Markdown of text to HTML: code spans,
+// links, images, autolinks, emphasis, and backslash escapes. HTML tags and
+// entities are passed through, and the rest is escaped.
+func inlineHTML(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		rest := text[i:]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(markdownPunctuation, text[i+1]) >= 0:
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+
+		case c == '\\' && strings.HasPrefix(rest, "\\\n"):
+			out.WriteString("<br>\n")
+			i += 2
+
+		case c == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			end := strings.Index(rest[ticks:], rest[:ticks])
+			if end < 0 {
+				out.WriteString(rest[:ticks])
+				i += ticks
+				continue
+			}
+			code := rest[ticks : ticks+end]
+			if len(code) > 1 && strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") {
+				code = code[1 : len(code)-1]
+			}
+			fmt.Fprintf(&out, "<code>%s</code>", html.EscapeString(strings.ReplaceAll(code, "\n", " ")))
+			i += 2*ticks + end
+
+		case c == '!' && strings.HasPrefix(rest, "!["):
+			if label, destination, end, ok := parseLink(rest[1:]); ok {
+				fmt.Fprintf(&out, `<img src="%s" alt="%s">`, html.EscapeString(destination), html.EscapeString(plainText(label)))
+				i += 1 + end
+				continue
+			}
+			out.WriteByte(c)
+			i++
+
+		case c == '[':
+			if label, destination, end, ok := parseLink(rest); ok {
+				fmt.Fprintf(&out, `<a href="%s">%s</a>`, html.EscapeString(destination), inlineHTML(label))
+				i += end
+				continue
+			}
+			out.WriteByte(c)
+			i++
+
+		case c == '<':
+			if match := markdownAutolink_Regexp.FindStringSubmatch(rest); match != nil {
+				fmt.Fprintf(&out, `<a href="%s">%s</a>`, html.EscapeString(match[1]), html.EscapeString(match[1]))
+				i += len(match[0])
+				continue
+			}
+			if match := markdownComment_Regexp.FindString(rest); match != "" {
+				out.WriteString(match)
+				i += len(match)
+				continue
+			}
+			if location := htmlTag_Regexp.FindStringIndex(rest); location != nil && location[0] == 0 {
+				out.WriteString(rest[:location[1]])
+				i += location[1]
+				continue
+			}
+			out.WriteString("&lt;")
+			i++
+
+		case c == '&':
+			if location := htmlEntity_Regexp.FindStringIndex(rest); location != nil && location[0] == 0 {
+				out.WriteString(rest[:location[1]])
+				i += location[1]
+				continue
+			}
+			out.WriteString("&amp;")
+			i++
+
+		case c == '>':
+			out.WriteString("&gt;")
+			i++
+
+		case c == '*' || c == '_':
+			delimiter := string(c)
+			tag := "em"
+			if strings.HasPrefix(rest, delimiter+delimiter) {
+				delimiter += delimiter
+				tag = "strong"
+			}
+			end := strings.Index(rest[len(delimiter):], delimiter)
+			opens := len(rest) > len(delimiter) && rest[len(delimiter)] != ' '
+			// Underscores within words (snake_case) are not emphasis
+			inWord := c == '_' && i > 0 && isWordByte(text[i-1])
+			if end <= 0 || !opens || inWord || rest[len(delimiter)+end-1] == ' ' {
+				out.WriteString(delimiter)
+				i += len(delimiter)
+				continue
+			}
+			fmt.Fprintf(&out, "<%s>%s</%s>", tag, inlineHTML(rest[len(delimiter):len(delimiter)+end]), tag)
+			i += 2*len(delimiter) + end
+
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// isWordByte reports whether c is a letter or a digit (of ASCII)
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// parseLink parses the link text starts with, "[label](destination)", and
+// returns its label, its destination (without a title), and its length
+func parseLink(text string) (string, string, int, bool) {
+	depth := 0
+	close := -1
+	for i := 0; i < len(text) && close < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				close = i
+			}
+		}
+	}
+	if close < 0 || close+1 >= len(text) || text[close+1] != '(' {
+		return "", "", 0, false
+	}
+	label := text[1:close]
+	rest := text[close+2:]
+	if strings.HasPrefix(rest, "<") {
+		end := strings.Index(rest, ">")
+		if end < 0 {
+			return "", "", 0, false
+		}
+		after := strings.Index(rest[end:], ")")
+		if after < 0 {
+			return "", "", 0, false
+		}
+		return label, rest[1:end], close + 2 + end + after + 1, true
+	}
+	depth = 0
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			destination := strings.TrimSpace(rest[:i])
+			if space := strings.IndexAny(destination, " \t\n"); space >= 0 {
+				// A title
+				destination = destination[:space]
+			}
+			return label, destination, close + 2 + i + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// plainText is the text of inline Markdown, without its markup
+func plainText(text string) string {
+	text = htmlTag_Regexp.ReplaceAllString(inlineHTML(text), "")
+	return html.UnescapeString(text)
+}
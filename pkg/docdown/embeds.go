@@ -0,0 +1,123 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// embedDirective is the directive that embeds files in a variable
+const embedDirective = "//go:embed"
+
+// embeddedAsset is a variable (exported or not) that a "//go:embed"
+// directive fills with the files matching its patterns
+type embeddedAsset struct {
+	Name     string
+	Type     string
+	Patterns []string
+}
+
+// embeddedAssets reads the variables with "//go:embed" directives in files,
+// in the order of the files and of their declarations. Like the
+// directives, they have to be read before go/doc takes the comments out.
+func embeddedAssets(fset *token.FileSet, files map[string]*ast.File) []embeddedAsset {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var assets []embeddedAsset
+	for _, name := range names {
+		for _, decl := range files[name].Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				group := spec.Doc
+				if group == nil && !decl.Lparen.IsValid() {
+					group = decl.Doc
+				}
+				patterns := embedPatterns(group)
+				if len(patterns) == 0 || len(spec.Names) != 1 {
+					continue
+				}
+				asset := embeddedAsset{Name: spec.Names[0].Name, Patterns: patterns}
+				if spec.Type != nil {
+					asset.Type = sourceOfNode(fset, spec.Type)
+				}
+				assets = append(assets, asset)
+			}
+		}
+	}
+	return assets
+}
+
+// embedPatterns are the patterns of the "//go:embed" directives in group,
+// unquoted
+func embedPatterns(group *ast.CommentGroup) []string {
+	if group == nil {
+		return nil
+	}
+	var patterns []string
+	for _, comment := range group.List {
+		rest, ok := strings.CutPrefix(comment.Text, embedDirective)
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+			end := strings.IndexAny(rest, " \t")
+			if rest[0] == '"' || rest[0] == '`' {
+				end = strings.IndexByte(rest[1:], rest[0]) + 2
+			}
+			if end <= 0 || end > len(rest) {
+				end = len(rest)
+			}
+			pattern := rest[:end]
+			if unquoted, err := strconv.Unquote(pattern); err == nil {
+				pattern = unquoted
+			}
+			patterns = append(patterns, pattern)
+			rest = rest[end:]
+		}
+	}
+	return patterns
+}
+
+// renderEmbedsTo writes, with -embeds, the variables the package embeds
+// files in, with their patterns, under EmbedsHeader
+func renderEmbedsTo(writer io.Writer, document *Document) {
+	if !document.style.IncludeEmbeds || len(document.embeds) == 0 {
+		return
+	}
+	style := document.style
+	code := func(list []string) string {
+		return "`" + strings.Join(list, "`, `") + "`"
+	}
+	fmt.Fprintf(writer, "%s\n", style.heading(style.EmbedsHeader))
+	if !style.tables() {
+		for _, asset := range document.embeds {
+			if asset.Type != "" {
+				fmt.Fprintf(writer, " - `%s` (`%s`): %s\n", asset.Name, asset.Type, code(asset.Patterns))
+			} else {
+				fmt.Fprintf(writer, " - `%s`: %s\n", asset.Name, code(asset.Patterns))
+			}
+		}
+		fmt.Fprintf(writer, "\n")
+		return
+	}
+	rows := [][]string{{style.text("Variable"), style.text("Type"), style.text("Files")}}
+	for _, asset := range document.embeds {
+		kind := ""
+		if asset.Type != "" {
+			kind = "`" + asset.Type + "`"
+		}
+		rows = append(rows, []string{"`" + asset.Name + "`", kind, code(asset.Patterns)})
+	}
+	fmt.Fprintf(writer, "%s\n", markdownTable(rows))
+}
@@ -0,0 +1,152 @@
+package docdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	emojiShortcode_Regexp = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+	htmlEntity_Regexp     = regexp.MustCompile(`&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+)
+
+// emojiShortcodes are the shortcodes (as GitHub names them) that -emoji
+// escapes and converts, with their emoji: the common ones, as there are too
+// many to keep them all. Others are left as they are.
+var emojiShortcodes = map[string]string{
+	"+1":                       "👍",
+	"-1":                       "👎",
+	"100":                      "💯",
+	"alarm_clock":              "⏰",
+	"arrow_down":               "⬇️",
+	"arrow_left":               "⬅️",
+	"arrow_right":              "➡️",
+	"arrow_up":                 "⬆️",
+	"bangbang":                 "‼️",
+	"beetle":                   "🪲",
+	"bell":                     "🔔",
+	"bomb":                     "💣",
+	"book":                     "📖",
+	"bookmark":                 "🔖",
+	"books":                    "📚",
+	"boom":                     "💥",
+	"bug":                      "🐛",
+	"bulb":                     "💡",
+	"calendar":                 "📆",
+	"chart_with_upwards_trend": "📈",
+	"clipboard":                "📋",
+	"clock1":                   "🕐",
+	"closed_lock_with_key":     "🔐",
+	"cloud":                    "☁️",
+	"construction":             "🚧",
+	"copyright":                "©️",
+	"crossed_fingers":          "🤞",
+	"dart":                     "🎯",
+	"dash":                     "💨",
+	"exclamation":              "❗",
+	"eyes":                     "👀",
+	"fire":                     "🔥",
+	"floppy_disk":              "💾",
+	"gear":                     "⚙️",
+	"gift":                     "🎁",
+	"globe_with_meridians":     "🌐",
+	"hammer":                   "🔨",
+	"hammer_and_wrench":        "🛠️",
+	"heart":                    "❤️",
+	"heavy_check_mark":         "✔️",
+	"heavy_minus_sign":         "➖",
+	"heavy_plus_sign":          "➕",
+	"hourglass":                "⌛",
+	"house":                    "🏠",
+	"information_source":       "ℹ️",
+	"key":                      "🔑",
+	"lady_beetle":              "🐞",
+	"link":                     "🔗",
+	"lock":                     "🔒",
+	"loudspeaker":              "📢",
+	"mag":                      "🔍",
+	"memo":                     "📝",
+	"no_entry":                 "⛔",
+	"no_entry_sign":            "🚫",
+	"ok":                       "🆗",
+	"ok_hand":                  "👌",
+	"package":                  "📦",
+	"paperclip":                "📎",
+	"pencil":                   "📝",
+	"pencil2":                  "✏️",
+	"point_right":              "👉",
+	"pushpin":                  "📌",
+	"question":                 "❓",
+	"recycle":                  "♻️",
+	"red_circle":               "🔴",
+	"rocket":                   "🚀",
+	"rotating_light":           "🚨",
+	"see_no_evil":              "🙈",
+	"shield":                   "🛡️",
+	"skull":                    "💀",
+	"smile":                    "😄",
+	"smiley":                   "😃",
+	"snail":                    "🐌",
+	"sparkles":                 "✨",
+	"star":                     "⭐",
+	"stop_sign":                "🛑",
+	"tada":                     "🎉",
+	"thinking":                 "🤔",
+	"thumbsdown":               "👎",
+	"thumbsup":                 "👍",
+	"tm":                       "™️",
+	"triangular_flag_on_post":  "🚩",
+	"unlock":                   "🔓",
+	"warning":                  "⚠️",
+	"wastebasket":              "🗑️",
+	"wave":                     "👋",
+	"white_check_mark":         "✅",
+	"wink":                     "😉",
+	"wrench":                   "🔧",
+	"x":                        "❌",
+	"zap":                      "⚡",
+}
+
+// markupEntities are the entities -entities=convert keeps, as their
+// characters would be taken for Markdown or HTML
+var markupEntities = map[string]bool{"<": true, ">": true, "&": true}
+
+// applyEmojiPolicy handles the emoji shortcodes (like :warning:) and HTML
+// entities (like &copy;) in doc text as the style says. Each can be passed
+// through as written (the default), escaped so that they show as written, or
+// converted to the characters they stand for, for targets that show them as
+// written. Code blocks and code spans are left alone.
+func applyEmojiPolicy(text string, style Style) string {
+	if style.Entities != "pass" && style.Entities != "" {
+		text = outsideCode(text, func(text string) string {
+			return htmlEntity_Regexp.ReplaceAllStringFunc(text, func(entity string) string {
+				if style.Entities == "escape" {
+					return "&amp;" + entity[1:]
+				}
+				character := html.UnescapeString(entity)
+				if character == entity || markupEntities[character] {
+					return entity
+				}
+				return character
+			})
+		})
+	}
+	if style.Emoji != "pass" && style.Emoji != "" {
+		text = outsideCode(text, func(text string) string {
+			return emojiShortcode_Regexp.ReplaceAllStringFunc(text, func(shortcode string) string {
+				emoji, ok := emojiShortcodes[strings.Trim(shortcode, ":")]
+				switch {
+				case !ok:
+					return shortcode
+				case style.Emoji == "escape":
+					// A zero-width space keeps it from being taken for
+					// one, without showing
+					return ":&#8203;" + shortcode[1:]
+				}
+				return emoji
+			})
+		})
+	}
+	return text
+}
@@ -0,0 +1,112 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+)
+
+// envVariable is an environment variable documented with a directive like
+// "//godocdown:env API_TOKEN The token to authenticate with", in any comment
+// of the package
+type envVariable struct {
+	Name        string
+	Description string
+
+	position token.Pos
+}
+
+// envDirective is the directive that documents an environment variable
+const envDirective = directivePrefix + "env"
+
+// envVariables reads the env directives in the comments of files, in order.
+// Like the terms, they have to be read before go/doc takes the comments out
+// of the files.
+func envVariables(files map[string]*ast.File) []envVariable {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var variables []envVariable
+	for _, name := range names {
+		for _, group := range files[name].Comments {
+			for _, comment := range group.List {
+				argument, ok := strings.CutPrefix(comment.Text, envDirective+" ")
+				if !ok {
+					continue
+				}
+				variable, description, _ := strings.Cut(strings.TrimSpace(argument), " ")
+				variables = append(variables, envVariable{variable, strings.TrimSpace(description), comment.Pos()})
+			}
+		}
+	}
+	return variables
+}
+
+// environment is the package's environment variables, by name, without the
+// variables that have no description, or the later directives for a
+// variable (which checkEnv warns about)
+func (self *Document) environment() []envVariable {
+	var variables []envVariable
+	seen := map[string]bool{}
+	for _, variable := range self.env {
+		if variable.Name == "" || variable.Description == "" || seen[variable.Name] {
+			continue
+		}
+		seen[variable.Name] = true
+		variables = append(variables, variable)
+	}
+	sort.SliceStable(variables, func(i, j int) bool {
+		return variables[i].Name < variables[j].Name
+	})
+	return variables
+}
+
+// renderEnvTo writes the environment variables of a command under
+// EnvHeader, in a table (or a list, for flavors without tables)
+func renderEnvTo(writer io.Writer, document *Document) {
+	variables := document.environment()
+	if len(variables) == 0 || !document.IsCommand {
+		return
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.EnvHeader))
+	if !style.tables() {
+		for _, variable := range variables {
+			fmt.Fprintf(writer, " - `%s`: %s\n", variable.Name, variable.Description)
+		}
+		fmt.Fprintf(writer, "\n")
+		return
+	}
+	rows := [][]string{{style.text("Variable"), style.text("Description")}}
+	for _, variable := range variables {
+		rows = append(rows, []string{"`" + variable.Name + "`", variable.Description})
+	}
+	fmt.Fprintf(writer, "%s\n", markdownTable(rows))
+}
+
+// checkEnv warns about env directives without a description, variables
+// documented more than once, and directives in a library, which has no
+// table of them
+func (self *Document) checkEnv() []warning {
+	var warnings []warning
+	documented := map[string]bool{}
+	for _, variable := range self.env {
+		position := self.fset.Position(variable.position)
+		switch {
+		case !self.IsCommand:
+			warnings = append(warnings, newWarning("directives", position, "directive %s is only documented for commands", envDirective))
+		case variable.Name == "" || variable.Description == "":
+			warnings = append(warnings, newWarning("directives", position, "directive %s needs a variable and its description, like \"API_TOKEN The token to use\"", envDirective))
+		case documented[variable.Name]:
+			warnings = append(warnings, newWarning("directives", position, "environment variable %s is documented more than once", variable.Name))
+		default:
+			documented[variable.Name] = true
+		}
+	}
+	return warnings
+}
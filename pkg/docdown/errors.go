@@ -0,0 +1,65 @@
+package docdown
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// ErrNoPackage is returned (wrapped, with the directory) when there is
+	// no Go package to document at a target
+	ErrNoPackage = errors.New("Could not find package")
+
+	// ErrNoGoMod is returned (wrapped, with the directory) when a package is
+	// not in a module, so it has no import path
+	ErrNoGoMod = errors.New("Could not find go.mod")
+)
+
+// TemplateError is an error parsing or running a template, with the position
+// in the template where it happened, if text/template reported one
+type TemplateError struct {
+	Path    string // The path of the template file, or the option, like -header
+	Running bool   // Whether the template failed running, not parsing
+	Line    int    // 0 if unknown
+	Column  int    // 0 if unknown
+	Message string
+	Err     error // The error from text/template
+}
+
+func (self *TemplateError) Error() string {
+	doing := "parsing"
+	if self.Running {
+		doing = "running"
+	}
+	position := self.Path
+	if self.Line > 0 {
+		position += ":" + strconv.Itoa(self.Line)
+		if self.Column > 0 {
+			position += ":" + strconv.Itoa(self.Column)
+		}
+	}
+	return fmt.Sprintf("Error %s template %s: %s", doing, position, self.Message)
+}
+
+func (self *TemplateError) Unwrap() error {
+	return self.Err
+}
+
+// templateError_Regexp matches the errors of text/template, like
+// "template: name:3:12: executing ...", where the column is only given when
+// running
+var templateError_Regexp = regexp.MustCompile(`(?s)^template: [^:]*:(\d+):(?:(\d+):)? (.*)$`)
+
+// templateError wraps an error from text/template in a TemplateError,
+// picking the position out of its message
+func templateError(path string, running bool, err error) error {
+	result := &TemplateError{Path: path, Running: running, Message: err.Error(), Err: err}
+	if match := templateError_Regexp.FindStringSubmatch(err.Error()); match != nil {
+		result.Line, _ = strconv.Atoi(match[1])
+		result.Column, _ = strconv.Atoi(match[2])
+		result.Message = match[3]
+	}
+	return result
+}
@@ -0,0 +1,180 @@
+package docdown
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exampleWeight is how heavy the example with the given name is, from the
+// style's Weights (named like ExampleClient_basic) or its
+// "//godocdown:weight" directive: heavier examples come first
+func (self *Document) exampleWeight(name string) int {
+	if weight, ok := self.style.Weights["Example"+name]; ok {
+		return weight
+	}
+	weight, _ := strconv.Atoi(self.exampleDirectives(name)["weight"])
+	return weight
+}
+
+// filteredExample reports whether the style's ExampleFilter leaves the
+// example with the given name out
+func (self *Document) filteredExample(name string) bool {
+	if self.style.ExampleFilter == nil {
+		return false
+	}
+	return self.style.ExampleFilter.MatchString("Example"+name) == self.style.SkipExamples
+}
+
+// pinIndex is the position of the example with the given name in the
+// style's PinnedExamples, or len(PinnedExamples) if it is not pinned
+func (self *Document) pinIndex(name string) int {
+	for i, pinned := range self.style.PinnedExamples {
+		if strings.TrimPrefix(pinned, "Example") == name {
+			return i
+		}
+	}
+	return len(self.style.PinnedExamples)
+}
+
+// orderExamples puts the examples of the package in the order they are
+// documented in (for each symbol, and in the index): the pinned ones first,
+// as listed, then by weight, then by name, or in the order of the source
+// with -example-order=source
+func (self *Document) orderExamples() {
+	list := self.Examples
+	if self.style.ExampleOrder == "source" {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Code.Pos() < list[j].Code.Pos()
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if pinI, pinJ := self.pinIndex(list[i].Name), self.pinIndex(list[j].Name); pinI != pinJ {
+			return pinI < pinJ
+		}
+		return self.exampleWeight(list[i].Name) > self.exampleWeight(list[j].Name)
+	})
+}
+
+// exampleHide and exampleShow are the comment lines that mark the lines of
+// an example to elide from its code, like a long setup, which are replaced
+// by a "// ..." line
+const (
+	exampleHide = directivePrefix + "hide"
+	exampleShow = directivePrefix + "show"
+)
+
+// exampleOutput_Regexp matches the comment with the expected output of an
+// example, which is shown as its output rather than in its code
+var exampleOutput_Regexp = regexp.MustCompile(`^(?i:unordered output|output):`)
+
+// exampleComments are the comments of an example to print with its code:
+// all of them but its output with the style's "comments" cleanup, and
+// otherwise only the lines that mark what to elide
+func (self *Document) exampleComments(example *doc.Example) []*ast.CommentGroup {
+	var comments []*ast.CommentGroup
+	for _, group := range example.Comments {
+		if exampleOutput_Regexp.MatchString(strings.TrimSpace(group.Text())) {
+			continue
+		}
+		if self.style.exampleCleanup("comments") {
+			comments = append(comments, group)
+			continue
+		}
+		for _, comment := range group.List {
+			if comment.Text == exampleHide || comment.Text == exampleShow {
+				comments = append(comments, &ast.CommentGroup{List: []*ast.Comment{comment}})
+			}
+		}
+	}
+	return comments
+}
+
+// sourceWithComments prints node with the comments that are within it
+func sourceWithComments(fset *token.FileSet, node ast.Node, comments []*ast.CommentGroup) string {
+	var within []*ast.CommentGroup
+	for _, group := range comments {
+		if node.Pos() <= group.Pos() && group.End() <= node.End() {
+			within = append(within, group)
+		}
+	}
+	if len(within) == 0 {
+		return sourceOfNode(fset, node)
+	}
+	return sourceOfNode(fset, &printer.CommentedNode{Node: node, Comments: within})
+}
+
+// exampleSource is the code of an example as it is rendered, before it is
+// fenced, with the cleanups of the style's ExampleCode: comments kept,
+// whole-file examples unwrapped (their declarations, then the body of the
+// example function), and gofmt run on it. The lines between
+// "//godocdown:hide" and "//godocdown:show" are elided either way.
+func (self *Document) exampleSource(example *doc.Example) string {
+	comments := self.exampleComments(example)
+	var source string
+	file, ok := example.Code.(*ast.File)
+	if ok && self.style.exampleCleanup("unwrap") {
+		var parts []string
+		for _, decl := range file.Decls {
+			if function, ok := decl.(*ast.FuncDecl); ok && function.Recv == nil && function.Name.Name == "Example"+example.Name {
+				parts = append(parts, exampleBody(sourceWithComments(self.fset, function.Body, comments)))
+				continue
+			}
+			parts = append(parts, sourceWithComments(self.fset, decl, comments))
+		}
+		source = strings.Join(parts, "\n\n")
+	} else {
+		source = sourceWithComments(self.fset, example.Code, comments)
+	}
+
+	source = elideExample(source)
+	source = unexportedFields(source, self.style)
+	if !self.style.KeepDirectives {
+		source = stripDirectives(source)
+	}
+	if self.style.exampleCleanup("gofmt") {
+		if formatted, err := format.Source([]byte(exampleBody(source))); err == nil {
+			source = string(formatted)
+		}
+	}
+	return source
+}
+
+// elideExample replaces the lines of source between "//godocdown:hide" and
+// "//godocdown:show" (or the end) with a "// ..." line
+func elideExample(source string) string {
+	if !strings.Contains(source, exampleHide) {
+		return source
+	}
+	var result []string
+	hiding := false
+	for _, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == exampleHide && !hiding:
+			hiding = true
+			result = append(result, line[:len(line)-len(strings.TrimLeft(line, " \t"))]+"// ...")
+		case trimmed == exampleShow:
+			hiding = false
+		case !hiding:
+			result = append(result, line)
+		}
+	}
+	return strings.Join(result, "\n")
+}
+
+// exampleCleanup reports whether the style's ExampleCode has the cleanup
+func (self Style) exampleCleanup(cleanup string) bool {
+	for _, entry := range self.ExampleCode {
+		if entry == cleanup {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exitCode is an exit status of a command, documented with a directive
+// like "//godocdown:exit 2 The flags were wrong", or by a constant named
+// like ExitUsage with a comment:
+//
+//	const (
+//		exitOK    = 0 // Success
+//		exitUsage = 2 // The flags were wrong
+//	)
+type exitCode struct {
+	Code        int
+	Description string
+
+	position  token.Pos
+	directive bool
+}
+
+// exitDirective is the directive that documents an exit code
+const exitDirective = directivePrefix + "exit"
+
+// exitConstant_Regexp matches the names of the constants taken for exit codes
+var exitConstant_Regexp = regexp.MustCompile(`^[Ee]xit(?:[A-Z0-9_]|$)`)
+
+// exitCodes reads the exit directives in the comments of files, and the
+// exit code constants, in order. Like the terms, they have to be read
+// before go/doc takes the comments (and unexported constants) out of the
+// files.
+func exitCodes(files map[string]*ast.File) []exitCode {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var codes []exitCode
+	for _, name := range names {
+		for _, group := range files[name].Comments {
+			for _, comment := range group.List {
+				argument, ok := strings.CutPrefix(comment.Text, exitDirective+" ")
+				if !ok {
+					continue
+				}
+				code, description, _ := strings.Cut(strings.TrimSpace(argument), " ")
+				value, err := strconv.Atoi(code)
+				if err != nil {
+					value = -1
+				}
+				codes = append(codes, exitCode{value, strings.TrimSpace(description), comment.Pos(), true})
+			}
+		}
+		for _, decl := range files[name].Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+				codes = append(codes, exitConstants(decl)...)
+			}
+		}
+	}
+	return codes
+}
+
+// exitConstants are the exit codes a const declaration defines: its integer
+// constants named like ExitUsage (with iota, and the expressions repeated
+// from the spec before), described by their line or doc comments
+func exitConstants(decl *ast.GenDecl) []exitCode {
+	var codes []exitCode
+	var values []ast.Expr
+	for iota, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		if len(spec.Values) > 0 {
+			values = spec.Values
+		}
+		for i, name := range spec.Names {
+			if !exitConstant_Regexp.MatchString(name.Name) || i >= len(values) {
+				continue
+			}
+			value, ok := constant.Int64Val(constantValue(values[i], iota))
+			if !ok {
+				continue
+			}
+			description := ""
+			if spec.Comment != nil {
+				description = spec.Comment.Text()
+			} else if spec.Doc != nil {
+				description = spec.Doc.Text()
+			}
+			codes = append(codes, exitCode{int(value), strings.Join(strings.Fields(description), " "), name.Pos(), false})
+		}
+	}
+	return codes
+}
+
+// constantValue evaluates a constant integer expression, of literals, iota,
+// and arithmetic, or is unknown
+func constantValue(expression ast.Expr, iota int) constant.Value {
+	switch expression := expression.(type) {
+	case *ast.BasicLit:
+		if expression.Kind == token.INT {
+			return constant.MakeFromLiteral(expression.Value, token.INT, 0)
+		}
+	case *ast.Ident:
+		if expression.Name == "iota" {
+			return constant.MakeInt64(int64(iota))
+		}
+	case *ast.ParenExpr:
+		return constantValue(expression.X, iota)
+	case *ast.UnaryExpr:
+		if x := constantValue(expression.X, iota); x.Kind() != constant.Unknown {
+			return constant.UnaryOp(expression.Op, x, 0)
+		}
+	case *ast.BinaryExpr:
+		x, y := constantValue(expression.X, iota), constantValue(expression.Y, iota)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			break
+		}
+		switch expression.Op {
+		case token.SHL, token.SHR:
+			if shift, ok := constant.Uint64Val(y); ok {
+				return constant.Shift(x, expression.Op, uint(shift))
+			}
+		case token.ADD, token.SUB, token.MUL, token.OR, token.AND, token.XOR:
+			return constant.BinaryOp(x, expression.Op, y)
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				break
+			}
+			if expression.Op == token.QUO {
+				// Integer division
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+			return constant.BinaryOp(x, token.REM, y)
+		}
+	}
+	return constant.MakeUnknown()
+}
+
+// exitTable is the exit codes of the package, by code: those of directives,
+// and for a command, those of constants, unless a directive has the code
+func (self *Document) exitTable() []exitCode {
+	var codes []exitCode
+	seen := map[int]bool{}
+	for _, directive := range []bool{true, false} {
+		for _, code := range self.exits {
+			if code.directive != directive || code.Code < 0 || code.Description == "" || seen[code.Code] {
+				continue
+			}
+			if !code.directive && !self.IsCommand {
+				continue
+			}
+			seen[code.Code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.SliceStable(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+	return codes
+}
+
+// renderExitCodesTo writes the exit codes of the package under
+// ExitCodesHeader, in a table (or a list, for flavors without tables)
+func renderExitCodesTo(writer io.Writer, document *Document) {
+	codes := document.exitTable()
+	if len(codes) == 0 {
+		return
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.ExitCodesHeader))
+	if !style.tables() {
+		for _, code := range codes {
+			fmt.Fprintf(writer, " - `%d`: %s\n", code.Code, code.Description)
+		}
+		fmt.Fprintf(writer, "\n")
+		return
+	}
+	rows := [][]string{{style.text("Code"), style.text("Meaning")}}
+	for _, code := range codes {
+		rows = append(rows, []string{"`" + strconv.Itoa(code.Code) + "`", code.Description})
+	}
+	fmt.Fprintf(writer, "%s\n", markdownTable(rows))
+}
+
+// checkExitCodes warns about exit directives without a code or a
+// description, and codes documented by more than one directive
+func (self *Document) checkExitCodes() []warning {
+	var warnings []warning
+	documented := map[int]bool{}
+	for _, code := range self.exits {
+		if !code.directive {
+			continue
+		}
+		position := self.fset.Position(code.position)
+		switch {
+		case code.Code < 0 || code.Description == "":
+			warnings = append(warnings, newWarning("directives", position, "directive %s needs a code and its description, like \"2 The flags were wrong\"", exitDirective))
+		case documented[code.Code]:
+			warnings = append(warnings, newWarning("directives", position, "exit code %d is documented more than once", code.Code))
+		default:
+			documented[code.Code] = true
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,68 @@
+package docdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// textFilter is a replacement made in the prose of doc comments, given with
+// -filter: a regular expression, or literal text
+type textFilter struct {
+	pattern     *regexp.Regexp // nil for literal text
+	text        string
+	replacement string
+}
+
+// parseFilter reads a -filter, either "s/pattern/replacement/" like sed
+// (with any punctuation as the delimiter, and $1 etc. for the groups of the
+// regular expression), or "text=>replacement" for literal text
+func parseFilter(value string) (textFilter, error) {
+	if len(value) > 1 && value[0] == 's' {
+		delimiter, size := utf8.DecodeRuneInString(value[1:])
+		if unicode.IsPunct(delimiter) || unicode.IsSymbol(delimiter) {
+			parts := strings.Split(value[1+size:], string(delimiter))
+			if len(parts) != 3 || parts[2] != "" {
+				return textFilter{}, fmt.Errorf("Invalid -filter \"%s\": expected s%cpattern%creplacement%c", value, delimiter, delimiter, delimiter)
+			}
+			pattern, err := regexp.Compile(parts[0])
+			if err != nil {
+				return textFilter{}, fmt.Errorf("Invalid -filter \"%s\": %v", value, err)
+			}
+			return textFilter{pattern: pattern, replacement: parts[1]}, nil
+		}
+	}
+	text, replacement, ok := strings.Cut(value, "=>")
+	if !ok || text == "" {
+		return textFilter{}, fmt.Errorf("Invalid -filter \"%s\": expected s/pattern/replacement/ or text=>replacement", value)
+	}
+	return textFilter{text: text, replacement: replacement}, nil
+}
+
+func (self textFilter) apply(text string) string {
+	if self.pattern != nil {
+		return self.pattern.ReplaceAllString(text, self.replacement)
+	}
+	return strings.ReplaceAll(text, self.text, self.replacement)
+}
+
+// applyFilters makes the replacements of the style's filters, in order, in
+// the prose of doc text, leaving its code blocks alone
+func applyFilters(text string, style Style) string {
+	if len(style.Filters) == 0 {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		for _, filter := range style.Filters {
+			line = filter.apply(line)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "")
+}
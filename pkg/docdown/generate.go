@@ -0,0 +1,370 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/doc"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	Time "time"
+)
+
+// job is a single package to be documented
+type job struct {
+	target   string
+	path     string    // Where to write the documentation, or "" for stdout
+	stdout   io.Writer // Where stdout output goes
+	absPath  string
+	links    packageLinks // The other packages documented in this run
+	document *Document    // nil when the output came from the cache
+	info     packageInfo
+	summary  *summary
+	cached   bool
+	written  int64
+	err      error
+	duration Time.Duration
+	locale   string // "" for the default output
+}
+
+// packageInfo is what is known about a package besides its documentation.
+// It is cached alongside the documentation.
+type packageInfo struct {
+	Name       string    `json:"name"`
+	ImportPath string    `json:"importPath"`
+	Synopsis   string    `json:"synopsis"`
+	Stats      stats     `json:"stats"`
+	Assets     []string  `json:"assets,omitempty"`
+	Coverage   coverage  `json:"coverage"`
+	Warnings   []warning `json:"warnings"`
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	writer io.Writer
+	count  *int64
+}
+
+func (self countingWriter) Write(p []byte) (int, error) {
+	n, err := self.writer.Write(p)
+	*self.count += int64(n)
+	return n, err
+}
+
+func (self *job) run(ctx context.Context, style Style, cache *cache) {
+	start := Time.Now()
+	defer func() {
+		self.duration = Time.Since(start)
+	}()
+
+	if ctx.Err() != nil {
+		self.err = canceled(ctx)
+		return
+	}
+
+	_, absPath, err := lookupImport(self.target)
+	if err != nil {
+		self.err = err
+		return
+	}
+	self.absPath = absPath
+
+	key := ""
+	if cache != nil && self.cacheable(style) {
+		key, err = cache.key(absPath, self.locale, self.links)
+		if err != nil {
+			self.err = err
+			return
+		}
+		if entry, ok := cache.get(key, &self.info); ok {
+			defer entry.Close()
+			logger.Debug("using cached documentation", "package", absPath, "key", key)
+			self.cached = true
+			self.err = writeDocumentTo(ctx, style, self.path, self.stdout, func(writer io.Writer) error {
+				_, err := io.Copy(countingWriter{writer, &self.written}, entry)
+				return err
+			})
+			if self.err == nil && style.CopyAssets {
+				self.err = copyAssets(absPath, self.path, self.info.Assets)
+			}
+			return
+		}
+	}
+
+	document, err := loadDocument(ctx, self.target, style)
+	if err != nil {
+		self.err = err
+		return
+	}
+	if ctx.Err() != nil {
+		self.err = canceled(ctx)
+		return
+	}
+	document.links = self.links
+	document.page = self.path
+	err = document.applyPlugins(ctx)
+	if err != nil {
+		self.err = err
+		return
+	}
+	if style.TestCoverage && !isSourceFile(self.target) {
+		document.testCoverage, err = measureTestCoverage(ctx, document.absPath)
+		if err != nil {
+			self.err = err
+			return
+		}
+	}
+	if style.AnnotateChanges != "" && !isSourceFile(self.target) {
+		err = document.loadChanges(ctx, style.AnnotateChanges)
+		if err != nil {
+			self.err = err
+			return
+		}
+	}
+	if style.UsedBy && !isSourceFile(self.target) {
+		err = document.loadUsedBy()
+		if err != nil {
+			self.err = err
+			return
+		}
+	}
+	if style.RunBenchmarks && !isSourceFile(self.target) {
+		document.benchmarks, err = runBenchmarks(ctx, document.absPath, style)
+		if err != nil {
+			self.err = err
+			return
+		}
+	}
+	if style.Split == "type" && self.path != "" {
+		document.split = splitPages(document, self.path)
+	}
+	self.document = document
+	self.info.Name = document.Name
+	self.info.ImportPath = document.ImportPath
+	self.info.Synopsis = doc.Synopsis(document.pkg.Doc)
+	self.info.Stats = document.Stats()
+	self.info.Coverage, self.info.Warnings = document.check()
+
+	var before []byte
+	if *flag_prSummary != "" {
+		err := self.summarize(ctx, style)
+		if err != nil {
+			self.err = err
+			return
+		}
+		if self.path != "" {
+			before = fileHash(self.path)
+		}
+	}
+	defer func() {
+		if self.summary != nil && self.path != "" && self.err == nil {
+			self.summary.regenerated = !bytes.Equal(before, fileHash(self.path))
+		}
+	}()
+
+	self.err = writeDocumentTo(ctx, style, self.path, self.stdout, func(writer io.Writer) error {
+		writer = countingWriter{writer, &self.written}
+		if key == "" {
+			return renderDocumentTo(ctx, writer, document)
+		}
+		entry, err := cache.create(key)
+		if err != nil {
+			return err
+		}
+		err = renderDocumentTo(ctx, io.MultiWriter(writer, entry), document)
+		if err != nil {
+			entry.abort()
+			return err
+		}
+		self.info.Assets = document.assetList()
+		return entry.commit(self.info)
+	})
+	if self.err == nil && document.split != nil {
+		self.err = writeSplitPages(ctx, document, self.path)
+	}
+	if self.err == nil && style.sectionPages() && self.path != "" {
+		self.err = writeSectionPages(ctx, document, self.path)
+	}
+	if self.err == nil && style.Tutorial != "" && self.path != "" {
+		self.err = writeTutorialPage(ctx, document, self.path)
+	}
+	if self.err == nil && style.CopyAssets {
+		self.err = copyAssets(absPath, self.path, document.assetList())
+	}
+	if self.err == nil && self.locale == "" {
+		self.err = writeOutputs(ctx, document)
+	}
+}
+
+// cacheable reports whether the documentation of the job can come from the
+// cache. The summary needs the document itself, not just its
+// documentation, a single file shares its directory (and so its key) with
+// its package, only the first page of a split document (or one with
+// sections or a tutorial on pages of their own) is cached, plugins may read
+// more than the package, benchmarks are measured each time, the revision
+// changes are annotated against may move, docsets and search indexes index
+// the symbols of the document, the users of symbols are in other packages,
+// the outputs of the config are rendered from the document, and the
+// subpackages are in other directories.
+func (self *job) cacheable(style Style) bool {
+	return *flag_prSummary == "" && !isSourceFile(self.target) && !style.IncludeSubpackages &&
+		style.Split == "" && !style.sectionPages() && style.Tutorial == "" && len(style.Plugins) == 0 &&
+		!style.RunBenchmarks && style.AnnotateChanges == "" && style.Format != "docset" &&
+		!style.SearchIndex && !style.UsedBy && len(configOutputs) == 0
+}
+
+// runContext is the context of a run, which is canceled when it is
+// interrupted (as with Ctrl-C) or once -timeout has passed. The packages not
+// yet generated then fail, and the commands running for the others (plugins
+// and -post-process) are killed.
+func runContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if *flag_timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, *flag_timeout, fmt.Errorf("-timeout of %v passed", *flag_timeout))
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// canceled is the error of a job stopped by the cancellation of ctx
+func canceled(ctx context.Context) error {
+	cause := context.Cause(ctx)
+	if cause == context.Canceled {
+		return fmt.Errorf("Interrupted")
+	}
+	return fmt.Errorf("Stopped: %v", cause)
+}
+
+// runJobs parses and renders every job using a bounded pool of workers
+func runJobs(ctx context.Context, jobs []*job, style Style, cache *cache, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan *job)
+	var wait sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for job := range queue {
+				job.run(ctx, style, cache)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wait.Wait()
+}
+
+// generateAll documents every target and returns the exit status for the
+// run. With -output, each package's documentation is written to that path
+// relative to the package directory, and with -out-dir, to a tree of pages
+// in that directory. Otherwise, the documentation for every package is
+// written to stdout in the order the targets were given.
+func generateAll(ctx context.Context, targets []string, style Style, cache *cache) int {
+	paths, err := outputPaths(targets)
+	if err == nil {
+		err = makeOutputDirs(paths)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	toStdout := paths[0] == ""
+	jobs := make([]*job, len(targets))
+	for i, target := range targets {
+		jobs[i] = &job{target: target, path: paths[i]}
+		if toStdout {
+			// Buffer each package so the output stays in order
+			jobs[i].stdout = &bytes.Buffer{}
+		}
+	}
+
+	if !toStdout {
+		// Link the packages to each other
+		links := packageLinks{}
+		for _, job := range jobs {
+			importPath, _, err := lookupImport(job.target)
+			if err == nil && importPath != "" {
+				links[importPath] = job.path
+			}
+			job.links = links
+		}
+	}
+
+	start := Time.Now()
+	runJobs(ctx, jobs, style, cache, *flag_jobs)
+	elapsed := Time.Since(start)
+
+	status := 0
+	first := true
+	for _, job := range jobs {
+		if toStdout && job.err == nil && !debug {
+			if !first {
+				os.Stdout.Write([]byte("\n"))
+			}
+			first = false
+			_, job.err = io.Copy(os.Stdout, job.stdout.(*bytes.Buffer))
+		}
+		if job.err != nil {
+			logger.Error(job.err.Error(), "target", job.target)
+			status = 1
+		}
+	}
+
+	if !toStdout {
+		err := writeIndex(ctx, jobs, style)
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+		}
+	}
+	if style.Format == "docset" {
+		err := writeDocset(ctx, jobs, style)
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+		}
+	}
+	if style.SearchIndex {
+		err := writeSearchIndex(jobs)
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+		}
+	}
+	err = writeReport(jobs, elapsed)
+	if err != nil {
+		logger.Error(err.Error())
+		status = 1
+	}
+	err = writeCoverageBadge(jobs)
+	if err != nil {
+		logger.Error(err.Error())
+		status = 1
+	}
+	if links := checkPageLinks(jobs); links > status {
+		status = links
+	}
+	err = writeSummary(jobs)
+	if err != nil {
+		logger.Error(err.Error())
+		status = 1
+	}
+	emitWarnings(os.Stderr, jobs)
+
+	for _, job := range jobs {
+		logger.Info("generated package", "target", job.target, "duration", job.duration.Round(Time.Microsecond), "cached", job.cached)
+	}
+	logger.Info("generated packages", "count", len(jobs), "duration", elapsed.Round(Time.Millisecond), "jobs", *flag_jobs)
+	return status
+}
@@ -0,0 +1,31 @@
+package docdown
+
+import (
+	"testing"
+)
+
+func TestCacheable(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		target    string
+		style     func(*Style)
+		cacheable bool
+	}{
+		{"default", ".", func(*Style) {}, true},
+		{"source file", "main.go", func(*Style) {}, false},
+		{"subpackages", ".", func(style *Style) { style.IncludeSubpackages = true }, false},
+		{"split", ".", func(style *Style) { style.Split = "type" }, false},
+		{"section page", ".", func(style *Style) { style.Sections = map[string]string{"FAQ": "FAQ.md"} }, false},
+		{"moved section", ".", func(style *Style) { style.Sections = map[string]string{"FAQ": ""} }, true},
+		{"tutorial", ".", func(style *Style) { style.Tutorial = "TUTORIAL.md" }, false},
+		{"changes", ".", func(style *Style) { style.AnnotateChanges = "v1.0.0" }, false},
+		{"used by", ".", func(style *Style) { style.UsedBy = true }, false},
+	} {
+		style := DefaultStyle
+		test.style(&style)
+		job := &job{target: test.target}
+		if cacheable := job.cacheable(style); cacheable != test.cacheable {
+			t.Errorf("%s: cacheable is %v, expected %v", test.name, cacheable, test.cacheable)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+)
+
+// git runs a git command in dir and returns its output
+func git(dir string, arguments ...string) ([]byte, error) {
+	command := exec.Command("git", arguments...)
+	command.Dir = dir
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", strings.Join(arguments, " "), message)
+	}
+	return output, nil
+}
+
+// gitDirFS reads the files in the directory absPath as they were at ref,
+// straight from the git objects, without touching the working tree
+func gitDirFS(absPath, ref string) (fs.FS, error) {
+	prefix, err := git(absPath, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	tree := ref + ":" + strings.TrimSpace(string(prefix))
+
+	// Without --full-tree, ls-tree only lists the entries of the tree that
+	// are below the working directory, as if the tree were the root
+	listing, err := git(absPath, "ls-tree", "-z", "--full-tree", tree)
+	if err != nil {
+		return nil, err
+	}
+	fsys := fstest.MapFS{}
+	for _, line := range strings.Split(string(listing), "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 || !strings.Contains(fields[0], " blob ") {
+			continue
+		}
+		name := fields[1]
+		if !strings.HasSuffix(name, ".go") && !strings.HasPrefix(name, ".godocdown") {
+			continue
+		}
+		object := strings.Fields(fields[0])[2]
+		data, err := git(absPath, "cat-file", "blob", object)
+		if err != nil {
+			return nil, err
+		}
+		fsys[name] = &fstest.MapFile{Data: data, Mode: 0644}
+	}
+	return fsys, nil
+}
+
+// loadDocumentAt loads the package in absPath as it was at ref. It returns
+// nil if the package did not exist then.
+func loadDocumentAt(ctx context.Context, absPath, importPath, ref string, style Style) (*Document, error) {
+	if _, err := git(absPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("Unknown git revision: %s", ref)
+	}
+	fsys, err := gitDirFS(absPath, ref)
+	if err != nil {
+		// The directory did not exist at ref
+		return nil, nil
+	}
+	document, err := loadDocumentFrom(ctx, fsys, absPath, importPath, style)
+	if errors.Is(err, ErrNoPackage) {
+		return nil, nil
+	}
+	return document, err
+}
+
+// sourceLink is the URL of node in the repository given by -repository-url,
+// or "" if there is no repository to link to
+func (self *Document) sourceLink(node ast.Node) string {
+	if self.style.RepositoryURL == "" || node == nil {
+		return ""
+	}
+	if self.repositoryPrefix == nil {
+		prefix := ""
+		if output, err := git(self.absPath, "rev-parse", "--show-prefix"); err == nil {
+			prefix = strings.TrimSpace(string(output))
+		} else {
+			self.style.log().Debug("not linking to source", "target", self.absPath, "error", err)
+		}
+		self.repositoryPrefix = &prefix
+	}
+
+	start := self.fset.Position(node.Pos())
+	end := self.fset.Position(node.End())
+	if !start.IsValid() {
+		return ""
+	}
+	link := fmt.Sprintf("%s/blob/%s/%s%s#L%d",
+		self.style.RepositoryURL, self.style.RepositoryRef,
+		*self.repositoryPrefix, filepath.Base(start.Filename), start.Line)
+	if end.Line > start.Line {
+		link += fmt.Sprintf("-L%d", end.Line)
+	}
+	return link
+}
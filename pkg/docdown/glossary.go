@@ -0,0 +1,153 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// glossaryTerm is a term defined with a directive like
+// "//godocdown:term RingBuffer: a fixed-size circular queue", in any comment
+// of the package
+type glossaryTerm struct {
+	Term       string
+	Definition string
+	Anchor     string
+
+	position token.Pos
+}
+
+// termDirective is the directive that defines a glossary term
+const termDirective = directivePrefix + "term"
+
+// termDefinitions reads the term directives in the comments of files, in
+// order, several to a comment if they like. They have to be read before
+// go/doc takes the comments out of the files.
+func termDefinitions(files map[string]*ast.File) []glossaryTerm {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var terms []glossaryTerm
+	for _, name := range names {
+		for _, group := range files[name].Comments {
+			for _, comment := range group.List {
+				argument, ok := strings.CutPrefix(comment.Text, termDirective+" ")
+				if !ok {
+					continue
+				}
+				term, definition, _ := strings.Cut(argument, ":")
+				term = strings.TrimSpace(term)
+				terms = append(terms, glossaryTerm{term, strings.TrimSpace(definition), "term-" + headingID(term), comment.Pos()})
+			}
+		}
+	}
+	return terms
+}
+
+// glossary is the package's glossary: its terms, alphabetically, without
+// the terms that have no definition, or the later definitions of a term
+// (which checkGlossary warns about)
+func (self *Document) glossary() []glossaryTerm {
+	var terms []glossaryTerm
+	seen := map[string]bool{}
+	for _, term := range self.terms {
+		key := strings.ToLower(term.Term)
+		if term.Term == "" || term.Definition == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return strings.ToLower(terms[i].Term) < strings.ToLower(terms[j].Term)
+	})
+	return terms
+}
+
+// findTerm finds a glossary term by its name (in any case), or nil
+func (self *Document) findTerm(name string) *glossaryTerm {
+	for _, term := range self.glossary() {
+		if strings.EqualFold(term.Term, name) {
+			return &term
+		}
+	}
+	return nil
+}
+
+// termLink_Regexp matches a doc link to a glossary term, like [RingBuffer] or
+// [ring buffer]
+var termLink_Regexp = regexp.MustCompile(`\[([^\[\]]+)\]`)
+
+// linkTerms turns doc links to glossary terms that are not symbols of the
+// package into Markdown links to their definitions. Code blocks are left
+// alone.
+func (self *Document) linkTerms(text string) string {
+	if len(self.terms) == 0 {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		var result strings.Builder
+		last := 0
+		for _, match := range termLink_Regexp.FindAllStringSubmatchIndex(line, -1) {
+			if strings.HasPrefix(line[match[1]:], "(") || strings.HasPrefix(line[match[1]:], ":") {
+				// Already a Markdown link, or a link definition
+				continue
+			}
+			name := line[match[2]:match[3]]
+			term := self.findTerm(name)
+			if term == nil || self.hasSymbol(name) {
+				continue
+			}
+			result.WriteString(line[last:match[1]])
+			fmt.Fprintf(&result, "(%s#%s)", self.termPage, term.Anchor)
+			last = match[1]
+		}
+		result.WriteString(line[last:])
+		lines[i] = result.String()
+	}
+	return strings.Join(lines, "")
+}
+
+// renderGlossaryTo writes the glossary terms of the package, alphabetically,
+// under GlossaryHeader, each with an anchor for doc text to link to
+func renderGlossaryTo(writer io.Writer, document *Document) {
+	terms := document.glossary()
+	if len(terms) == 0 {
+		return
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.GlossaryHeader))
+	for _, term := range terms {
+		fmt.Fprintf(writer, " - <a name=\"%s\"></a>**%s**: %s\n", term.Anchor, term.Term, term.Definition)
+	}
+	fmt.Fprintf(writer, "\n")
+}
+
+// checkGlossary warns about term directives without a definition, and terms
+// that are defined more than once
+func (self *Document) checkGlossary() []warning {
+	var warnings []warning
+	defined := map[string]bool{}
+	for _, term := range self.terms {
+		position := self.fset.Position(term.position)
+		switch key := strings.ToLower(term.Term); {
+		case term.Term == "" || term.Definition == "":
+			warnings = append(warnings, newWarning("directives", position, "directive %s needs a term and its definition, like \"Term: definition\"", termDirective))
+		case defined[key]:
+			warnings = append(warnings, newWarning("directives", position, "glossary term %s is defined more than once", term.Term))
+		default:
+			defined[key] = true
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,210 @@
+package docdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// listedPackage is the part of a `go list -json` object that godocdown uses
+type listedPackage struct {
+	Dir          string
+	ImportPath   string
+	Name         string
+	Standard     bool
+	GoFiles      []string
+	CgoFiles     []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	Error        *struct {
+		Err string
+	}
+}
+
+// listed has the packages read with -go-list, by directory, which are
+// documented as go list described them rather than as found on disk
+var listed = map[string]*listedPackage{}
+
+// readListed reads the packages to document from a stream of `go list
+// -json` objects (with -go-list) and returns their directories
+func readListed(reader io.Reader, filter DirFilter) ([]string, error) {
+	list, err := decodePackages(reader)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for i := range list {
+		pkg := &list[i]
+		if pkg.Error != nil {
+			return nil, fmt.Errorf("Could not list %s: %s", pkg.ImportPath, pkg.Error.Err)
+		}
+		if pkg.Dir == "" || filter.skipImport(pkg.ImportPath) {
+			continue
+		}
+		listed[pkg.Dir] = pkg
+		targets = append(targets, pkg.Dir)
+	}
+	return targets, nil
+}
+
+// lookupImport returns the import path and absolute directory of target,
+// from -go-list if it was listed there
+func lookupImport(target string) (string, string, error) {
+	if pkg, ok := listed[target]; ok {
+		return pkg.ImportPath, pkg.Dir, nil
+	}
+	return findImport(target)
+}
+
+// findImport returns the import path and absolute directory of target, as
+// found on disk
+func findImport(target string) (string, string, error) {
+	if isSourceFile(target) {
+		return sourceFileImport(target)
+	}
+	return buildImport(target)
+}
+
+// listedFS is the directory of a listed package, in which only the Go files
+// that go list named can be seen
+type listedFS struct {
+	fs.FS
+	files map[string]bool
+}
+
+func newListedFS(pkg *listedPackage) listedFS {
+	files := map[string]bool{}
+	for _, list := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.TestGoFiles, pkg.XTestGoFiles} {
+		for _, name := range list {
+			files[name] = true
+		}
+	}
+	return listedFS{os.DirFS(pkg.Dir), files}
+}
+
+func (self listedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(self.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	var kept []fs.DirEntry
+	for _, entry := range entries {
+		if name == "." && !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") && !self.files[entry.Name()] {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept, nil
+}
+
+// isPattern reports whether target is a package pattern for go list (like
+// std, ./... or example.com/mod/...) rather than a directory
+func isPattern(target string) bool {
+	switch {
+	case strings.Contains(target, "..."):
+		return true
+	case target == "std", target == "cmd", target == "all":
+		return true
+	case filepath.IsAbs(target), strings.HasPrefix(target, "."):
+		return false
+	}
+	// An import path, unless there is a directory by that name
+	_, err := os.Stat(target)
+	return err != nil
+}
+
+// goList runs `go list -json` on patterns and returns the packages they
+// match
+func goList(patterns []string) ([]listedPackage, error) {
+	command := exec.Command("go", append([]string{"list", "-e", "-json"}, patterns...)...)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	// Warnings, like a pattern that matched no packages
+	os.Stderr.Write(stderr.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Could not list packages: %s", strings.TrimSpace(stderr.String()))
+	}
+	return decodePackages(bytes.NewReader(output))
+}
+
+// decodePackages reads a stream of `go list -json` objects
+func decodePackages(reader io.Reader) ([]listedPackage, error) {
+	var list []listedPackage
+	decoder := json.NewDecoder(reader)
+	for {
+		var pkg listedPackage
+		err := decoder.Decode(&pkg)
+		if err == io.EOF {
+			return list, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid go list output: %v", err)
+		}
+		list = append(list, pkg)
+	}
+}
+
+// expandTargets replaces the package patterns among targets with the
+// directories of the packages they match. Packages that filter would leave
+// out of a walk (like internal and vendored packages) are left out. A
+// standard library package can be given by its import path, like net/http,
+// and a module zip or tarball is replaced by the root of the module in it.
+func expandTargets(targets []string, filter DirFilter) ([]string, error) {
+	var expanded, patterns []string
+	flush := func() error {
+		if len(patterns) == 0 {
+			return nil
+		}
+		list, err := goList(patterns)
+		if err != nil {
+			return err
+		}
+		patterns = nil
+		for _, pkg := range list {
+			if pkg.Error != nil {
+				return fmt.Errorf("Could not list %s: %s", pkg.ImportPath, pkg.Error.Err)
+			}
+			if pkg.Dir == "" || filter.skipImport(pkg.ImportPath) {
+				continue
+			}
+			if pkg.Standard {
+				// The standard library has files for every platform, so
+				// only document those go list chose
+				pkg := pkg
+				listed[pkg.Dir] = &pkg
+			}
+			expanded = append(expanded, pkg.Dir)
+		}
+		return nil
+	}
+	for _, target := range targets {
+		if isArchive(target) {
+			root, err := extractArchive(target)
+			if err != nil {
+				return nil, err
+			}
+			target = root
+		} else if !isSourceFile(target) && isPattern(target) {
+			patterns = append(patterns, target)
+			continue
+		}
+		// Keep the order of the targets
+		err := flush()
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, target)
+	}
+	err := flush()
+	if err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
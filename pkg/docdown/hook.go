@@ -0,0 +1,134 @@
+package docdown
+
+import (
+	"bytes"
+	Flag "flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookFlags are the flags of "godocdown hook": those of the documentation,
+// those of the jobs and the cache it regenerates it with, and -fix
+func hookFlags() (*Flag.FlagSet, *bool) {
+	hookFlag := subcommandFlags("hook", "jobs", "timeout", "cache", "cache-dir")
+	fix := hookFlag.Bool("fix", false, "Write and stage stale documentation instead of failing")
+	return hookFlag, fix
+}
+
+// runHook implements "godocdown hook", for use as a git pre-commit hook. It
+// regenerates the documentation of every package with staged Go files and
+// reports (or with -fix, updates and stages) any that are stale.
+func runHook(arguments []string) int {
+	hookFlag, fix := hookFlags()
+	hookFlag.Parse(arguments)
+
+	err := loadConfig(hookFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+	err = setupLogger(os.Stderr)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	if flag_output == "" || flag_output == "-" || filepath.IsAbs(flag_output) {
+		flag_output = "README.md"
+	}
+
+	root, err := git(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	staged, err := git(".", "diff", "--cached", "--name-only", "-z", "--diff-filter=ACMRD")
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	// Only packages that already have generated documentation are checked
+	seen := map[string]bool{}
+	var jobs []*job
+	for _, name := range strings.Split(string(staged), "\x00") {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		dir := filepath.Join(strings.TrimSpace(string(root)), filepath.FromSlash(filepath.Dir(name)))
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if _, err := os.Stat(filepath.Join(dir, flag_output)); err != nil {
+			continue
+		}
+		jobs = append(jobs, &job{target: dir, stdout: &bytes.Buffer{}})
+	}
+
+	cache, err := openCache()
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	style, err := buildStyle()
+	if err == nil {
+		err = buildOutputs()
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	ctx, stop := runContext()
+	defer stop()
+	runJobs(ctx, jobs, style, cache, *flag_jobs)
+
+	status := 0
+	for _, job := range jobs {
+		if job.err != nil {
+			logger.Error(job.err.Error(), "target", job.target)
+			status = 1
+			continue
+		}
+		path := filepath.Join(job.absPath, flag_output)
+		current, err := ioutil.ReadFile(path)
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+			continue
+		}
+		generated := job.stdout.(*bytes.Buffer).Bytes()
+		if spliced, ok := spliceDocumentation(current, generated); ok {
+			generated = spliced
+		}
+		if bytes.Equal(current, generated) {
+			continue
+		}
+
+		relative, _ := filepath.Rel(strings.TrimSpace(string(root)), path)
+		relative = filepath.ToSlash(relative)
+		if !*fix {
+			unifiedDiff(os.Stdout, "a/"+relative, "b/"+relative, string(current), string(generated))
+			logger.Error("documentation is stale; run godocdown hook -fix to update it", "path", relative)
+			status = 1
+			continue
+		}
+		err = writeOutputTo(path, os.Stdout, func(writer io.Writer) error {
+			_, err := writer.Write(generated)
+			return err
+		})
+		if err == nil {
+			_, err = git(job.absPath, "add", "--", flag_output)
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+			continue
+		}
+		logger.Info("updated documentation", "path", relative)
+	}
+	return status
+}
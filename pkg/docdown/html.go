@@ -0,0 +1,107 @@
+package docdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTag_Regexp       = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[^<>]*?)?)\s*(/?)>`)
+	htmlAttribute_Regexp = regexp.MustCompile(`([a-zA-Z-]+)\s*=\s*("[^"]*"|'[^']*')`)
+	htmlEscaper          = strings.NewReplacer("<", "&lt;", ">", "&gt;")
+)
+
+// allowedHTML are the tags (and their attributes) kept by -html=sanitize
+var allowedHTML = map[string][]string{
+	"a":       {"href", "title"},
+	"b":       nil,
+	"br":      nil,
+	"code":    nil,
+	"details": nil,
+	"em":      nil,
+	"i":       nil,
+	"img":     {"src", "alt", "title", "width", "height"},
+	"kbd":     nil,
+	"p":       nil,
+	"pre":     nil,
+	"strong":  nil,
+	"sub":     nil,
+	"summary": nil,
+	"sup":     nil,
+}
+
+// applyHTMLPolicy handles the raw HTML in doc text as the style says: "pass"
+// it through (the default), "escape" it all, or "sanitize" it, keeping the
+// tags of allowedHTML and escaping the rest. Code blocks and code spans are
+// left alone, since Markdown shows them as written.
+func applyHTMLPolicy(text string, style Style) string {
+	var clean func(string) string
+	switch style.HTML {
+	case "escape":
+		clean = htmlEscaper.Replace
+	case "sanitize":
+		clean = sanitizeHTML
+	default:
+		return text
+	}
+	return outsideCode(text, clean)
+}
+
+// outsideCode applies clean to the parts of doc text outside of code blocks
+// and code spans
+func outsideCode(text string, clean func(string) string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		// The even parts are outside of code spans
+		parts := strings.Split(line, "`")
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = clean(parts[j])
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+	return strings.Join(lines, "")
+}
+
+// sanitizeHTML keeps the allowed tags (minus their other attributes) and
+// escapes everything else that looks like HTML
+func sanitizeHTML(text string) string {
+	var result strings.Builder
+	last := 0
+	for _, match := range htmlTag_Regexp.FindAllStringSubmatchIndex(text, -1) {
+		result.WriteString(htmlEscaper.Replace(text[last:match[0]]))
+		last = match[1]
+
+		name := strings.ToLower(text[match[4]:match[5]])
+		attributes, ok := allowedHTML[name]
+		if !ok {
+			result.WriteString(htmlEscaper.Replace(text[match[0]:match[1]]))
+			continue
+		}
+		result.WriteString("<" + text[match[2]:match[3]] + name)
+		for _, attribute := range htmlAttribute_Regexp.FindAllStringSubmatch(text[match[6]:match[7]], -1) {
+			key, value := strings.ToLower(attribute[1]), attribute[2]
+			if !contains(attributes, key) {
+				continue
+			}
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(strings.Trim(value, `"'`))), "javascript:") {
+				continue
+			}
+			result.WriteString(" " + key + "=" + value)
+		}
+		result.WriteString(text[match[8]:match[9]] + ">")
+	}
+	result.WriteString(htmlEscaper.Replace(text[last:]))
+	return result.String()
+}
+
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package docdown
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadTranslations reads a YAML file that maps the fixed English strings of
+// the output to their translations:
+//
+//	Index: 索引
+//	Example: 示例
+//	Output: 输出
+func loadTranslations(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read translations: %v", err)
+	}
+	translations := map[string]string{}
+	err = yaml.Unmarshal(contents, &translations)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse translations \"%s\": %v", path, err)
+	}
+	return translations, nil
+}
+
+// text translates one of the fixed strings of the output, or leaves it in
+// English if there is no translation
+func (self Style) text(english string) string {
+	if translation, ok := self.Strings[english]; ok && translation != "" {
+		return translation
+	}
+	return english
+}
+
+// heading translates the text of a heading like "#### Index\n"
+func (self Style) heading(heading string) string {
+	level, label, found := strings.Cut(heading, " ")
+	if !found || strings.Trim(level, "#") != "" {
+		return heading
+	}
+	text := strings.TrimRight(label, "\n")
+	return level + " " + self.text(text) + label[len(text):]
+}
@@ -0,0 +1,80 @@
+package docdown
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// findModule finds the go.mod of the module dir belongs to, by searching
+// dir and its parents, and returns the module path and directory
+func findModule(dir string) (string, string, error) {
+	start := canonicalPath(dir)
+	dir = start
+	for {
+		contents, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			path := modfile.ModulePath(contents)
+			if path == "" {
+				return "", "", fmt.Errorf("No module path in %s", filepath.Join(dir, "go.mod"))
+			}
+			return path, dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", fmt.Errorf("%w for %s", ErrNoGoMod, start)
+		}
+		dir = parent
+	}
+}
+
+// isStandard reports whether importPath is in the standard library, which
+// (unlike other packages) has no dot in its first path element
+func isStandard(importPath string) bool {
+	first := strings.SplitN(importPath, "/", 2)[0]
+	return !strings.Contains(first, ".")
+}
+
+// externalImports lists the packages the document imports from outside of
+// the standard library and its own module
+func (self *Document) externalImports() []string {
+	module, _, err := findModule(self.absPath)
+	if err != nil {
+		self.style.log().Debug("not leaving out the imports of the module", "package", self.absPath, "error", err)
+	}
+	var list []string
+	for _, importPath := range self.imports {
+		if isStandard(importPath) || importPath == "C" {
+			continue
+		}
+		if module != "" && (importPath == module || strings.HasPrefix(importPath, module+"/")) {
+			continue
+		}
+		list = append(list, importPath)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func renderImportsTo(writer io.Writer, document *Document) {
+	if !document.style.IncludeImports {
+		return
+	}
+	list := document.externalImports()
+	if len(list) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "%s\n", document.style.heading(document.style.ImportsHeader))
+	for _, importPath := range list {
+		fmt.Fprintf(writer, " - [%s](https://pkg.go.dev/%s)\n", importPath, importPath)
+	}
+	fmt.Fprintf(writer, "\n")
+}
@@ -0,0 +1,98 @@
+package docdown
+
+import (
+	"go/ast"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// includeMarker starts the lines of a doc comment that splice a Markdown
+// file into the documentation at that point, like
+//
+//	godocdown:include docs/usage.md
+//
+// The file is relative to the package directory. The directive form,
+// "//godocdown:include docs/usage.md", is turned into the marker by
+// markIncludes.
+const includeMarker = "godocdown:include "
+
+// include_Regexp finds the files included by the doc comments of a source
+// file, for the cache key
+var include_Regexp = regexp.MustCompile(`(?m)^\s*//\s*godocdown:include\s+(\S+)`)
+
+// markIncludes turns the "//godocdown:include" directives of files into
+// include markers, so the package's documentation keeps them where they
+// are. CommentGroup.Text drops directives, so this has to happen before
+// go/doc reads the comments.
+func markIncludes(files map[string]*ast.File) {
+	for _, file := range files {
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				if strings.HasPrefix(comment.Text, directivePrefix+"include ") {
+					comment.Text = "// " + strings.TrimPrefix(comment.Text, "//")
+				}
+			}
+		}
+	}
+}
+
+// includeFiles replaces the include markers of doc text with the files they
+// name. The links of the images in an included file are made relative to the
+// package directory, like those of the doc comments, so rewriteAssets takes
+// care of them.
+func (self *Document) includeFiles(text string) string {
+	if !strings.Contains(text, includeMarker) {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		name := strings.TrimSpace(line)
+		if !strings.HasPrefix(name, includeMarker) || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		name = strings.TrimSpace(strings.TrimPrefix(name, includeMarker))
+		contents, err := os.ReadFile(filepath.Join(self.absPath, filepath.FromSlash(name)))
+		if err != nil {
+			self.style.log().Warn("Could not include file", "package", self.absPath, "error", err)
+			lines[i] = ""
+			continue
+		}
+		included := image_Regexp.ReplaceAllStringFunc(string(contents), func(match string) string {
+			parts := image_Regexp.FindStringSubmatch(match)
+			before, link, after := parts[1], parts[2], parts[3]
+			if before == "" {
+				before, link, after = parts[4], parts[5], parts[6]
+			}
+			if !isLocalLink(link) {
+				return match
+			}
+			return before + path.Join(path.Dir(name), link) + after
+		})
+		// Keep the file a block of its own, even inside a paragraph
+		lines[i] = strings.TrimSpace(included) + "\n"
+		if i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+			lines[i] = "\n" + lines[i]
+		}
+		if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			lines[i] += "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// includedPaths are the files included by the doc comments of the source
+// file at path, in the package directory absPath
+func includedPaths(absPath, path string) []string {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, match := range include_Regexp.FindAllSubmatch(contents, -1) {
+		paths = append(paths, filepath.Join(absPath, filepath.FromSlash(string(match[1]))))
+	}
+	return paths
+}
@@ -0,0 +1,80 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// writeIndex writes the -index page, a table of every package documented
+// in this run that links to each package's page
+func writeIndex(ctx context.Context, jobs []*job, style Style) error {
+	if indexPath() == "" {
+		return nil
+	}
+	index, err := filepath.Abs(indexPath())
+	if err != nil {
+		return err
+	}
+
+	var documented []*job
+	for _, job := range jobs {
+		if job.err == nil && job.path != "" {
+			documented = append(documented, job)
+		}
+	}
+	sort.SliceStable(documented, func(i, j int) bool {
+		return documented[i].info.ImportPath < documented[j].info.ImportPath
+	})
+
+	err = os.MkdirAll(filepath.Dir(index), 0755)
+	if err != nil {
+		return err
+	}
+	return writeDocumentTo(ctx, style, index, os.Stdout, func(writer io.Writer) error {
+		headings := newHeadingWriter(writer, style)
+		err := renderIndexPageTo(headings, filepath.Dir(index), documented, style)
+		if err != nil {
+			return err
+		}
+		return headings.Close()
+	})
+}
+
+// renderIndexPageTo writes the index of jobs, with links relative to dir
+func renderIndexPageTo(writer io.Writer, dir string, jobs []*job, style Style) error {
+	fmt.Fprintf(writer, "# %s\n\n", style.text("Packages"))
+	if style.tables() {
+		fmt.Fprintf(writer, "| %s | %s | %s |\n", style.text("Package"), style.text("Import path"), style.text("Synopsis"))
+		fmt.Fprintf(writer, "| --- | --- | --- |\n")
+	}
+	for _, job := range jobs {
+		link, err := filepath.Rel(dir, job.path)
+		if err != nil {
+			return err
+		}
+		importPath := job.info.ImportPath
+		if importPath != "" {
+			importPath = "`" + importPath + "`"
+		}
+		if !style.tables() {
+			fmt.Fprintf(writer, " - [%s](%s) %s: %s\n",
+				job.info.Name, filepath.ToSlash(link), importPath, job.info.Synopsis)
+			continue
+		}
+		fmt.Fprintf(writer, "| [%s](%s) | %s | %s |\n",
+			job.info.Name, filepath.ToSlash(link), importPath, escapeTableCell(job.info.Synopsis))
+	}
+	return nil
+}
+
+var tableCellEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+// escapeTableCell keeps text from breaking out of a Markdown table cell
+func escapeTableCell(text string) string {
+	return tableCellEscaper.Replace(text)
+}
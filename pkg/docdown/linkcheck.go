@@ -0,0 +1,254 @@
+package docdown
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// brokenLink is a link in the output that does not lead anywhere
+type brokenLink struct {
+	File   string
+	Line   int
+	Symbol string // The heading the link is under
+	Link   string
+	Reason string
+}
+
+var (
+	// outputLink_Regexp matches the destinations of Markdown links (in
+	// angle brackets, as gomarkdoc writes them, or not) and of HTML links
+	outputLink_Regexp = regexp.MustCompile(`\]\((?:<([^>]*)>|([^)\s]+))|href=["']([^"']+)["']`)
+
+	// outputAnchor_Regexp matches explicit anchors: heading attributes and
+	// HTML anchors
+	outputAnchor_Regexp = regexp.MustCompile(`\{#([^}\s]+)\}\s*$|<a (?:name|id)=["']([^"']+)["']`)
+
+	// outputHeading_Regexp matches an ATX heading, without its attributes
+	outputHeading_Regexp = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*(?:\{#[^}]*\})?\s*$`)
+
+	// inlineCode_Regexp matches code spans, whose text is not links
+	inlineCode_Regexp = regexp.MustCompile("`[^`]*`")
+)
+
+// listStart_Regexp matches the start of a list item, whose indented lines are
+// not code
+var listStart_Regexp = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+
+// codeLines reports which of the lines of a page are code: in fenced code
+// blocks (with the fences), or in indented ones
+func codeLines(lines []string) []bool {
+	code := make([]bool, len(lines))
+	fenced, indented, list := false, false, false
+	for i, line := range lines {
+		blank := strings.TrimSpace(line) == ""
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "```"):
+			fenced = !fenced
+			code[i] = true
+			continue
+		case fenced:
+			code[i] = true
+			continue
+		}
+		isIndented := strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ")
+		if isIndented && !list && (indented || i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			indented = true
+			code[i] = true
+			continue
+		}
+		if !blank {
+			indented = false
+			list = listStart_Regexp.MatchString(line) || (list && isIndented)
+		}
+		code[i] = indented && blank
+	}
+	return code
+}
+
+// outputPages are the pages written by the jobs: their documentation, the
+// pages of split types, and the index
+func outputPages(jobs []*job) []string {
+	seen := map[string]bool{}
+	var pages []string
+	add := func(path string) {
+		if absolute, err := filepath.Abs(path); err == nil && !seen[absolute] {
+			seen[absolute] = true
+			pages = append(pages, absolute)
+		}
+	}
+	for _, job := range jobs {
+		if job.err != nil || job.path == "" || job.path == "-" {
+			continue
+		}
+		add(job.path)
+		if job.document != nil {
+			for _, page := range job.document.split {
+				add(filepath.Join(filepath.Dir(job.path), page))
+			}
+			_, sections := job.document.packageSections()
+			for _, section := range sections {
+				if section.Page != "" {
+					add(filepath.Join(filepath.Dir(job.path), section.Page))
+				}
+			}
+		}
+	}
+	if len(jobs) > 1 || *flag_outDir != "" {
+		if index := indexPath(); index != "" {
+			if _, err := os.Stat(index); err == nil {
+				add(index)
+			}
+		}
+	}
+	sort.Strings(pages)
+	return pages
+}
+
+// pageAnchors are the anchors of a page: its explicit anchors, and the
+// identifiers of its headings
+func pageAnchors(text string) map[string]bool {
+	anchors := map[string]bool{}
+	lines := strings.Split(text, "\n")
+	code := codeLines(lines)
+	for i, line := range lines {
+		if code[i] {
+			continue
+		}
+		for _, match := range outputAnchor_Regexp.FindAllStringSubmatch(line, -1) {
+			anchors[match[1]+match[2]] = true
+		}
+		if match := outputHeading_Regexp.FindStringSubmatch(line); match != nil {
+			anchors[headingID(match[1])] = true
+		}
+	}
+	return anchors
+}
+
+// checkOutputLinks checks that the links of the pages lead to anchors that
+// exist, in the same page or another page of the output, and that the links
+// to other files lead to files that exist
+func checkOutputLinks(pages []string) ([]brokenLink, error) {
+	texts := map[string]string{}
+	anchors := map[string]map[string]bool{}
+	for _, page := range pages {
+		read, err := os.ReadFile(page)
+		if err != nil {
+			return nil, err
+		}
+		texts[page] = string(read)
+		anchors[page] = pageAnchors(texts[page])
+	}
+	// Pages that are not part of the output are read when they are linked to
+	anchorsOf := func(path string) (map[string]bool, bool) {
+		if found, ok := anchors[path]; ok {
+			return found, true
+		}
+		read, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false
+		}
+		anchors[path] = pageAnchors(string(read))
+		return anchors[path], true
+	}
+
+	var broken []brokenLink
+	for _, page := range pages {
+		symbol := ""
+		lines := strings.Split(texts[page], "\n")
+		code := codeLines(lines)
+		for number, line := range lines {
+			if code[number] {
+				continue
+			}
+			if match := outputHeading_Regexp.FindStringSubmatch(line); match != nil {
+				symbol = match[1]
+			}
+			line = inlineCode_Regexp.ReplaceAllString(line, "")
+			for _, match := range outputLink_Regexp.FindAllStringSubmatch(line, -1) {
+				link := match[1] + match[2] + match[3]
+				if strings.Contains(link, ":") || strings.HasPrefix(link, "/") || link == "#" {
+					// A URL, or a path that depends on where the pages are served
+					continue
+				}
+				report := func(reason string) {
+					broken = append(broken, brokenLink{page, number + 1, symbol, link, reason})
+				}
+				target, anchor, _ := strings.Cut(link, "#")
+				path := page
+				if target != "" {
+					unescaped, err := url.PathUnescape(target)
+					if err != nil {
+						report("invalid path")
+						continue
+					}
+					path = filepath.Join(filepath.Dir(page), filepath.FromSlash(unescaped))
+					if _, err := os.Stat(path); err != nil {
+						report("no such file")
+						continue
+					}
+				}
+				if anchor == "" {
+					continue
+				}
+				if ext := strings.ToLower(filepath.Ext(path)); ext != ".md" && ext != ".markdown" {
+					continue
+				}
+				found, ok := anchorsOf(path)
+				if ok && !found[anchor] {
+					report("no such anchor")
+				}
+			}
+		}
+	}
+	return broken, nil
+}
+
+// renderBrokenLinksTo writes the broken links like the compiler's errors, or
+// as GitHub annotations with -warnings=github
+func renderBrokenLinksTo(writer io.Writer, broken []brokenLink) {
+	cwd, _ := os.Getwd()
+	for _, link := range broken {
+		file := link.File
+		if relative, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(relative, "..") {
+			file = relative
+		}
+		message := fmt.Sprintf("broken link to %s (%s)", link.Link, link.Reason)
+		if link.Symbol != "" {
+			message += " in " + link.Symbol
+		}
+		if *flag_warnings == "github" {
+			fmt.Fprintf(writer, "::warning file=%s,line=%d::%s\n",
+				githubEscape(filepath.ToSlash(file), true),
+				link.Line,
+				githubEscape(message, false))
+			continue
+		}
+		fmt.Fprintf(writer, "%s:%d: %s\n", file, link.Line, message)
+	}
+}
+
+// checkPageLinks checks the links of the pages written by the jobs, with
+// -check-links, and returns the exit status for them: 1 for broken links
+// with -check-links=strict
+func checkPageLinks(jobs []*job) int {
+	if *flag_checkLinks == "off" {
+		return 0
+	}
+	broken, err := checkOutputLinks(outputPages(jobs))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Could not check links: %v", err))
+		return 1
+	}
+	renderBrokenLinksTo(os.Stderr, broken)
+	if len(broken) > 0 && *flag_checkLinks == "strict" {
+		logger.Error(fmt.Sprintf("Found %d broken links", len(broken)))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// packageLinks maps the import path of each package documented in this
+// run to the file its documentation is written to, so that packages can
+// link to each other
+type packageLinks map[string]string
+
+// importsOf maps the name each file imports a package as to its import path
+func importsOf(files map[string]*ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			name := path[strings.LastIndex(path, "/")+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name == "_" || name == "." {
+				continue
+			}
+			imports[name] = path
+		}
+	}
+	return imports
+}
+
+// linkTo is the relative link to symbol (or to the package itself, if
+// symbol is "") in the documentation of the package importPath, or "" if
+// that package is not documented in this run
+func (self *Document) linkTo(importPath, symbol string) string {
+	page, ok := self.links[importPath]
+	if !ok || self.page == "" || importPath == self.ImportPath {
+		return ""
+	}
+	link, err := filepath.Rel(filepath.Dir(self.page), page)
+	if err != nil {
+		return ""
+	}
+	link = filepath.ToSlash(link)
+	if anchor := self.anchorOf(symbol); symbol != "" && anchor != "" {
+		link += "#" + anchor
+	}
+	return link
+}
+
+// anchorOf is the anchor the heading of symbol ("Name" or "Type.Method")
+// has, or "" if that depends on more than its name, as in the commonmark
+// flavor, where a heading's anchor comes from its text
+func (self *Document) anchorOf(symbol string) string {
+	if self.style.Flavor == "commonmark" {
+		return ""
+	}
+	if self.style.Flavor != "gomarkdoc" {
+		return symbol[strings.LastIndex(symbol, ".")+1:]
+	}
+	return symbol
+}
+
+// resolve is the import path a doc link qualifier refers to: either an
+// import path or the name of an imported package
+func (self *Document) resolve(qualifier string) string {
+	if strings.ContainsAny(qualifier, "./") {
+		return qualifier
+	}
+	return self.imports[qualifier]
+}
+
+// docLink_Regexp matches doc links to other packages: [pkg.Name],
+// [pkg.Type.Method], or [import/path.Name]
+var docLink_Regexp = regexp.MustCompile(`\[([A-Za-z0-9_./-]+?)\.([A-Z][A-Za-z0-9_]*(?:\.[A-Z][A-Za-z0-9_]*)?)\]`)
+
+// linkDocRefs turns doc links to packages documented in this run into
+// Markdown links to their documentation. Code blocks are left alone.
+func (self *Document) linkDocRefs(text string) string {
+	if len(self.links) == 0 {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		var result strings.Builder
+		last := 0
+		for _, match := range docLink_Regexp.FindAllStringSubmatchIndex(line, -1) {
+			if strings.HasPrefix(line[match[1]:], "(") {
+				// Already a Markdown link
+				continue
+			}
+			link := self.linkTo(self.resolve(line[match[2]:match[3]]), line[match[4]:match[5]])
+			if link == "" {
+				continue
+			}
+			result.WriteString(line[last:match[1]])
+			fmt.Fprintf(&result, "(%s)", link)
+			last = match[1]
+		}
+		result.WriteString(line[last:])
+		lines[i] = result.String()
+	}
+	return strings.Join(lines, "")
+}
+
+// commentText is doc as it should be rendered in Markdown, before the files
+// it includes are spliced in
+func (self *Document) commentText(doc string) string {
+	return self.linkTerms(self.linkDocRefs(applyHTMLPolicy(applyEmojiPolicy(asciiTables(stripStability(applyFilters(filterText(doc), self.style)), self.style), self.style), self.style)))
+}
+
+// docText is doc as it should be rendered in Markdown
+func (self *Document) docText(doc string) string {
+	return self.rewriteAssets(self.includeFiles(self.commentText(doc)))
+}
+
+// packageDoc is the package's doc comment as it should be rendered in
+// Markdown, with its headings detected (but not those of the files it
+// includes, which are Markdown already)
+func (self *Document) packageDoc() string {
+	text, _ := self.packageSections()
+	return self.rewriteAssets(self.includeFiles(headifySynopsis(self.commentText(text), self.style)))
+}
+
+// referencesOf lists links to the symbols of packages documented in this
+// run that node (a declaration) refers to, followed by a blank line, or is
+// "" if there are none
+func (self *Document) referencesOf(node ast.Node) string {
+	if len(self.links) == 0 || node == nil {
+		return ""
+	}
+	seen := map[string]bool{}
+	var references []string
+	ast.Inspect(node, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		name, ok := selector.X.(*ast.Ident)
+		if !ok || name.Obj != nil {
+			return true
+		}
+		symbol := name.Name + "." + selector.Sel.Name
+		if seen[symbol] {
+			return true
+		}
+		seen[symbol] = true
+		if link := self.linkTo(self.imports[name.Name], selector.Sel.Name); link != "" {
+			references = append(references, fmt.Sprintf("[%s](%s)", symbol, link))
+		}
+		return true
+	})
+	if len(references) == 0 {
+		return ""
+	}
+	sort.Strings(references)
+	return fmt.Sprintf("%s %s\n\n", self.style.text("Refers to"), strings.Join(references, ", "))
+}
@@ -0,0 +1,208 @@
+package docdown
+
+import (
+	Flag "flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// lintRules are the checks of "godocdown lint", by name
+var lintRules = []string{
+	"package-comment", // The package has a package comment
+	"documented",      // Exported symbols have doc comments
+	"name-prefix",     // Doc comments start with the name of their symbol
+	"examples",        // Examples are named for symbols that exist
+	"links",           // Links to anchors in doc comments are not broken
+	"directives",      // godocdown directives are known and well-formed
+	"references",      // Doc links and "See also" name symbols that exist
+}
+
+// lint checks the conventions of the package's doc comments, returning the
+// violations of the rules that are not disabled
+func (self *Document) lint(disabled map[string]bool) []warning {
+	_, warnings := self.check()
+	warnings = append(warnings, self.checkNamePrefix()...)
+	var result []warning
+	for _, warning := range warnings {
+		if !disabled[warning.Rule] {
+			result = append(result, warning)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].File != result[j].File {
+			return result[i].File < result[j].File
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
+// startsWithName reports whether the doc comment text starts with name, as
+// in "Name does...", optionally after an article ("A Name is...")
+func startsWithName(text, name string) bool {
+	for _, article := range []string{"", "A ", "An ", "The "} {
+		rest, ok := strings.CutPrefix(text, article+name)
+		if ok && (rest == "" || strings.IndexAny(rest[:1], " \t\n.,:;'") == 0) {
+			return true
+		}
+	}
+	// A comment for a deprecated symbol can say only that
+	return strings.HasPrefix(text, "Deprecated:")
+}
+
+// checkNamePrefix warns about doc comments that do not start with the name
+// of their symbol, and a package comment that does not start with "Package"
+// and the name of the package (for commands, the name of the command is
+// conventional but not required)
+func (self *Document) checkNamePrefix() []warning {
+	var warnings []warning
+	check := func(kind, name string, node ast.Node, text string) {
+		if text != "" && !startsWithName(text, name) {
+			position := self.fset.Position(node.Pos())
+			warnings = append(warnings, newWarning("name-prefix", position, "comment on exported %s %s should be of the form \"%s ...\"", kind, name, name))
+		}
+	}
+	values := func(kind string, list []*doc.Value) {
+		for _, value := range list {
+			// The comment of a group is about the group
+			if len(value.Names) == 1 {
+				check(kind, value.Names[0], value.Decl, value.Doc)
+			}
+		}
+	}
+	funcs := func(list []*doc.Func) {
+		for _, entry := range list {
+			kind := "function"
+			if entry.Recv != "" {
+				kind = "method"
+			}
+			check(kind, entry.Name, entry.Decl, entry.Doc)
+		}
+	}
+
+	if self.IsCommand {
+		return warnings
+	}
+	if self.pkg.Doc != "" && !strings.HasPrefix(self.pkg.Doc, "Package "+self.Name) {
+		warnings = append(warnings, newWarning("name-prefix", self.docPosition(nil), "package comment should be of the form \"Package %s ...\"", self.Name))
+	}
+	values("constant", self.pkg.Consts)
+	values("variable", self.pkg.Vars)
+	funcs(self.pkg.Funcs)
+	for _, entry := range self.pkg.Types {
+		check("type", entry.Name, entry.Decl, entry.Doc)
+		values("constant", entry.Consts)
+		values("variable", entry.Vars)
+		funcs(entry.Funcs)
+		funcs(entry.Methods)
+	}
+	return warnings
+}
+
+// lintDisabled reads -lint-disable, the rules not to check
+func lintDisabled() (map[string]bool, error) {
+	disabled := map[string]bool{}
+	for _, rule := range flag_lintDisable {
+		known := false
+		for _, name := range lintRules {
+			known = known || name == rule
+		}
+		if !known {
+			return nil, fmt.Errorf("Invalid -lint-disable \"%s\": expected one of %s", rule, strings.Join(lintRules, ", "))
+		}
+		disabled[rule] = true
+	}
+	return disabled, nil
+}
+
+// lintFlags are the flags of "godocdown lint": those of the documentation,
+// -lint-disable, and -warnings for how the violations are reported
+func lintFlags() *Flag.FlagSet {
+	return subcommandFlags("lint", "timeout", "lint-disable", "warnings")
+}
+
+// runLint implements "godocdown lint", which checks the doc comments of the
+// given packages (the one in the current directory by default) and reports
+// each violation with its position. It exits with 1 if there are any.
+func runLint(arguments []string) int {
+	lintFlag := lintFlags()
+	lintFlag.Parse(arguments)
+
+	err := loadConfig(lintFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+	err = setupLogger(os.Stderr)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	disabled, err := lintDisabled()
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	style, err := buildStyle()
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	defer removeTempDirs()
+
+	arguments = lintFlag.Args()
+	if len(arguments) == 0 {
+		arguments = []string{"."}
+	}
+	targets, err := expandTargets(arguments, style.Dirs)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	status := 0
+	for _, target := range targets {
+		document, err := loadDocument(ctx, target, style)
+		if err != nil {
+			logger.Error(err.Error(), "target", target)
+			status = 2
+			continue
+		}
+		warnings := document.lint(disabled)
+		if len(warnings) > 0 && status == 0 {
+			status = 1
+		}
+		renderLintTo(os.Stdout, warnings)
+	}
+	return status
+}
+
+// renderLintTo writes the violations found by lint, one on each line like
+// the compiler's errors, or as GitHub annotations with -warnings=github
+func renderLintTo(writer io.Writer, warnings []warning) {
+	cwd, _ := os.Getwd()
+	for _, warning := range warnings {
+		file := warning.File
+		if relative, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(relative, "..") {
+			file = relative
+		}
+		if *flag_warnings == "github" {
+			fmt.Fprintf(writer, "::warning file=%s,line=%d,col=%d,title=%s::%s\n",
+				githubEscape(filepath.ToSlash(file), true),
+				warning.Line,
+				warning.Column,
+				githubEscape(warning.Rule, true),
+				githubEscape(warning.Message, false))
+			continue
+		}
+		fmt.Fprintf(writer, "%s:%d:%d: %s (%s)\n", file, warning.Line, warning.Column, warning.Message, warning.Rule)
+	}
+}
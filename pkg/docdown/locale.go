@@ -0,0 +1,104 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	Time "time"
+)
+
+// localePlaceholder is replaced by the locale in the -translations and
+// -template paths
+const localePlaceholder = "{locale}"
+
+// localize replaces the locale placeholder in path
+func localize(path, locale string) string {
+	return strings.ReplaceAll(path, localePlaceholder, locale)
+}
+
+// localePath returns where the documentation for a locale goes, next to the
+// default output: README.md becomes README.zh.md
+func localePath(path, locale string) string {
+	extension := filepath.Ext(path)
+	return strings.TrimSuffix(path, extension) + "." + locale + extension
+}
+
+// localeStyles returns a copy of style for each of the -locales, with the
+// translations for that locale
+func localeStyles(style Style) ([]Style, error) {
+	if *flag_locales == "" {
+		return nil, nil
+	}
+	if (flag_output == "" || flag_output == "-") && *flag_outDir == "" {
+		return nil, fmt.Errorf("Cannot use -locales without -output or -out-dir")
+	}
+	var styles []Style
+	for _, locale := range strings.Split(*flag_locales, ",") {
+		locale = strings.TrimSpace(locale)
+		if locale == "" {
+			continue
+		}
+		if strings.ContainsAny(locale, `/\.`) {
+			return nil, fmt.Errorf("Invalid locale: %s", locale)
+		}
+		localized := style
+		localized.Locale = locale
+		if *flag_translations != "" {
+			if !strings.Contains(*flag_translations, localePlaceholder) {
+				return nil, fmt.Errorf("Cannot use -locales unless -translations contains %s", localePlaceholder)
+			}
+			strings, err := loadTranslations(localize(*flag_translations, locale))
+			if err != nil {
+				return nil, err
+			}
+			localized.Strings = strings
+		}
+		styles = append(styles, localized)
+	}
+	return styles, nil
+}
+
+// generateLocales documents every target again in each of the locales,
+// next to the default output, and returns the exit status for the run
+func generateLocales(ctx context.Context, targets []string, styles []Style, cache *cache) int {
+	if len(styles) == 0 {
+		return 0
+	}
+	paths, err := outputPaths(targets)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	status := 0
+	for _, style := range styles {
+		jobs := make([]*job, len(targets))
+		for i, target := range targets {
+			jobs[i] = &job{target: target, path: localePath(paths[i], style.Locale), locale: style.Locale}
+		}
+		if len(targets) > 1 || *flag_outDir != "" {
+			// Link to the other packages in the same language
+			links := packageLinks{}
+			for _, job := range jobs {
+				importPath, _, err := lookupImport(job.target)
+				if err == nil && importPath != "" {
+					links[importPath] = job.path
+				}
+				job.links = links
+			}
+		}
+
+		start := Time.Now()
+		runJobs(ctx, jobs, style, cache, *flag_jobs)
+		elapsed := Time.Since(start)
+		for _, job := range jobs {
+			if job.err != nil {
+				logger.Error(job.err.Error(), "target", job.target, "locale", style.Locale)
+				status = 1
+			}
+		}
+		logger.Info("generated locale", "locale", style.Locale, "count", len(jobs), "duration", elapsed.Round(Time.Millisecond))
+	}
+	return status
+}
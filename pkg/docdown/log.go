@@ -0,0 +1,60 @@
+package docdown
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// logger reports progress and problems on stderr. It is configured by
+// setupLogger from the -v, -q, and -log-format flags.
+var logger = slog.New(newLogHandler(io.Discard, "text", slog.LevelInfo))
+
+// discardLogger logs nowhere, for styles without a Logger
+var discardLogger = slog.New(newLogHandler(io.Discard, "text", slog.LevelInfo))
+
+// log is the style's Logger, or discardLogger if it has none
+func (self Style) log() *slog.Logger {
+	if self.Logger == nil {
+		return discardLogger
+	}
+	return self.Logger
+}
+
+func newLogHandler(writer io.Writer, format string, level slog.Level) slog.Handler {
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			// Timestamps are noise for a command line tool
+			if len(groups) == 0 && attr.Key == slog.TimeKey && format == "text" {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}
+	if format == "json" {
+		return slog.NewJSONHandler(writer, options)
+	}
+	return slog.NewTextHandler(writer, options)
+}
+
+func setupLogger(writer io.Writer) error {
+	level := slog.LevelInfo
+	switch {
+	case *flag_quiet && *flag_verbose:
+		return fmt.Errorf("Cannot use -q and -v together")
+	case *flag_quiet:
+		level = slog.LevelError
+	case *flag_verbose:
+		level = slog.LevelDebug
+	}
+
+	switch *flag_logFormat {
+	case "text", "json":
+	default:
+		return fmt.Errorf("Invalid log format \"%s\": expected text or json", *flag_logFormat)
+	}
+
+	logger = slog.New(newLogHandler(writer, *flag_logFormat, level))
+	return nil
+}
@@ -0,0 +1,2262 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	Flag "flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	Debug "runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	Template "text/template"
+	Time "time"
+
+	"github.com/lithammer/dedent"
+)
+
+const (
+	punchCardWidth = 80
+	debug          = false
+)
+
+var (
+	flag            = Flag.NewFlagSet("", Flag.ExitOnError)
+	flag_signature  = flag.Bool("signature", false, string(rune(0))) // Deprecated: use -footer
+	flag_plain      = flag.Bool("plain", false, "Emit standard Markdown, rather than Github Flavored Markdown (the default)")
+	flag_flavor     = flag.String("flavor", "github", "The kind of Markdown to emit: github, plain, commonmark, gomarkdoc")
+	flag_heading    = flag.String("heading", "TitleCase1Word", "Heading detection method: 1Word, TitleCase, Title, TitleCase1Word, \"\"")
+	flag_template   = flag.String("template", "", "The template file to use")
+	flag_noTemplate = flag.Bool("no-template", false, "Disable template processing")
+	flag_noFuncs    = flag.Bool("no-funcs", false, "Ignore Funcs")
+	flag_eol        = flag.String("eol", "lf", "Line ending to use in the output: lf, crlf")
+	flag_newline    = flag.Bool("final-newline", true, "End the output with a line ending")
+	flag_jobs       = flag.Int("jobs", runtime.NumCPU(), "Number of packages to generate concurrently")
+	flag_timeout    = flag.Duration("timeout", 0, "Stop generating after this long, like 5m (0 for no limit)")
+	flag_cache      = flag.Bool("cache", false, "Reuse previously generated documentation for unchanged packages")
+	flag_cacheDir   = flag.String("cache-dir", "", "The directory to keep the cache in (default is the user cache directory)")
+	flag_verbose    = flag.Bool("v", false, "Log which files are parsed, which template is used, and where output is written")
+	flag_quiet      = flag.Bool("q", false, "Only log errors")
+	flag_logFormat  = flag.String("log-format", "text", "Log format: text, json")
+	flag_index      = flag.String("index", "docs/README.md", "When documenting several packages to files, write an index of them to this file")
+	flag_report     = flag.String("report", "", "Write a JSON report of the run to a file")
+	flag_warnings   = flag.String("warnings", "off", "How to report documentation warnings: off, log, github")
+	flag_prSummary  = flag.String("pr-summary", "", "Write a Markdown summary of the changes since -base-ref to a file, for posting on a pull request")
+	flag_baseRef    = flag.String("base-ref", "origin/HEAD", "The git revision to compare against for -pr-summary")
+
+	flag_checkLinks = flag.String("check-links", "off", "Check the links of the written pages after rendering: off, warn, or strict (failing on broken links)")
+
+	flag_coverageBadge = flag.String("coverage-badge", "", "Write a shields.io endpoint badge of the documentation coverage to a file")
+
+	flag_repositoryURL = flag.String("repository-url", "", "The URL of the repository, for linking declarations to their source (e.g. https://github.com/user/project)")
+	flag_repositoryRef = flag.String("repository-ref", "", "The branch or tag to link to in the repository (default main)")
+
+	flag_config      = flag.String("config", "", "The config file to read options from (default .godocdown.yaml, if present)")
+	flag_indexHeader = flag.String("index-header", "", "The heading of the index of symbols, e.g. \"API\" or \"## Contents\", or - for none")
+
+	flag_title  = flag.String("title", "", "The title of the documentation, instead of the package name (a template)")
+	flag_header = flag.String("header", "", "What comes after the title, like badges or a tagline (a template)")
+
+	flag_footer         = flag.String("footer", "", "What comes at the end of the documentation, like a \"generated by\" line (a template), or - for nothing")
+	flag_footerTemplate = flag.String("footer-template", "", "A template file for the footer")
+
+	flag_locales = flag.String("locales", "", "A comma-separated list of locales to also generate the documentation in, like \"zh,ja\"")
+
+	flag_translations = flag.String("translations", "", "A YAML file translating the fixed strings of the output, like Index and Example")
+	flag_html         = flag.String("html", "pass", "What to do with raw HTML in doc comments: pass, escape, sanitize")
+
+	flag_emoji = flag.String("emoji", "pass", "What to do with emoji shortcodes, like :warning:, in doc comments: pass, escape, convert")
+
+	flag_entities = flag.String("entities", "pass", "What to do with HTML entities, like &copy;, in doc comments: pass, escape, convert")
+
+	flag_exampleOrder = flag.String("example-order", "name", "The order of examples: name, or source (as in the test files); heavier ones come first")
+
+	flag_exampleFilter = flag.String("example-filter", "", "A regular expression for the examples to document, or with a leading ! for the ones to leave out")
+
+	flag_exampleCode = flag.String("example-code", "", "Clean up the code of examples, with a list of: comments (keep them), unwrap (whole-file examples), gofmt")
+
+	flag_examplePrograms = flag.Bool("example-programs", false, "Show examples as programs that can be run as they are, with a main function and imports")
+
+	flag_methodOrder = flag.String("method-order", "name", "The order of the functions and methods of a type: name, or receiver (constructors first, and value receivers before pointer ones)")
+
+	flag_qualify    = flag.String("qualify", "source", "How declarations qualify the types of other packages: source, full (with their imports), or short (not for the package itself)")
+	flag_wrapParams = flag.Bool("wrap-params", false, "Put the parameters of long function signatures on lines of their own")
+
+	flag_wrap = flag.Bool("wrap", false, "Wrap the lines of declarations wider than -max-width: parameters, type parameters, and results")
+
+	flag_indent   = flag.String("indent", "tabs", "How declarations are indented: tabs, or spaces")
+	flag_tabWidth = flag.Int("tab-width", 4, "How wide a tab is, for aligning declarations")
+	flag_maxWidth = flag.Int("max-width", 80, "How wide a function signature can be before -wrap-params wraps it")
+
+	flag_schema = flag.Bool("schema", false, "Write a JSON Schema of the data templates are executed with, and exit")
+
+	flag_tui = flag.Bool("tui", false, "Show the documentation in the terminal, with a tree of its symbols to navigate it with, instead of writing it")
+
+	flag_configStruct = stringList{}
+	_                 = func() byte {
+		flag.Var(&flag_configStruct, "config-struct", "A struct type to make a configuration reference of, from the tags of its fields (can be repeated)")
+		return 0
+	}()
+
+	flag_pinExample = stringList{}
+	_               = func() byte {
+		flag.Var(&flag_pinExample, "pin-example", "Put an example, like ExampleClient_basic, before the others (can be repeated)")
+		return 0
+	}()
+
+	flag_subpackages       = flag.Bool("subpackages", false, "List the packages in the directories below the documented one")
+	flag_exampleIndex      = flag.String("example-index", "flat", "How the index lists examples: flat, grouped (by symbol), off")
+	flag_codeLang          = flag.String("code-lang", "go", "The language to fence declarations and examples with, e.g. go, golang, or \"\" for none")
+	flag_exampleOutputLang = flag.String("example-output-lang", "", "The language to fence the output of examples with, e.g. text, console, json")
+	flag_exampleTitle      = flag.String("example-title", "", "A template for the titles of examples, e.g. \"Example: {{ .Symbol }} — {{ .Suffix }}\"")
+	flag_stats             = flag.Bool("stats", false, "Count the types, functions, methods, examples, files, and lines of the package")
+	flag_embeds            = flag.Bool("embeds", false, "List the variables filled with files by //go:embed directives, and their patterns")
+	flag_imports           = flag.Bool("imports", false, "List the packages imported from outside of the standard library and the module")
+	flag_diagram           = flag.Bool("diagram", false, "Add a Mermaid diagram of type embedding and interface implementation")
+
+	flag_quickstart = flag.String("quickstart", "", "Show an example, like ExampleClient_basic, in a \"Quick start\" section near the top")
+
+	flag_tutorial = flag.String("tutorial", "", "Write the examples of the package, in order, as the steps of a tutorial on a page of its own next to -output, like TUTORIAL.md")
+
+	flag_stabilitySummary = flag.Bool("stability-summary", false, "List the experimental and beta symbols in an \"Experimental APIs\" section")
+
+	flag_platforms = stringList{}
+	_              = func() byte {
+		flag.Var(&flag_platforms, "platforms", "Document the package for these platforms, like linux,darwin,windows, showing what differs between them")
+		return 0
+	}()
+
+	flag_testCoverage = flag.Bool("test-coverage", false, "Run the package's tests with -cover and add a badge for their coverage to the header")
+
+	flag_runBenchmarks = flag.Bool("run-benchmarks", false, "Run the package's benchmarks and add a table of their results")
+	flag_bench         = flag.String("bench", ".", "A regular expression for the benchmarks to run with -run-benchmarks")
+	flag_benchtime     = flag.String("benchtime", "1s", "How long to run each benchmark for with -run-benchmarks, like 100ms, or how many times, like 100x")
+
+	flag_compact = flag.Bool("compact", false, "Render a terse reference: no index, signatures as headings, short examples, and few blank lines")
+
+	flag_usedBy = flag.Bool("used-by", false, "List the other packages of the module that use each symbol, by type-checking them all")
+
+	flag_annotateChanges = flag.String("annotate-changes", "", "Label the symbols added or changed since a git revision, like v1.2.0")
+
+	flag_goList = flag.Bool("go-list", false, "Read the packages to document from \"go list -json\" on stdin")
+
+	flag_recursive = flag.Bool("recursive", false, "Document every package in the directory trees below the given directories, like ./... but into nested modules too")
+
+	flag_split = flag.String("split", "", "Split the documentation into more than one page: type (a page for each exported type)")
+
+	flag_section = stringList{}
+	_            = func() byte {
+		flag.Var(&flag_section, "section", "Move a section of the package comment, like FAQ, after the API, or to a page of its own with FAQ=FAQ.md (can be repeated)")
+		return 0
+	}()
+
+	flag_outDir = flag.String("out-dir", "", "Write the documentation to a tree of pages in this directory, one for each package")
+
+	flag_searchIndex = flag.Bool("search-index", false, "Write a search index of the packages and their symbols (search.json, for lunr or elasticlunr) and a script to search it (search.js) to -out-dir")
+
+	flag_navigation = flag.String("navigation", "", "Links between the pages of -out-dir, -split, and -section: a list of breadcrumbs (module › package › type) and pages (previous and next)")
+
+	flag_format = flag.String("format", "markdown", "What to write: markdown, docset (a Dash and Zeal docset, like -out-dir=Client.docset), or an announcement for mrkdwn (Slack) or discord")
+
+	flag_asciiTables = flag.String("ascii-tables", "markdown", "What to do with tables drawn in doc comments: markdown (convert them), pre (leave them)")
+
+	flag_numberedHeadings = flag.Bool("numbered-headings", false, "Number the headings after the title by their level, like 2., 2.1, and 2.1.3")
+
+	flag_headingStyle = flag.String("heading-style", "atx", "How to write level 1 and 2 headings: atx (# Title) or setext (underlined)")
+
+	flag_includeInternal = flag.Bool("include-internal", false, "Include internal packages when searching directories for packages")
+	flag_skipDir         = stringList{}
+	_                    = func() byte {
+		flag.Var(&flag_skipDir, "skip-dir", "A directory name to skip when searching directories for packages (can be repeated)")
+		return 0
+	}()
+	flag_unexportedFields = flag.String("unexported-fields", "strip", "What to do with the comment for unexported fields: strip, keep, or text to replace it with")
+	flag_keepDirectives   = flag.Bool("keep-directives", false, "Keep //go:generate, //nolint, and other tool directives in declarations")
+	flag_package          = flag.String("package", "", "The package to document in a directory with more than one (by name)")
+	flag_assembly         = flag.Bool("asm", false, "Note which functions are implemented in assembly, and for which architectures")
+	flag_commandAPI       = flag.Bool("command-api", false, "Document the exported symbols of commands too, not just their package comment")
+	flag_skipGenerated    = flag.Bool("skip-generated", false, "Do not parse or document files with a \"Code generated ... DO NOT EDIT.\" header")
+
+	flag_excludeDir = stringList{}
+	_               = func() byte {
+		flag.Var(&flag_excludeDir, "exclude-dir", "A glob (e.g. **/mocks) for directories to skip when searching directories for packages (can be repeated)")
+		return 0
+	}()
+
+	flag_lintDisable = stringList{}
+	_                = func() byte {
+		flag.Var(&flag_lintDisable, "lint-disable", "A rule for godocdown lint not to check, like name-prefix (can be repeated)")
+		return 0
+	}()
+
+	flag_plugin = stringList{}
+	_           = func() byte {
+		flag.Var(&flag_plugin, "plugin", "A command that reads the package as JSON and changes or adds to its documentation (can be repeated)")
+		return 0
+	}()
+
+	flag_postProcess = flag.String("post-process", "", "A command to pipe the documentation through before it is written, e.g. \"prettier --parser markdown\"")
+
+	flag_filter = stringList{}
+	_           = func() byte {
+		flag.Var(&flag_filter, "filter", "A replacement in the prose of doc comments: s/pattern/replacement/ or text=>replacement (can be repeated)")
+		return 0
+	}()
+
+	flag_weight = stringList{}
+	_           = func() byte {
+		flag.Var(&flag_weight, "weight", "Order a symbol before lighter ones, like Client=10 (can be repeated)")
+		return 0
+	}()
+
+	flag_output = ""
+	_           = func() byte {
+		flag.StringVar(&flag_output, "output", flag_output, "Write output to a file instead of stdout. Write to stdout with -")
+		flag.StringVar(&flag_output, "o", flag_output, string(rune(0)))
+		return 0
+	}()
+)
+
+var (
+	synopsisHeading1Word_Regexp          = regexp.MustCompile("(?m)^([A-Za-z0-9_-]+)$")
+	synopsisHeadingTitleCase_Regexp      = regexp.MustCompile("(?m)^((?:[A-Z][A-Za-z0-9_-]*)(?:[ \t]+[A-Z][A-Za-z0-9_-]*)*)$")
+	synopsisHeadingTitle_Regexp          = regexp.MustCompile("(?m)^((?:[A-Za-z0-9_-]+)(?:[ \t]+[A-Za-z0-9_-]+)*)$")
+	synopsisHeadingTitleCase1Word_Regexp = regexp.MustCompile("(?m)^((?:[A-Za-z0-9_-]+)|(?:(?:[A-Z][A-Za-z0-9_-]*)(?:[ \t]+[A-Z][A-Za-z0-9_-]*)*))$")
+
+	strip_Regexp           = regexp.MustCompile("(?m)^\\s*// contains filtered or unexported fields\\s*\n")
+	unexported_Regexp      = regexp.MustCompile(`(?m)^([ \t]*)// contains filtered or unexported fields[ \t]*$`)
+	directiveLine_Regexp   = regexp.MustCompile(`(?m)^[ \t]*//(?:[a-z0-9]+:[a-z0-9]|nolint\b|line |export |extern |\+build )[^\n]*\n`)
+	directiveAfter_Regexp  = regexp.MustCompile(`(?m)[ \t]+//(?:[a-z0-9]+:[a-z0-9]|nolint\b)[^\n]*$`)
+	indent_Regexp          = regexp.MustCompile("(?m)^([^\\n])") // Match at least one character at the start of the line
+	synopsisHeading_Regexp = synopsisHeading1Word_Regexp
+	match_7f               = regexp.MustCompile(`(?m)[\t ]*\x7f[\t ]*$`)
+)
+
+var DefaultStyle = Style{
+	Flavor:        "github",
+	IncludeImport: true,
+	CodeLanguage:  "go",
+	ASCIITables:   "markdown",
+	Printer:       defaultPrinter,
+	EOL:           "lf",
+	FinalNewline:  true,
+
+	SynopsisHeader:  "####",
+	SynopsisHeading: synopsisHeadingTitleCase1Word_Regexp,
+
+	UsageHeader: "#### Index\n",
+
+	ConstantHeader:     "####",
+	VariableHeader:     "####",
+	FunctionHeader:     "####",
+	TypeHeader:         "####",
+	TypeFunctionHeader: "####",
+
+	ImportsHeader:     "#### Imports\n",
+	CgoHeader:         "#### C dependencies\n",
+	EmbedsHeader:      "#### Embedded assets\n",
+	StatsHeader:       "#### Stats\n",
+	SubpackagesHeader: "#### Subpackages\n",
+	StabilityHeader:   "#### Experimental APIs\n",
+	BenchmarksHeader:  "#### Benchmarks\n",
+	GlossaryHeader:    "#### Glossary\n",
+	EnvHeader:         "#### Environment variables\n",
+	ExitCodesHeader:   "#### Exit codes\n",
+	QuickstartHeader:  "#### Quick start\n",
+
+	ConfigurationHeader: "#### Configuration\n",
+}
+
+// GomarkdocStyle produces headings, anchors, and source links like
+// gomarkdoc (https://github.com/princjef/gomarkdoc) does
+var GomarkdocStyle = Style{
+	Flavor:        "gomarkdoc",
+	IncludeImport: true,
+	CodeLanguage:  "go",
+	ASCIITables:   "markdown",
+	Printer:       defaultPrinter,
+	EOL:           "lf",
+	FinalNewline:  true,
+
+	SynopsisHeader:  "###",
+	SynopsisHeading: synopsisHeadingTitleCase1Word_Regexp,
+
+	UsageHeader: "## Index\n",
+
+	ConstantHeader:     "##",
+	VariableHeader:     "##",
+	FunctionHeader:     "##",
+	TypeHeader:         "##",
+	TypeFunctionHeader: "###",
+
+	ImportsHeader:     "## Imports\n",
+	CgoHeader:         "## C dependencies\n",
+	EmbedsHeader:      "## Embedded assets\n",
+	StatsHeader:       "## Stats\n",
+	SubpackagesHeader: "## Subpackages\n",
+	StabilityHeader:   "## Experimental APIs\n",
+	BenchmarksHeader:  "## Benchmarks\n",
+	GlossaryHeader:    "## Glossary\n",
+	EnvHeader:         "## Environment variables\n",
+	ExitCodesHeader:   "## Exit codes\n",
+	QuickstartHeader:  "## Quick start\n",
+
+	ConfigurationHeader: "## Configuration\n",
+
+	RepositoryRef: "main",
+
+	Footer: Template.Must(parseInline("footer", "Generated by [gomarkdoc](<https://github.com/princjef/gomarkdoc>)")),
+}
+
+// signatureFooter is the footer of the deprecated -signature flag
+var signatureFooter = Template.Must(parseInline("signature", "--\n**godocdown** http://github.com/aschey/godocdown"))
+
+// parseInline parses a small template given as an option, like -header.
+// Besides the document, it can use "now" (the time.Time of the run) and
+// "version" (godocdown's version).
+func parseInline(name, text string) (*Template.Template, error) {
+	template, err := Template.New(name).Funcs(Template.FuncMap{
+		"now":     Time.Now,
+		"version": godocdownVersion,
+	}).Parse(text)
+	if err != nil {
+		return nil, templateError("-"+name, false, err)
+	}
+	return template, nil
+}
+
+// godocdownVersion is the version of the godocdown module this was built
+// from, or "(devel)"
+func godocdownVersion() string {
+	if info, ok := Debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+	executable, err := os.Stat(os.Args[0])
+	if err != nil {
+		return
+	}
+	time := executable.ModTime()
+	since := Time.Since(time)
+	fmt.Fprintf(os.Stderr, "---\n%s (%.2f)\n", time.Format("2006-01-02 15:04 MST"), since.Minutes())
+}
+
+func init() {
+	flag.Usage = usage
+}
+
+// Style controls how a document is rendered. Every document carries its own
+// Style, so documents with different styles can be rendered concurrently.
+type Style struct {
+	// Title and Header are templates, executed with the document, for the
+	// title (instead of the package name) and for what comes right after it
+	// (badges, a tagline, a logo, ...)
+	Title  *Template.Template
+	Header *Template.Template
+
+	// Flavor is the kind of Markdown to emit: github (GitHub Flavored
+	// Markdown), plain (standard Markdown), or gomarkdoc
+	Flavor string
+
+	IncludeImport bool
+
+	SynopsisHeader  string
+	SynopsisHeading *regexp.Regexp
+
+	UsageHeader string
+
+	ConstantHeader     string
+	VariableHeader     string
+	FunctionHeader     string
+	TypeHeader         string
+	TypeFunctionHeader string
+
+	// IncludeSubpackages lists the packages in the directories immediately
+	// below the documented one, under SubpackagesHeader
+	IncludeSubpackages bool
+	SubpackagesHeader  string
+
+	// Dirs decides which directories are searched for packages
+	Dirs DirFilter
+
+	// IncludeImports lists the packages the package imports from outside of
+	// the standard library and its module, under ImportsHeader
+	IncludeImports bool
+	ImportsHeader  string
+
+	// CgoHeader is the heading of the C dependencies of a package that uses
+	// cgo (the headers, libraries, and pkg-config packages of its preambles),
+	// listed after its imports
+	CgoHeader string
+
+	// IncludeEmbeds lists the variables that "//go:embed" directives fill
+	// with files (exported or not), and their patterns, under EmbedsHeader
+	IncludeEmbeds bool
+	EmbedsHeader  string
+
+	// IncludeStats counts the types, functions, methods, examples, files, and
+	// lines of the package, under StatsHeader
+	IncludeStats bool
+	StatsHeader  string
+
+	// IncludeStability lists the symbols of the package that are experimental
+	// or in beta, under StabilityHeader
+	IncludeStability bool
+	StabilityHeader  string
+
+	// RunBenchmarks runs the benchmarks of the package that match
+	// BenchmarkPattern, each for BenchmarkTime (as in go test -benchtime),
+	// and adds their results under BenchmarksHeader
+	RunBenchmarks    bool
+	BenchmarkPattern string
+	BenchmarkTime    string
+	BenchmarksHeader string
+
+	// Compact renders a terse reference, for embedding in a README: without
+	// the index, with the signatures of functions as their headings, short
+	// examples, and no empty sections or blank lines that can be left out
+	Compact bool
+
+	// AnnotateChanges is a git revision to label the symbols added or
+	// changed since with "New since" or "Changed since" it
+	AnnotateChanges string
+
+	// UsedBy lists the other packages of the module that use each symbol,
+	// found by type-checking every package of the module
+	UsedBy bool
+
+	// Quickstart names the example (like ExampleClient_basic) whose code is
+	// shown under QuickstartHeader, after the package comment, instead of
+	// the one with a "//godocdown:quickstart" directive
+	Quickstart       string
+	QuickstartHeader string
+
+	// Tutorial is the page (relative to the page of the package) to write
+	// the examples of the package on, as the steps of a tutorial
+	Tutorial string
+
+	// EnvHeader is the heading of the environment variables documented
+	// with "//godocdown:env" directives
+	EnvHeader string
+
+	// ExitCodesHeader is the heading of the exit codes of a command
+	ExitCodesHeader string
+
+	// ConfigStructs are the struct types to make configuration references
+	// of, from the tags of their fields, under ConfigurationHeader (with the
+	// types that have a "//godocdown:config" directive)
+	ConfigStructs       []string
+	ConfigurationHeader string
+
+	// GlossaryHeader is the heading of the terms defined with
+	// "//godocdown:term" directives
+	GlossaryHeader string
+
+	// Platforms are the platforms (GOOS or GOOS/GOARCH) to document the
+	// package for, if not every file: files that build on none of them are
+	// left out, and declarations that differ between them are shown for each
+	Platforms []string
+
+	// TestCoverage runs the tests of the package with -cover, and adds a
+	// badge for the statement coverage to the header
+	TestCoverage bool
+
+	// ExampleIndex is how the index lists examples: "flat" (the default),
+	// "grouped" under the symbols they are for, or "off"
+	ExampleIndex string
+
+	// MethodOrder is the order of the functions and methods of a type:
+	// "name" (the default), or "receiver", for constructors first, and the
+	// methods with a value receiver before those with a pointer receiver
+	MethodOrder string
+
+	// ExampleOrder is the order of the examples: "name" (the default), or
+	// "source", after the PinnedExamples (in their order), and the heavier
+	// ones by Weights and their "//godocdown:weight" directives
+	ExampleOrder   string
+	PinnedExamples []string
+
+	// ExampleCode are the cleanups made to the code of examples: "comments"
+	// (keep them), "unwrap" (whole-file examples), and "gofmt"
+	ExampleCode []string
+
+	// ExamplePrograms shows examples as programs that can be run as they
+	// are, where they can be
+	ExamplePrograms bool
+
+	// ExampleFilter matches the names of the examples to document (like
+	// ExampleClient_basic), or of those to leave out with SkipExamples
+	ExampleFilter *regexp.Regexp
+	SkipExamples  bool
+
+	// ExampleTitle forms the titles of examples from an exampleTitleData,
+	// instead of "Example (sub name)"
+	ExampleTitle *Template.Template
+
+	// Filters are the replacements made in the prose of doc comments, in
+	// order, before they are rendered
+	Filters []textFilter
+
+	// Weights order symbols (named like F, T, or T.M) before lighter ones in
+	// their sections, overriding their "//godocdown:weight" directives.
+	// Symbols weigh 0 otherwise.
+	Weights map[string]int
+
+	// Plugins are the commands the document model is sent to, in order,
+	// before it is rendered
+	Plugins []string
+
+	// CodeLanguage is the language code blocks are fenced with, unless a
+	// declaration has a "//godocdown:lang" directive
+	CodeLanguage string
+
+	// Printer is how the code of declarations is printed, and Qualify how
+	// they qualify the types of other packages: "source" (as written),
+	// "full" (declaring the imports they use above them), or "short"
+	// (without the qualifier of the package itself). WrapParams puts the parameters of a function
+	// too wide for Printer.Width on lines of their own, and Wrap every list
+	// of a declaration it takes for its lines to fit.
+	Printer    printerProfile
+	Qualify    string
+	WrapParams bool
+	Wrap       bool
+
+	// ExampleOutputLanguage is the language the output of examples is fenced
+	// with, unless an example has a "//godocdown:output-lang" directive
+	ExampleOutputLanguage string
+
+	// ASCIITables is what to do with tables drawn in the preformatted
+	// blocks of doc comments: convert them to "markdown" tables, or leave
+	// them as they are ("pre")
+	ASCIITables string
+
+	// HeadingStyle is how level 1 and 2 headings are written: "atx" (with
+	// "#"), or "setext" (underlined)
+	HeadingStyle string
+
+	// NumberedHeadings numbers the headings after the title, those of the
+	// package comment too, by their level like 2.1.3
+	NumberedHeadings bool
+
+	// CopyAssets copies the local images the documentation refers to next
+	// to its pages, instead of linking to them where they are
+	CopyAssets bool
+
+	// Breadcrumbs puts a trail of links (module › package › type) at the top
+	// of each page, and PageLinks links to the previous and the next page at
+	// the bottom, for documentation on more than one page
+	Breadcrumbs bool
+	PageLinks   bool
+
+	// SearchIndex writes a search index of the packages and their symbols
+	// to -out-dir, for lunr or elasticlunr, with a script to search it
+	SearchIndex bool
+
+	// Format is what is written: "markdown", a "docset" of HTML pages for
+	// Dash and Zeal (as -out-dir), or a short announcement of each package
+	// for Slack ("mrkdwn") or "discord"
+	Format string
+
+	// Split is how the documentation is split into more than one page:
+	// "type" gives each exported type a page of its own, or "" for none
+	Split string
+
+	// Sections maps the headings of the sections of the package comment to
+	// take out of it to the pages to put them on, relative to the page of
+	// the package, or "" to put them after the documentation of the API
+	Sections map[string]string
+
+	// IncludeDiagram adds a Mermaid diagram of how the types of the package
+	// embed each other and implement its interfaces
+	IncludeDiagram bool
+
+	// UnexportedFields is what to do with the "// contains filtered or
+	// unexported fields" comments in declarations: "strip" them (the
+	// default), "keep" them, or replace them with any other text
+	UnexportedFields string
+
+	// KeepDirectives keeps the comments meant for the compiler and other
+	// tools (//go:generate, //nolint, and the like) in declarations
+	KeepDirectives bool
+
+	// SkipGenerated leaves out files with a "Code generated ... DO NOT EDIT."
+	// header
+	SkipGenerated bool
+
+	// Package is the name of the package to document in a directory with
+	// more than one, or "" to choose one
+	Package string
+
+	// CommandAPI documents the exported symbols of commands, not only their
+	// package comment
+	CommandAPI bool
+
+	// Assembly notes which functions are implemented in assembly
+	Assembly bool
+
+	// Locale is the language of the documentation when generating it in
+	// more than one, and selects the templates for that language
+	Locale string
+
+	// Strings translates the fixed strings of the output, like "Index" and
+	// "Example", from English
+	Strings map[string]string
+
+	// Template is the template file to render with (or for the outputs of
+	// the config with templates of their own, theirs), rather than one
+	// found next to the package, and NoTemplate renders without any
+	Template   string
+	NoTemplate bool
+
+	// NoFuncs leaves the functions and methods of the package out
+	NoFuncs bool
+
+	// EOL is the line ending of the output, "lf" (or "") or "crlf", and
+	// FinalNewline ends the output with one
+	EOL          string
+	FinalNewline bool
+
+	// HTML is what to do with raw HTML in doc comments: "pass" it through
+	// (the default), "escape" it, or "sanitize" it, escaping all but a few
+	// harmless tags
+	HTML string
+
+	// Emoji is what to do with emoji shortcodes (like :warning:) in doc
+	// comments, which GitHub shows as emoji: "pass" them through (the
+	// default), "escape" them, or "convert" them to emoji
+	Emoji string
+
+	// Entities is what to do with HTML entities (like &copy;) in doc
+	// comments: "pass" them through (the default), "escape" them, or
+	// "convert" them to the characters they stand for
+	Entities string
+
+	// Footer is a template, executed with the document, for what comes at
+	// the end (like a "generated by" line)
+	Footer *Template.Template
+
+	// RepositoryURL and RepositoryRef are used to link declarations to
+	// their source
+	RepositoryURL string
+	RepositoryRef string
+
+	// PostProcess is a command, run with the shell, to pipe the
+	// documentation written by the command through before it is written
+	PostProcess string
+
+	// OutDir is the directory the command writes the pages of the packages
+	// to, which with the docset Format is the docset
+	OutDir string
+
+	// Logger is where problems found while loading and rendering (like a
+	// directory with more than one package) are logged, or nowhere if nil
+	Logger *slog.Logger
+
+	// listed has the packages read with -go-list, by directory, which are
+	// documented as go list described them rather than as found on disk
+	listed map[string]*listedPackage
+}
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (self *stringList) String() string {
+	return strings.Join(*self, ",")
+}
+
+func (self *stringList) Set(value string) error {
+	*self = append(*self, value)
+	return nil
+}
+
+type Document struct {
+	Name       string
+	pkg        *doc.Package
+	fset       *token.FileSet
+	style      Style
+	absPath    string
+	testFiles  map[string]*ast.File
+	IsCommand  bool
+	ImportPath string
+	Examples   Examples
+
+	// files are the (non-test) files of the package, and imports maps the
+	// names they import packages as to their import paths
+	files   map[string]*ast.File
+	imports map[string]string
+
+	// links are the other packages documented in this run, and page is
+	// where this document is written, for linking to them
+	links packageLinks
+	page  string
+
+	// directives are the godocdown directives of the package's
+	// declarations, by their positions
+	directives map[token.Pos]map[string]string
+
+	// assets are the local images the documentation refers to, relative
+	// to the package directory
+	assets map[string]bool
+
+	// split maps the types that have pages of their own (with -split=type)
+	// to the names of their pages
+	split map[string]string
+
+	// footerEmitted is set once a template has emitted the footer, so it
+	// isn't added again at the end, and sectionsEmitted has the (lower case)
+	// headings of the sections of the package comment it has emitted
+	footerEmitted   bool
+	sectionsEmitted map[string]bool
+
+	// pluginSections are the sections added by plugins, and pluginMarkdown
+	// is the documentation of a plugin that rendered it itself
+	pluginSections []pluginSection
+	pluginMarkdown *string
+
+	// benchmarks are the results of the package's benchmarks, with
+	// -run-benchmarks, and testCoverage is the statement coverage of its
+	// tests, with -test-coverage
+	benchmarks   benchmarks
+	testCoverage string
+
+	// changes maps the names of the symbols (as api names them) that were
+	// added or changed since the style's AnnotateChanges to how
+	changes map[string]string
+
+	// usedBy maps the names of the symbols (Name, or Type.Method) to the
+	// other packages of the module that use them, with the style's UsedBy
+	usedBy map[string][]string
+
+	// platforms maps the files that build on some of the style's Platforms,
+	// but not all, to those
+	platforms map[string][]string
+
+	// terms are the "//godocdown:term" directives of the package, for its
+	// glossary, and termPage is the page their definitions are on, relative
+	// to the page being rendered ("" for the same page)
+	terms    []glossaryTerm
+	termPage string
+
+	// blocks are the layouts of the grouped const and var declarations of
+	// the package, for valueBlock
+	blocks map[token.Pos][]blockElement
+
+	// cgo are the preambles of the cgo files of the package
+	cgo []string
+
+	// assembly maps the functions of the package implemented in assembly
+	// to the architectures they are implemented for, with Assembly
+	assembly map[string][]string
+
+	// embeds are the variables of the package with "//go:embed" directives
+	embeds []embeddedAsset
+
+	// env are the "//godocdown:env" directives of the package
+	env []envVariable
+
+	// exits are the exit codes of the package, from directives and constants
+	exits []exitCode
+
+	// docPos is the position of the package comment (of the first file with
+	// one), which go/doc takes out of the files, for problems with it
+	docPos token.Pos
+
+	// source is the directory the package was parsed from, which Render
+	// parses again for a style that takes other files or another package,
+	// and candidates are the packages there were to choose from
+	source     fs.FS
+	candidates []string
+
+	// exampleTypes is the package type-checked with its test files, with
+	// ExamplePrograms, done on first use by typeCheckExamples
+	exampleTypes *exampleTypes
+
+	// module is the path of the module the package is in, looked up on
+	// first use by modulePath
+	module *string
+
+	// repositoryPrefix is the directory of the package within its
+	// repository, looked up on first use by sourceLink
+	repositoryPrefix *string
+}
+
+func takeOut7f(input string) string {
+	return match_7f.ReplaceAllString(input, "")
+}
+
+// func _formatIndent(target, indent, preIndent string) string {
+// 	var buffer bytes.Buffer
+// 	toText(&buffer, target, indent, preIndent, punchCardWidth-2*len(indent))
+// 	s := buffer.String()
+// 	return dedent.Dedent(s)
+// }
+//
+//
+// func formatIndent(target string) string {
+// 	return _formatIndent(target, spacer(0), spacer(0))
+// }
+
+// filterExamples filters the list of examples to only includes the ones that
+// are associated with the provided type/func name
+func filterExamples(exs []*doc.Example, name string) (res []*doc.Example) {
+	for _, e := range exs {
+		root := strings.SplitN(e.Name, "_", 2)[0]
+		if root == name {
+			res = append(res, e)
+		}
+	}
+	return
+}
+
+func spacer(width int) string {
+	return strings.Repeat(" ", width)
+}
+
+func indentCode(target string, style Style) string {
+	if style.Flavor == "plain" {
+		return indent(target+"\n", spacer(4))
+	}
+	if target[0] == '{' && target[len(target)-1] == '}' {
+		// example code night be wrapped in a weird enclosing brackets. clean it
+		// up.
+		target = target[1 : len(target)-1]
+	}
+	target = dedent.Dedent(target)
+	target = strings.Trim(target, "\n")
+	return fmt.Sprintf("```%s\n%s\n```", style.CodeLanguage, target)
+}
+
+func headifySynopsis(target string, style Style) string {
+	detect := style.SynopsisHeading
+	if detect == nil {
+		return target
+	}
+	return detect.ReplaceAllStringFunc(target, func(heading string) string {
+		return fmt.Sprintf("%s %s", style.SynopsisHeader, heading)
+	})
+}
+
+func exampleNames(name string) (base, sub string) {
+	comps := strings.SplitN(name, "_", 2)
+	base = comps[0]
+	if len(comps) > 1 {
+		sub = " (" + strings.Replace(comps[1], "_", " ", -1) + ")"
+	}
+	return
+}
+
+func headlineSynopsis(synopsis, header string, scanner *regexp.Regexp) string {
+	return scanner.ReplaceAllStringFunc(synopsis, func(headline string) string {
+		return fmt.Sprintf("%s %s", header, headline)
+	})
+}
+
+func sourceOfNode(fset *token.FileSet, target interface{}) string {
+	return defaultPrinter.source(fset, target)
+}
+
+// unexportedFields keeps, strips, or replaces the "// contains filtered or
+// unexported fields" comments go/doc leaves in declarations, as the style
+// says
+func unexportedFields(source string, style Style) string {
+	switch style.UnexportedFields {
+	case "keep":
+		return source
+	case "", "strip":
+		return strip_Regexp.ReplaceAllString(source, "")
+	}
+	text := style.UnexportedFields
+	if !strings.HasPrefix(text, "//") {
+		text = "// " + text
+	}
+	return unexported_Regexp.ReplaceAllString(source, "${1}"+strings.ReplaceAll(text, "$", "$$"))
+}
+
+// stripDirectives removes the comments meant for the compiler and other
+// tools (//go:generate, //nolint, and the like) from source
+func stripDirectives(source string) string {
+	source = directiveLine_Regexp.ReplaceAllString(source, "")
+	return directiveAfter_Regexp.ReplaceAllString(source, "")
+}
+
+func indentNode(source string, style Style) string {
+	source = unexportedFields(source, style)
+	if !style.KeepDirectives {
+		source = stripDirectives(source)
+	}
+	return indentCode(source, style)
+}
+
+func indent(target string, indent string) string {
+	return indent_Regexp.ReplaceAllString(target, indent+"$1")
+}
+
+func filterText(input string) string {
+	// Why is this here?
+	// Normally, godoc will ALWAYS collapse adjacent lines separated only by whitespace.
+	// However, if you place a (normally invisible) \x7f character in the documentation,
+	// this collapse will not happen. Thankfully, Markdown does not need this sort of hack,
+	// so we remove it.
+	return takeOut7f(input)
+}
+
+func fromSlash(path string) string {
+	return filepath.FromSlash(path)
+}
+
+func exampleSubName(name string) string {
+	_, sub := exampleNames(name)
+	return sub
+}
+
+/*
+	    This is how godoc does it:
+
+		// Determine paths.
+		//
+		// If we are passed an operating system path like . or ./foo or /foo/bar or c:\mysrc,
+		// we need to map that path somewhere in the fs name space so that routines
+		// like getPageInfo will see it.  We use the arbitrarily-chosen virtual path "/target"
+		// for this.  That is, if we get passed a directory like the above, we map that
+		// directory so that getPageInfo sees it as /target.
+		const target = "/target"
+		const cmdPrefix = "cmd/"
+		path := flag.Arg(0)
+		var forceCmd bool
+		var abspath, relpath string
+		if filepath.IsAbs(path) {
+			fs.Bind(target, OS(path), "/", bindReplace)
+			abspath = target
+		} else if build.IsLocalImport(path) {
+			cwd, _ := os.Getwd() // ignore errors
+			path = filepath.Join(cwd, path)
+			fs.Bind(target, OS(path), "/", bindReplace)
+			abspath = target
+		} else if strings.HasPrefix(path, cmdPrefix) {
+			path = path[len(cmdPrefix):]
+			forceCmd = true
+		} else if bp, _ := build.Import(path, "", build.FindOnly); bp.Dir != "" && bp.ImportPath != "" {
+			fs.Bind(target, OS(bp.Dir), "/", bindReplace)
+			abspath = target
+			relpath = bp.ImportPath
+		} else {
+			abspath = pathpkg.Join(pkgHandler.fsRoot, path)
+		}
+		if relpath == "" {
+			relpath = abspath
+		}
+*/
+func buildImport(target string) (string, string, error) {
+	absPath, err := filepath.Abs(target)
+	if err != nil {
+		return "", "", err
+	}
+	absPath = normalizeVolume(absPath)
+
+	// Compare the real paths, as the package or the module can be reached
+	// through a symlink
+	realPath := canonicalPath(absPath)
+	modName, modDir, err := findModule(realPath)
+	if err != nil {
+		return "", "", err
+	}
+	relPath, err := filepath.Rel(modDir, realPath)
+	if err != nil {
+		return "", "", err
+	}
+	// Ensure we use forward slashes on windows
+	importPath := path.Join(modName, filepath.ToSlash(relPath))
+	if modName == "std" {
+		// The standard library's import paths have no module prefix
+		importPath = filepath.ToSlash(relPath)
+	}
+
+	return importPath, absPath, nil
+}
+
+// parseDir parses the Go files in the root of fsys, like parser.ParseDir.
+// absPath is where the files live on disk and is used to name them. With
+// the style's SkipGenerated, files with a "Code generated ... DO NOT EDIT."
+// header are left out without parsing more than their header. It stops
+// when ctx is done.
+func parseDir(ctx context.Context, fset *token.FileSet, fsys fs.FS, absPath string, style Style) (map[string]*ast.Package, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	pkgSet := map[string]*ast.Package{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name[0] == '.' || !strings.HasSuffix(name, ".go") { //} && !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		filename := filepath.Join(absPath, name)
+		style.log().Debug("parsing file", "file", filename)
+		src, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if style.SkipGenerated {
+			header, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.PackageClauseOnly|parser.ParseComments)
+			if err == nil && ast.IsGenerated(header) {
+				style.log().Debug("skipping generated file", "file", filename)
+				continue
+			}
+		}
+		file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg := pkgSet[file.Name.Name]
+		if pkg == nil {
+			pkg = &ast.Package{
+				Name:  file.Name.Name,
+				Files: map[string]*ast.File{},
+			}
+			pkgSet[pkg.Name] = pkg
+		}
+		pkg.Files[filename] = file
+	}
+	return pkgSet, nil
+}
+
+// loadDocument loads the package at target, arranged as style says
+func loadDocument(ctx context.Context, target string, style Style) (*Document, error) {
+	document, err := parseTarget(ctx, target, style)
+	if err != nil {
+		return nil, err
+	}
+	document.arrange()
+	return document, nil
+}
+
+// parseTarget parses the package at target (a directory, an import path, or
+// a single source file), as the style's -go-list packages describe it if it
+// is one of them
+func parseTarget(ctx context.Context, target string, style Style) (*Document, error) {
+	if isSourceFile(target) {
+		return loadSourceFile(ctx, target, style)
+	}
+	if pkg, ok := style.listed[target]; ok {
+		return parseDocument(ctx, newListedFS(pkg), pkg.Dir, pkg.ImportPath, style)
+	}
+
+	importPath, absPath, err := findImport(target)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(ctx, os.DirFS(absPath), absPath, importPath, style)
+}
+
+// loadDocumentFrom loads the package in the root of fsys, which is found on
+// disk at absPath (or was, for packages loaded from elsewhere), arranged as
+// style says
+func loadDocumentFrom(ctx context.Context, fsys fs.FS, absPath, importPath string, style Style) (*Document, error) {
+	document, err := parseDocument(ctx, fsys, absPath, importPath, style)
+	if err != nil {
+		return nil, err
+	}
+	document.arrange()
+	return document, nil
+}
+
+// parseDocument parses the package in the root of fsys, taking the files and
+// choosing the package as style says, but leaving the rest of the style's
+// choices to arrange and rendering
+func parseDocument(ctx context.Context, fsys fs.FS, absPath, importPath string, style Style) (*Document, error) {
+	fset := token.NewFileSet()
+	pkgSet, err := parseDir(ctx, fset, fsys, absPath, style)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse \"%s\": %w", absPath, err)
+	}
+	var platforms map[string][]string
+	if len(style.Platforms) > 0 {
+		platforms, err = filterPlatforms(fsys, absPath, pkgSet, style.Platforms, style.log())
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse \"%s\": %v", absPath, err)
+		}
+	}
+
+	if read, err := fs.ReadFile(fsys, ".godocdown.import"); err == nil {
+		importPath = strings.TrimSpace(strings.Split(string(read), "\n")[0])
+	}
+
+	{
+		isCommand := false
+		name := ""
+		var pkg *doc.Package
+		var files, testFiles map[string]*ast.File
+		var directives map[token.Pos]map[string]string
+		var terms []glossaryTerm
+		var blocks map[token.Pos][]blockElement
+		var cgo []string
+		var assembly map[string][]string
+		var embeds []embeddedAsset
+		var env []envVariable
+		var exits []exitCode
+		var docPos token.Pos
+
+		// Choose the best package for documentation. Either
+		// documentation, main, or whatever the package is, unless -package
+		// names it. The names are sorted, so that a guess is the same every
+		// time.
+		var pkgNames, candidates []string
+		var externalTests map[string]map[string]*ast.File
+		for pkgName := range pkgSet {
+			pkgNames = append(pkgNames, pkgName)
+		}
+		sort.Strings(pkgNames)
+		for _, pkgName := range pkgNames {
+			parsePkg := pkgSet[pkgName]
+			if style.Package != "" && pkgName != style.Package && pkgName != style.Package+"_test" {
+				continue
+			}
+			// we don't want to document the test files, but we do need to keep
+			// them around to extract the examples from them.
+			astFiles := make(map[string]*ast.File)
+			for k, f := range parsePkg.Files {
+				if strings.HasSuffix(k, "_test.go") {
+					astFiles[k] = f
+					delete(parsePkg.Files, k)
+				}
+			}
+			if len(parsePkg.Files) == 0 {
+				// Only tests, like an external test package, whose examples
+				// go with the package it tests
+				if externalTests == nil {
+					externalTests = make(map[string]map[string]*ast.File)
+				}
+				externalTests[pkgName] = astFiles
+				continue
+			}
+			if pkgName != "documentation" {
+				// Which goes with the command it documents
+				candidates = append(candidates, pkgName)
+			}
+
+			declared := declarationDirectives(parsePkg.Files)
+			defined := termDefinitions(parsePkg.Files)
+			layouts := blockLayouts(fset, parsePkg.Files)
+			preambles := cgoPreambles(parsePkg.Files)
+			assets := embeddedAssets(fset, parsePkg.Files)
+			variables := envVariables(parsePkg.Files)
+			codes := exitCodes(parsePkg.Files)
+			commentPos := packageDocPos(parsePkg.Files)
+			implemented := assemblyFuncs(fsys, parsePkg.Files)
+			markIncludes(parsePkg.Files)
+			tmpPkg := doc.New(parsePkg, ".", 0)
+			switch tmpPkg.Name {
+			case "main":
+				if isCommand || name != "" {
+					// We've already seen "package documentation"
+					// (or something else), so favor that over main.
+					continue
+				}
+				fallthrough
+			case "documentation":
+				// We're a command, this package/file contains the documentation
+				// path is used to get the containing directory in the case of
+				// command documentation
+
+				_, name = filepath.Split(absPath)
+				isCommand = true
+				pkg = tmpPkg
+				testFiles = nil
+				files = parsePkg.Files
+				directives = declared
+				terms = defined
+				blocks = layouts
+				cgo = preambles
+				assembly = implemented
+				embeds = assets
+				env = variables
+				exits = codes
+				docPos = commentPos
+			default:
+				// Just a regular package, which is favored over a main one
+				// (like a generator with an ignore build tag) seen before it
+				name = tmpPkg.Name
+				isCommand = false
+				pkg = tmpPkg
+				files = parsePkg.Files
+				directives = declared
+				terms = defined
+				blocks = layouts
+				cgo = preambles
+				assembly = implemented
+				embeds = assets
+				env = variables
+				exits = codes
+				docPos = commentPos
+				testFiles = astFiles
+			}
+		}
+
+		if pkg != nil && !isCommand {
+			for k, f := range externalTests[pkg.Name+"_test"] {
+				if testFiles == nil {
+					testFiles = make(map[string]*ast.File)
+				}
+				testFiles[k] = f
+			}
+		}
+		if pkg != nil {
+			var exs Examples
+			for _, f := range testFiles {
+				for _, e := range doc.Examples(f) {
+					exs = append(exs, e)
+				}
+			}
+
+			sort.Sort(exs)
+			document := &Document{
+				Name:       name,
+				pkg:        pkg,
+				fset:       fset,
+				style:      style,
+				absPath:    absPath,
+				testFiles:  testFiles,
+				files:      files,
+				directives: directives,
+				platforms:  platforms,
+				terms:      terms,
+				blocks:     blocks,
+				cgo:        cgo,
+				assembly:   assembly,
+				embeds:     embeds,
+				env:        env,
+				exits:      exits,
+				docPos:     docPos,
+				source:     fsys,
+				candidates: candidates,
+				imports:    importsOf(files),
+				IsCommand:  isCommand,
+				ImportPath: importPath,
+				Examples:   exs,
+			}
+			return document, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoPackage, absPath)
+}
+
+// arrange makes the choices of the style about what go/doc found: it warns
+// about a package chosen from several, leaves out what is ignored, and
+// puts the symbols and examples in order
+func (self *Document) arrange() {
+	if len(self.candidates) > 1 {
+		self.style.log().Warn("Found more than one package, choose one with -package", "directory", self.absPath, "packages", strings.Join(self.candidates, ", "), "documenting", self.pkg.Name)
+	}
+	self.dropIgnored()
+	self.dropPlatformDuplicates()
+	self.orderByWeight()
+	self.orderMethods()
+	self.orderExamples()
+}
+
+func emitString(fn func(io.Writer)) string {
+	var buffer bytes.Buffer
+	writer := newTrimWriter(&buffer)
+	fn(writer)
+	writer.Close()
+	return buffer.String()
+}
+
+// Emit
+func (self *Document) Emit() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitTo(writer)
+	})
+}
+
+func (self *Document) EmitTo(writer io.Writer) {
+	trim := newTrimWriter(writer)
+	defer trim.Close()
+
+	// Header
+	self.EmitHeaderTo(trim)
+
+	// Synopsis
+	self.EmitSynopsisTo(trim)
+
+	// Quick start
+	self.EmitQuickstartTo(trim)
+
+	// Usage
+	if self.documentsAPI() {
+		self.EmitUsageTo(trim)
+	}
+
+	// Sections moved out of the package comment
+	self.EmitSectionsTo(trim)
+
+	// Environment variables
+	self.EmitEnvTo(trim)
+
+	// Exit codes
+	self.EmitExitCodesTo(trim)
+
+	// Configuration
+	self.EmitConfigurationTo(trim)
+
+	// Glossary
+	self.EmitGlossaryTo(trim)
+
+	// Plugins
+	self.EmitPluginsTo(trim)
+
+	// Experimental APIs
+	self.EmitStabilityTo(trim)
+
+	// Stats
+	self.EmitStatsTo(trim)
+
+	// Benchmarks
+	self.EmitBenchmarksTo(trim)
+
+	// Imports
+	self.EmitImportsTo(trim)
+
+	// Embedded assets
+	self.EmitEmbedsTo(trim)
+
+	// Subpackages
+	self.EmitSubpackagesTo(trim)
+}
+
+// documentsAPI reports whether the exported symbols of the package are
+// documented: for a library, or for a command with -command-api
+func (self *Document) documentsAPI() bool {
+	return !self.IsCommand || self.style.CommandAPI
+}
+
+// Footer
+func (self *Document) EmitFooter() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitFooterTo(writer)
+	})
+}
+
+func (self *Document) EmitFooterTo(writer io.Writer) {
+	self.footerEmitted = true
+	renderFooterTo(writer, self)
+}
+
+// EmitSignature is the old name of EmitFooter
+func (self *Document) EmitSignature() string {
+	return self.EmitFooter()
+}
+
+// Stats
+func (self *Document) EmitStats() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitStatsTo(writer)
+	})
+}
+
+func (self *Document) EmitStatsTo(writer io.Writer) {
+	renderStatsTo(writer, self)
+}
+
+// Quick start
+func (self *Document) EmitQuickstart() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitQuickstartTo(writer)
+	})
+}
+
+func (self *Document) EmitQuickstartTo(writer io.Writer) {
+	renderQuickstartTo(writer, self)
+}
+
+// Sections moved out of the package comment
+func (self *Document) EmitSections() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitSectionsTo(writer)
+	})
+}
+
+func (self *Document) EmitSectionsTo(writer io.Writer) {
+	renderSectionsTo(writer, self)
+}
+
+// EmitSection emits the section of the package comment with the given
+// heading, if -section takes it out of the comment
+func (self *Document) EmitSection(heading string) string {
+	_, sections := self.packageSections()
+	for _, section := range sections {
+		if strings.EqualFold(section.Heading, heading) && section.Page == "" {
+			if self.sectionsEmitted == nil {
+				self.sectionsEmitted = map[string]bool{}
+			}
+			self.sectionsEmitted[strings.ToLower(section.Heading)] = true
+			return emitString(func(writer io.Writer) {
+				renderSectionTo(writer, self, section)
+			})
+		}
+	}
+	return ""
+}
+
+// Environment variables
+func (self *Document) EmitEnv() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitEnvTo(writer)
+	})
+}
+
+func (self *Document) EmitEnvTo(writer io.Writer) {
+	renderEnvTo(writer, self)
+}
+
+// Exit codes
+func (self *Document) EmitExitCodes() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitExitCodesTo(writer)
+	})
+}
+
+func (self *Document) EmitExitCodesTo(writer io.Writer) {
+	renderExitCodesTo(writer, self)
+}
+
+// Configuration
+func (self *Document) EmitConfiguration() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitConfigurationTo(writer)
+	})
+}
+
+func (self *Document) EmitConfigurationTo(writer io.Writer) {
+	renderConfigurationTo(writer, self)
+}
+
+// Glossary
+func (self *Document) EmitGlossary() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitGlossaryTo(writer)
+	})
+}
+
+func (self *Document) EmitGlossaryTo(writer io.Writer) {
+	renderGlossaryTo(writer, self)
+}
+
+// Embedded assets
+func (self *Document) EmitEmbeds() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitEmbedsTo(writer)
+	})
+}
+
+func (self *Document) EmitEmbedsTo(writer io.Writer) {
+	renderEmbedsTo(writer, self)
+}
+
+// Plugins
+func (self *Document) EmitPlugins() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitPluginsTo(writer)
+	})
+}
+
+func (self *Document) EmitPluginsTo(writer io.Writer) {
+	renderPluginSectionsTo(writer, self)
+}
+
+// Benchmarks
+func (self *Document) EmitBenchmarks() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitBenchmarksTo(writer)
+	})
+}
+
+func (self *Document) EmitBenchmarksTo(writer io.Writer) {
+	renderBenchmarksTo(writer, self)
+}
+
+// Experimental APIs
+func (self *Document) EmitStability() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitStabilityTo(writer)
+	})
+}
+
+func (self *Document) EmitStabilityTo(writer io.Writer) {
+	renderStabilityTo(writer, self)
+}
+
+// Imports
+func (self *Document) EmitImports() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitImportsTo(writer)
+	})
+}
+
+func (self *Document) EmitImportsTo(writer io.Writer) {
+	renderImportsTo(writer, self)
+	renderCgoTo(writer, self)
+}
+
+// Diagram
+func (self *Document) EmitDiagram() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitDiagramTo(writer)
+	})
+}
+
+func (self *Document) EmitDiagramTo(writer io.Writer) {
+	renderDiagramTo(writer, self)
+}
+
+// Subpackages
+func (self *Document) EmitSubpackages() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitSubpackagesTo(writer)
+	})
+}
+
+func (self *Document) EmitSubpackagesTo(writer io.Writer) {
+	err := renderSubpackagesTo(writer, self)
+	if err != nil {
+		self.style.log().Warn("Could not list subpackages", "package", self.absPath, "error", err)
+	}
+}
+
+// Header
+func (self *Document) EmitHeader() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitHeaderTo(writer)
+	})
+}
+
+func (self *Document) EmitHeaderTo(writer io.Writer) {
+	renderHeaderTo(writer, self)
+}
+
+// Synopsis
+func (self *Document) EmitSynopsis() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitSynopsisTo(writer)
+	})
+}
+
+func (self *Document) EmitSynopsisTo(writer io.Writer) {
+	renderSynopsisTo(writer, self)
+}
+
+// Usage
+func (self *Document) EmitUsage() string {
+	return emitString(func(writer io.Writer) {
+		self.EmitUsageTo(writer)
+	})
+}
+
+func (self *Document) EmitUsageTo(writer io.Writer) {
+	renderUsageTo(writer, self)
+}
+
+var templateNameList = strings.Fields(`
+	.godocdown.markdown
+	.godocdown.md
+	.godocdown.template
+	.godocdown.tmpl
+`)
+
+func findTemplate(path string, locale string) string {
+
+	for _, templateName := range templateNameList {
+		if locale != "" {
+			// .godocdown.template becomes .godocdown.zh.template
+			templateName = localePath(templateName, locale)
+		}
+		templatePath := filepath.Join(path, templateName)
+		_, err := os.Stat(templatePath)
+		if err != nil {
+			if os.IsExist(err) {
+				continue
+			}
+			continue // Other error reporting?
+		}
+		return templatePath
+	}
+	return "" // Nothing found
+}
+
+func loadTemplate(document *Document) (*Template.Template, string, error) {
+	if document.style.NoTemplate {
+		return nil, "", nil
+	}
+
+	locale := document.style.Locale
+	templatePath := localize(document.style.Template, locale)
+	if templatePath == "" && locale != "" {
+		templatePath = findTemplate(document.absPath, locale)
+	}
+	if templatePath == "" {
+		templatePath = findTemplate(document.absPath, "")
+	}
+
+	if templatePath == "" {
+		document.style.log().Debug("no template", "package", document.absPath)
+		return nil, "", nil
+	}
+	document.style.log().Debug("using template", "package", document.absPath, "template", templatePath)
+
+	template := Template.New("").Funcs(Template.FuncMap{})
+	template, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return nil, "", templateError(templatePath, false, err)
+	}
+	return template, templatePath, nil
+}
+
+func (self *Document) Badge() string {
+	return "[![GoDocDown](https://img.shields.io/badge/docs-generated-blue.svg?longCache=true)](https://github.com/aschey/godocdown)"
+}
+
+func (self *Document) ToCode(code string) string {
+	return indentCode(code, self.style)
+}
+
+func (self *Document) Synopsis() string {
+	return self.packageDoc()
+}
+
+func (self *Document) Import() string {
+	return fmt.Sprintf(`import "%s"`, self.ImportPath)
+}
+
+func (self *Document) Funcs() []*doc.Func {
+	return self.pkg.Funcs
+}
+
+func (self *Document) Types() []*doc.Type {
+	return self.pkg.Types
+}
+
+func (self *Document) Consts() []*doc.Value {
+	return self.pkg.Consts
+}
+
+func (self *Document) Vars() []*doc.Value {
+	return self.pkg.Vars
+}
+
+// Examples are the examples of a package, which sort by name
+type Examples []*doc.Example
+
+func (exs Examples) Len() int           { return len(exs) }
+func (exs Examples) Less(i, j int) bool { return exs[i].Name < exs[j].Name }
+func (exs Examples) Swap(i, j int)      { exs[i], exs[j] = exs[j], exs[i] }
+
+// withoutFuncs is a copy of pkg without its functions and methods, for
+// NoFuncs, which leaves pkg (shared by the documents Render copies) alone
+func withoutFuncs(pkg *doc.Package) *doc.Package {
+	copied := *pkg
+	copied.Funcs = nil
+	copied.Types = make([]*doc.Type, len(pkg.Types))
+	for i, entry := range pkg.Types {
+		typeCopy := *entry
+		typeCopy.Funcs = nil
+		typeCopy.Methods = nil
+		copied.Types[i] = &typeCopy
+	}
+	return &copied
+}
+
+func buildStyle() (Style, error) {
+	flavor := *flag_flavor
+	if *flag_plain && flavor == "github" {
+		flavor = "plain"
+	}
+
+	style := DefaultStyle
+	switch flavor {
+	case "github":
+	case "plain", "commonmark":
+		style.Flavor = flavor
+	case "gomarkdoc":
+		style = GomarkdocStyle
+	default:
+		return style, fmt.Errorf("Invalid flavor \"%s\": expected github, plain, commonmark, or gomarkdoc", flavor)
+	}
+	style.IncludeSubpackages = *flag_subpackages
+	style.Template = *flag_template
+	style.NoTemplate = *flag_noTemplate
+	style.NoFuncs = *flag_noFuncs
+	switch *flag_eol {
+	case "lf", "crlf":
+		style.EOL = *flag_eol
+	default:
+		return style, fmt.Errorf("Invalid -eol \"%s\": expected lf or crlf", *flag_eol)
+	}
+	style.FinalNewline = *flag_newline
+	style.SkipGenerated = *flag_skipGenerated
+	style.Package = *flag_package
+	style.CommandAPI = *flag_commandAPI
+	style.Assembly = *flag_assembly
+	style.IncludeDiagram = *flag_diagram
+	style.CopyAssets = *flag_outDir != ""
+	switch *flag_format {
+	case "markdown":
+	case "docset":
+		if *flag_outDir == "" {
+			return style, fmt.Errorf("Cannot use -format=docset without -out-dir")
+		}
+		if !strings.HasSuffix(filepath.Clean(*flag_outDir), ".docset") {
+			return style, fmt.Errorf("Invalid -out-dir \"%s\" with -format=docset: expected a directory ending in .docset", *flag_outDir)
+		}
+		if *flag_split != "" || len(flag_section) > 0 {
+			return style, fmt.Errorf("Cannot use -split or -section with -format=docset")
+		}
+		// Checked before anything is written, rather than once the pages are
+		if _, err := exec.LookPath("sqlite3"); err != nil {
+			return style, fmt.Errorf("Cannot use -format=docset without the sqlite3 command, which writes its search index: %v", err)
+		}
+	case "mrkdwn", "discord":
+		if *flag_outDir != "" {
+			return style, fmt.Errorf("Cannot use -format=%s with -out-dir", *flag_format)
+		}
+	default:
+		return style, fmt.Errorf("Invalid -format \"%s\": expected markdown, docset, mrkdwn, or discord", *flag_format)
+	}
+	style.Format = *flag_format
+	style.OutDir = *flag_outDir
+	style.PostProcess = *flag_postProcess
+	if *flag_searchIndex && *flag_outDir == "" {
+		return style, fmt.Errorf("Cannot use -search-index without -out-dir")
+	}
+	style.SearchIndex = *flag_searchIndex
+	for _, navigation := range strings.Split(*flag_navigation, ",") {
+		switch navigation = strings.TrimSpace(navigation); navigation {
+		case "":
+		case "breadcrumbs":
+			style.Breadcrumbs = true
+		case "pages":
+			style.PageLinks = true
+		default:
+			return style, fmt.Errorf("Invalid -navigation \"%s\": expected a list of breadcrumbs and pages", *flag_navigation)
+		}
+	}
+	switch *flag_asciiTables {
+	case "markdown", "pre":
+		style.ASCIITables = *flag_asciiTables
+	default:
+		return style, fmt.Errorf("Invalid -ascii-tables: %s", *flag_asciiTables)
+	}
+	style.Plugins = flag_plugin
+	for _, value := range flag_filter {
+		filter, err := parseFilter(value)
+		if err != nil {
+			return style, err
+		}
+		style.Filters = append(style.Filters, filter)
+	}
+	for _, weight := range flag_weight {
+		symbol, value, _ := strings.Cut(weight, "=")
+		number, err := strconv.Atoi(value)
+		if symbol == "" || err != nil {
+			return style, fmt.Errorf("Invalid -weight \"%s\": expected a symbol and a number, like Client=10", weight)
+		}
+		if style.Weights == nil {
+			style.Weights = map[string]int{}
+		}
+		style.Weights[symbol] = number
+	}
+	style.NumberedHeadings = *flag_numberedHeadings
+	switch *flag_headingStyle {
+	case "atx", "setext":
+		style.HeadingStyle = *flag_headingStyle
+	default:
+		return style, fmt.Errorf("Invalid -heading-style: %s", *flag_headingStyle)
+	}
+	switch *flag_split {
+	case "", "type":
+		style.Split = *flag_split
+	default:
+		return style, fmt.Errorf("Invalid -split: %s", *flag_split)
+	}
+	if style.Split != "" && (flag_output == "" || flag_output == "-") && *flag_outDir == "" {
+		return style, fmt.Errorf("Cannot use -split without -output or -out-dir")
+	}
+	for _, section := range flag_section {
+		heading, page, _ := strings.Cut(section, "=")
+		heading = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(heading), "#"))
+		if heading == "" {
+			return style, fmt.Errorf("Invalid -section \"%s\": expected a heading, like FAQ, or a heading and a page, like FAQ=FAQ.md", section)
+		}
+		if style.Sections == nil {
+			style.Sections = map[string]string{}
+		}
+		style.Sections[heading] = filepath.Clean(page)
+		if page == "" {
+			style.Sections[heading] = ""
+		}
+	}
+	style.IncludeImports = *flag_imports
+	style.IncludeEmbeds = *flag_embeds
+	style.IncludeStats = *flag_stats
+	style.IncludeStability = *flag_stabilitySummary
+	style.Quickstart = *flag_quickstart
+	if *flag_tutorial != "" && (flag_output == "" || flag_output == "-") && *flag_outDir == "" {
+		return style, fmt.Errorf("Cannot use -tutorial without -output or -out-dir")
+	}
+	style.Tutorial = filepath.Clean(*flag_tutorial)
+	if *flag_tutorial == "" {
+		style.Tutorial = ""
+	}
+	style.RunBenchmarks = *flag_runBenchmarks
+	style.AnnotateChanges = *flag_annotateChanges
+	style.UsedBy = *flag_usedBy
+	style.Compact = *flag_compact
+	style.TestCoverage = *flag_testCoverage
+	switch *flag_checkLinks {
+	case "off", "warn", "strict":
+	default:
+		return style, fmt.Errorf("Invalid -check-links \"%s\": expected off, warn, or strict", *flag_checkLinks)
+	}
+	for _, value := range flag_platforms {
+		for _, platform := range strings.Split(value, ",") {
+			platform = strings.TrimSpace(platform)
+			goos, goarch, ok := strings.Cut(platform, "/")
+			if goos == "" || (ok && goarch == "") {
+				return style, fmt.Errorf("Invalid -platforms \"%s\": expected a list like linux,darwin,windows/arm64", value)
+			}
+			style.Platforms = append(style.Platforms, platform)
+		}
+	}
+	style.BenchmarkPattern = *flag_bench
+	style.BenchmarkTime = *flag_benchtime
+	switch *flag_methodOrder {
+	case "name", "receiver":
+		style.MethodOrder = *flag_methodOrder
+	default:
+		return style, fmt.Errorf("Invalid -method-order \"%s\": expected name or receiver", *flag_methodOrder)
+	}
+	switch *flag_exampleOrder {
+	case "name", "source":
+		style.ExampleOrder = *flag_exampleOrder
+	default:
+		return style, fmt.Errorf("Invalid -example-order \"%s\": expected name or source", *flag_exampleOrder)
+	}
+	style.PinnedExamples = flag_pinExample
+	style.ConfigStructs = flag_configStruct
+	style.ExamplePrograms = *flag_examplePrograms
+	for _, cleanup := range strings.Split(*flag_exampleCode, ",") {
+		switch cleanup = strings.TrimSpace(cleanup); cleanup {
+		case "":
+		case "comments", "unwrap", "gofmt":
+			style.ExampleCode = append(style.ExampleCode, cleanup)
+		default:
+			return style, fmt.Errorf("Invalid -example-code \"%s\": expected a list of comments, unwrap, and gofmt", *flag_exampleCode)
+		}
+	}
+	if *flag_exampleFilter != "" {
+		pattern, skip := strings.CutPrefix(*flag_exampleFilter, "!")
+		filter, err := regexp.Compile(pattern)
+		if err != nil {
+			return style, fmt.Errorf("Invalid -example-filter \"%s\": %v", *flag_exampleFilter, err)
+		}
+		style.ExampleFilter = filter
+		style.SkipExamples = skip
+	}
+	switch *flag_warnings {
+	case "off", "log", "github":
+	default:
+		return style, fmt.Errorf("Invalid -warnings \"%s\": expected off, log, or github", *flag_warnings)
+	}
+	switch *flag_exampleIndex {
+	case "flat", "grouped", "off":
+		style.ExampleIndex = *flag_exampleIndex
+	default:
+		return style, fmt.Errorf("Invalid -example-index \"%s\": expected flat, grouped, or off", *flag_exampleIndex)
+	}
+	style.ExampleOutputLanguage = *flag_exampleOutputLang
+	style.CodeLanguage = *flag_codeLang
+	switch *flag_qualify {
+	case "source", "full", "short":
+		style.Qualify = *flag_qualify
+	default:
+		return style, fmt.Errorf("Invalid -qualify \"%s\": expected source, full, or short", *flag_qualify)
+	}
+	style.WrapParams = *flag_wrapParams
+	style.Wrap = *flag_wrap
+	switch *flag_indent {
+	case "tabs":
+		style.Printer.Mode = printer.TabIndent | printer.UseSpaces
+	case "spaces":
+		style.Printer.Mode = printer.UseSpaces
+	default:
+		return style, fmt.Errorf("Invalid -indent \"%s\": expected tabs or spaces", *flag_indent)
+	}
+	if *flag_tabWidth < 1 {
+		return style, fmt.Errorf("Invalid -tab-width %d: expected at least 1", *flag_tabWidth)
+	}
+	style.Printer.Tabwidth = *flag_tabWidth
+	if *flag_maxWidth < 1 {
+		return style, fmt.Errorf("Invalid -max-width %d: expected at least 1", *flag_maxWidth)
+	}
+	style.Printer.Width = *flag_maxWidth
+	if *flag_translations != "" && !strings.Contains(*flag_translations, localePlaceholder) {
+		strings, err := loadTranslations(*flag_translations)
+		if err != nil {
+			return style, err
+		}
+		style.Strings = strings
+	}
+	switch *flag_html {
+	case "pass", "escape", "sanitize":
+		style.HTML = *flag_html
+	default:
+		return style, fmt.Errorf("Invalid -html \"%s\": expected pass, escape, or sanitize", *flag_html)
+	}
+	switch *flag_emoji {
+	case "pass", "escape", "convert":
+		style.Emoji = *flag_emoji
+	default:
+		return style, fmt.Errorf("Invalid -emoji \"%s\": expected pass, escape, or convert", *flag_emoji)
+	}
+	switch *flag_entities {
+	case "pass", "escape", "convert":
+		style.Entities = *flag_entities
+	default:
+		return style, fmt.Errorf("Invalid -entities \"%s\": expected pass, escape, or convert", *flag_entities)
+	}
+	switch header := *flag_indexHeader; {
+	case header == "":
+	case header == "-":
+		style.UsageHeader = ""
+	case strings.HasPrefix(header, "#"):
+		style.UsageHeader = header + "\n"
+	default:
+		// Keep the level of the style's heading
+		level, _, _ := strings.Cut(style.UsageHeader, " ")
+		style.UsageHeader = level + " " + header + "\n"
+	}
+	for _, inline := range []struct {
+		name     string
+		text     string
+		template **Template.Template
+	}{
+		{"title", *flag_title, &style.Title},
+		{"header", *flag_header, &style.Header},
+		{"footer", *flag_footer, &style.Footer},
+	} {
+		switch inline.text {
+		case "":
+			continue
+		case "-":
+			*inline.template = nil
+			continue
+		}
+		template, err := parseInline(inline.name, inline.text)
+		if err != nil {
+			return style, err
+		}
+		*inline.template = template
+	}
+	if *flag_footerTemplate != "" {
+		if *flag_footer != "" {
+			return style, fmt.Errorf("Cannot use both -footer and -footer-template")
+		}
+		text, err := os.ReadFile(*flag_footerTemplate)
+		if err != nil {
+			return style, fmt.Errorf("Could not read -footer-template: %v", err)
+		}
+		style.Footer, err = parseInline("footer-template", string(text))
+		if err != nil {
+			return style, err
+		}
+	}
+	if *flag_signature && style.Footer == nil {
+		logger.Warn("-signature is deprecated: use -footer instead")
+		style.Footer = signatureFooter
+	}
+	if *flag_exampleTitle != "" {
+		title, err := Template.New("example-title").Parse(*flag_exampleTitle)
+		if err != nil {
+			return style, fmt.Errorf("Could not parse -example-title: %v", err)
+		}
+		style.ExampleTitle = title
+	}
+	style.KeepDirectives = *flag_keepDirectives
+	style.UnexportedFields = *flag_unexportedFields
+	style.Dirs = DirFilter{
+		IncludeInternal: *flag_includeInternal,
+		Skip:            flag_skipDir,
+		Exclude:         flag_excludeDir,
+	}
+	for _, pattern := range flag_excludeDir {
+		if !validGlob(pattern) {
+			return style, fmt.Errorf("Invalid -exclude-dir pattern: %s", pattern)
+		}
+	}
+	style.RepositoryURL = strings.TrimSuffix(*flag_repositoryURL, "/")
+	if *flag_repositoryRef != "" {
+		style.RepositoryRef = *flag_repositoryRef
+	}
+
+	switch *flag_heading {
+	case "1Word":
+		style.SynopsisHeading = synopsisHeading1Word_Regexp
+	case "TitleCase":
+		style.SynopsisHeading = synopsisHeadingTitleCase_Regexp
+	case "Title":
+		style.SynopsisHeading = synopsisHeadingTitle_Regexp
+	case "TitleCase1Word":
+		style.SynopsisHeading = synopsisHeadingTitleCase1Word_Regexp
+	case "", "-":
+		style.SynopsisHeading = nil
+	}
+	style.Logger = logger
+	style.listed = listed
+	return style, nil
+}
+
+// renderDocumentTo renders the document, through its template if one is
+// present, streaming the final output to writer, unless ctx is done
+func renderDocumentTo(ctx context.Context, writer io.Writer, document *Document) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if document.style.NoFuncs {
+		document.pkg = withoutFuncs(document.pkg)
+	}
+
+	if document.style.Format == "mrkdwn" || document.style.Format == "discord" {
+		return renderAnnouncementTo(writer, document)
+	}
+
+	tpl, templatePath, err := loadTemplate(document)
+	if err != nil {
+		return err
+	}
+
+	output, err := newOutputWriter(writer, document.style.EOL, document.style.FinalNewline)
+	if err != nil {
+		return err
+	}
+	if document.pluginMarkdown != nil {
+		_, err := io.WriteString(output, *document.pluginMarkdown)
+		if err != nil {
+			return err
+		}
+		return output.Close()
+	}
+	headings := newHeadingWriter(output, document.style)
+	inner := io.Writer(headings)
+	var compact *compactWriter
+	if document.style.Compact {
+		compact = newCompactWriter(headings)
+		inner = compact
+	}
+	renderBreadcrumbsTo(inner, document, document.page, "")
+	body := newTrimWriter(inner)
+	if tpl == nil {
+		document.EmitTo(body)
+	} else {
+		err := tpl.Templates()[0].Execute(body, document)
+		if err != nil {
+			return templateError(templatePath, true, err)
+		}
+	}
+	body.Close()
+	if compact != nil {
+		err = compact.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if links := emitString(func(writer io.Writer) { renderPageLinksTo(writer, document, document.page) }); links != "" {
+		fmt.Fprintf(headings, "\n\n%s", links)
+	}
+	if !document.footerEmitted {
+		document.EmitFooterTo(headings)
+	}
+	err = headings.Close()
+	if err != nil {
+		return err
+	}
+	return output.Close()
+}
+
+// Main runs the godocdown command with the arguments of the process (see
+// the documentation of the command), and exits when it is done
+func Main() {
+	if len(os.Args) > 1 {
+		if command, ok := subcommands()[os.Args[1]]; ok {
+			os.Exit(command.Run(os.Args[2:]))
+		}
+	}
+
+	flag.Parse(os.Args[1:])
+
+	err := loadConfig(flag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+
+	err = setupLogger(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+
+	if *flag_schema {
+		err := writeTemplateSchema(os.Stdout)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	cache, err := openCache()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	style, err := buildStyle()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(2)
+	}
+
+	locales, err := localeStyles(style)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(2)
+	}
+
+	err = buildOutputs()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(2)
+	}
+
+	// Remove what was extracted from archives
+	exit := func(status int) {
+		removeTempDirs()
+		os.Exit(status)
+	}
+
+	targets, err := expandTargets(flag.Args(), style.Dirs)
+	if *flag_goList {
+		if flag.NArg() > 0 || *flag_recursive {
+			logger.Error("Cannot use -go-list with packages to document, or -recursive")
+			exit(2)
+		}
+		targets, err = readListed(os.Stdin, style.Dirs)
+	}
+	if *flag_recursive && err == nil {
+		if len(targets) == 0 && flag.NArg() == 0 {
+			targets = []string{"."}
+		}
+		targets, err = recursiveTargets(targets, style.Dirs)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		exit(1)
+	}
+	ctx, stop := runContext()
+	defer stop()
+	if *flag_tui {
+		if len(targets) > 1 {
+			logger.Error("Cannot use -tui with more than one package")
+			exit(2)
+		}
+		target := "."
+		if len(targets) > 0 {
+			target = targets[0]
+		}
+		if err := runTUI(ctx, target, style); err != nil {
+			logger.Error(err.Error(), "target", target)
+			exit(1)
+		}
+		exit(0)
+	}
+	if len(targets) > 1 || (*flag_outDir != "" && len(targets) > 0) {
+		status := generateAll(ctx, targets, style, cache)
+		if locales := generateLocales(ctx, targets, locales, cache); locales > status {
+			status = locales
+		}
+		exit(status)
+	}
+
+	target := ""
+	if len(targets) > 0 {
+		target = targets[0]
+	} else if flag.NArg() > 0 {
+		logger.Error("No packages to document")
+		exit(1)
+	}
+	fallbackUsage := false
+	if target == "" {
+		fallbackUsage = true
+		target = "."
+	}
+
+	stdout := io.Writer(os.Stdout)
+	if debug {
+		// Skip printing if we're debugging
+		stdout = ioutil.Discard
+	}
+
+	start := Time.Now()
+	single := &job{target: target, path: flag_output, stdout: stdout}
+	single.run(ctx, style, cache)
+	if err := writeReport([]*job{single}, Time.Since(start)); err != nil {
+		logger.Error(err.Error())
+		exit(1)
+	}
+	if err := writeCoverageBadge([]*job{single}); err != nil {
+		logger.Error(err.Error())
+		exit(1)
+	}
+	if err := writeSummary([]*job{single}); err != nil {
+		logger.Error(err.Error())
+		exit(1)
+	}
+	emitWarnings(os.Stderr, []*job{single})
+	if single.err != nil {
+		logger.Error(single.err.Error(), "target", single.target)
+		// Nothing found.
+		if fallbackUsage && errors.Is(single.err, ErrNoPackage) {
+			usage()
+			exit(2)
+		}
+		exit(1)
+	}
+	if checkPageLinks([]*job{single}) != 0 {
+		exit(1)
+	}
+	exit(generateLocales(ctx, []string{target}, locales, cache))
+}
@@ -0,0 +1,126 @@
+package docdown
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExternalTestExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":           "module example.com/tp\n",
+		"tp.go":            "// Package tp is a test package.\npackage tp\n\n// Greet says hello.\nfunc Greet() string { return \"hello\" }\n",
+		"tp_test.go":       "package tp\n\nfunc ExampleGreet() {\n\tGreet()\n}\n",
+		"external_test.go": "package tp_test\n\nimport \"example.com/tp\"\n\nfunc Example() {\n\ttp.Greet()\n}\n\nfunc ExampleGreet_loud() {\n\ttp.Greet()\n}\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, example := range document.Examples {
+		names = append(names, example.Name)
+	}
+	if strings.Join(names, " ") != " Greet Greet_loud" {
+		t.Errorf("examples are %q, expected the package, Greet and Greet_loud", names)
+	}
+	output, err := document.Render(DefaultStyle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"tp.Greet()", "Greet_loud"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("the examples of the external test package are missing %q:\n%s", expected, output)
+		}
+	}
+}
+
+func TestLibraryOverMain(t *testing.T) {
+	for _, library := range []string{"alib", "zlib"} {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"go.mod":      "module example.com/" + library + "\n",
+			"gen.go":      "//go:build ignore\n\n// Gen generates the tables.\npackage main\n\nfunc main() {}\n",
+			"lib.go":      "// Package " + library + " sorts.\npackage " + library + "\n\n// Sort sorts.\nfunc Sort() {}\n",
+			"lib_test.go": "package " + library + "_test\n\nfunc ExampleSort() {}\n",
+		})
+		document, err := Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if document.IsCommand || document.Name != library || len(document.Examples) != 1 {
+			t.Errorf("%s: documented %s (command %v) with %d examples, expected %s with 1", library, document.Name, document.IsCommand, len(document.Examples), library)
+		}
+		output, err := document.Render(DefaultStyle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{"import \"example.com/" + library + "\"", "func Sort()", "#ExampleSort"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%s: the output is missing %q:\n%s", library, expected, output)
+			}
+		}
+	}
+}
+
+func TestBuildStyleWarnings(t *testing.T) {
+	warnings := *flag_warnings
+	defer func() { *flag_warnings = warnings }()
+	for value, valid := range map[string]bool{"off": true, "log": true, "github": true, "githb": false} {
+		*flag_warnings = value
+		if _, err := buildStyle(); (err == nil) != valid {
+			t.Errorf("-warnings=%s: the error is %v", value, err)
+		}
+	}
+}
+
+func TestBuildStyleDocset(t *testing.T) {
+	format, outDir := *flag_format, *flag_outDir
+	defer func() { *flag_format, *flag_outDir = format, outDir }()
+	*flag_format, *flag_outDir = "docset", filepath.Join(t.TempDir(), "Client.docset")
+
+	// Without sqlite3, before the pages are written
+	t.Setenv("PATH", t.TempDir())
+	if _, err := buildStyle(); err == nil || !strings.Contains(err.Error(), "sqlite3") {
+		t.Errorf("-format=docset without sqlite3 is %v, expected an error", err)
+	}
+}
+
+func TestSubcommandFlags(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		has, lacks []string
+	}{
+		{"hook", []string{"flavor", "v", "jobs", "cache", "fix"}, []string{"lint-disable", "tui", "pr-summary"}},
+		{"lint", []string{"flavor", "lint-disable", "warnings"}, []string{"jobs", "tui", "cache"}},
+		{"release-notes", []string{"flavor", "from", "to"}, []string{"jobs", "lint-disable", "tui"}},
+		{"init", []string{"flavor", "jobs", "tui", "force"}, nil},
+	} {
+		flags := subcommands()[test.name].Flags()
+		for _, name := range test.has {
+			if flags.Lookup(name) == nil {
+				t.Errorf("%s has no -%s", test.name, name)
+			}
+		}
+		for _, name := range test.lacks {
+			if flags.Lookup(name) != nil {
+				t.Errorf("%s has -%s", test.name, name)
+			}
+		}
+	}
+
+	// The configuration can still have the options the subcommand does not use
+	config := *flag_config
+	defer func() { *flag_config = config }()
+	*flag_config = filepath.Join(t.TempDir(), "godocdown.yaml")
+	for contents, valid := range map[string]bool{"jobs: 4\nlint-disable: []\n": true, "nonsense: 1\n": false} {
+		if err := os.WriteFile(*flag_config, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := loadConfig(lintFlags()); (err == nil) != valid {
+			t.Errorf("loading %q for lint: the error is %v", contents, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package docdown
+
+import (
+	"fmt"
+	"go/doc"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isConstructor reports whether the function named name looks like a
+// constructor: NewClient, New, MustParse
+func isConstructor(name string) bool {
+	for _, prefix := range []string{"New", "Must"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			next, _ := utf8.DecodeRuneInString(rest)
+			return rest == "" || !unicode.IsLower(next)
+		}
+	}
+	return false
+}
+
+// pointerMethod reports whether a method has a pointer receiver
+func pointerMethod(entry *doc.Func) bool {
+	return strings.HasPrefix(entry.Recv, "*")
+}
+
+// orderMethods puts, with -method-order=receiver, the constructors of each
+// type before its other functions, and its methods with a value receiver
+// before those with a pointer receiver, keeping the order of each
+func (self *Document) orderMethods() {
+	if self.style.MethodOrder != "receiver" {
+		return
+	}
+	for _, entry := range self.pkg.Types {
+		funcs, methods := entry.Funcs, entry.Methods
+		sort.SliceStable(funcs, func(i, j int) bool {
+			return isConstructor(funcs[i].Name) && !isConstructor(funcs[j].Name)
+		})
+		sort.SliceStable(methods, func(i, j int) bool {
+			return !pointerMethod(methods[i]) && pointerMethod(methods[j])
+		})
+	}
+}
+
+// renderMethodsTo writes the methods of a type, and with
+// -method-order=receiver, when it has methods of both kinds, a line before
+// those with a value receiver and before those with a pointer receiver
+func renderMethodsTo(writer io.Writer, document *Document, entry *doc.Type) {
+	methods := entry.Methods
+	split := sort.Search(len(methods), func(i int) bool {
+		return pointerMethod(methods[i])
+	})
+	if document.style.MethodOrder != "receiver" || split == 0 || split == len(methods) {
+		renderFunctionSectionTo(writer, document, methods, true, nil)
+		return
+	}
+	fmt.Fprintf(writer, "%s `%s`:\n\n", document.style.text("Methods on"), entry.Name)
+	renderFunctionSectionTo(writer, document, methods[:split], true, nil)
+	fmt.Fprintf(writer, "%s `*%s`:\n\n", document.style.text("Methods on"), entry.Name)
+	renderFunctionSectionTo(writer, document, methods[split:], true, nil)
+}
@@ -0,0 +1,125 @@
+package docdown
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// navigationPage is a page of the documentation, as the links between pages
+// go to it
+type navigationPage struct {
+	title string
+	path  string
+}
+
+// pageSequence is the order -navigation=pages goes through the pages in:
+// the page of the previous package, the pages of this one (its own, then
+// those of its sections, its tutorial, and its types), and the page of the
+// next package.
+// The packages are in the order of their import paths, as in the index.
+func (self *Document) pageSequence() []navigationPage {
+	dir := filepath.Dir(self.page)
+	var pages []navigationPage
+
+	var importPaths []string
+	for importPath := range self.links {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	position := sort.SearchStrings(importPaths, self.ImportPath)
+	found := position < len(importPaths) && importPaths[position] == self.ImportPath
+	if found && position > 0 {
+		previous := importPaths[position-1]
+		pages = append(pages, navigationPage{path.Base(previous), self.links[previous]})
+	}
+
+	pages = append(pages, navigationPage{self.Name, self.page})
+	_, sections := self.packageSections()
+	for _, section := range sections {
+		if section.Page != "" {
+			pages = append(pages, navigationPage{section.Heading, filepath.Join(dir, section.Page)})
+		}
+	}
+	if page := self.tutorialPage(self.page); page != "" {
+		pages = append(pages, navigationPage{self.style.text("Tutorial"), page})
+	}
+	for _, entry := range self.pkg.Types {
+		if page, ok := self.split[entry.Name]; ok {
+			pages = append(pages, navigationPage{entry.Name, filepath.Join(dir, page)})
+		}
+	}
+
+	if found && position+1 < len(importPaths) {
+		next := importPaths[position+1]
+		pages = append(pages, navigationPage{path.Base(next), self.links[next]})
+	}
+	return pages
+}
+
+// relativeLink is the link from the page at from to the one at to
+func relativeLink(from, to string) string {
+	from, err := filepath.Abs(from)
+	if err == nil {
+		to, err = filepath.Abs(to)
+	}
+	if err != nil {
+		return filepath.ToSlash(to)
+	}
+	link, err := filepath.Rel(filepath.Dir(from), to)
+	if err != nil {
+		return filepath.ToSlash(to)
+	}
+	return filepath.ToSlash(link)
+}
+
+// renderBreadcrumbsTo writes the trail of links at the top of the page at
+// from, with -navigation=breadcrumbs: the module (linked to the index of
+// the packages, if there is one), the package, and current, the title of
+// the page if it is not that of the package
+func renderBreadcrumbsTo(writer io.Writer, document *Document, from, current string) {
+	if !document.style.Breadcrumbs || from == "" {
+		return
+	}
+	var crumbs []string
+	if module := document.modulePath(); module != "" {
+		if index := indexPath(); len(document.links) > 1 && index != "" {
+			module = fmt.Sprintf("[%s](%s)", module, relativeLink(from, index))
+		}
+		crumbs = append(crumbs, module)
+	}
+	if current == "" {
+		crumbs = append(crumbs, document.Name)
+	} else {
+		crumbs = append(crumbs, fmt.Sprintf("[%s](%s)", document.Name, relativeLink(from, document.page)), current)
+	}
+	fmt.Fprintf(writer, "%s\n\n", strings.Join(crumbs, " › "))
+}
+
+// renderPageLinksTo writes the links to the previous and the next page (see
+// pageSequence) at the bottom of the page at from, with -navigation=pages
+func renderPageLinksTo(writer io.Writer, document *Document, from string) {
+	if !document.style.PageLinks || from == "" {
+		return
+	}
+	pages := document.pageSequence()
+	for i, page := range pages {
+		if page.path != from {
+			continue
+		}
+		var links []string
+		if i > 0 {
+			links = append(links, fmt.Sprintf("[← %s](%s)", pages[i-1].title, relativeLink(from, pages[i-1].path)))
+		}
+		if i+1 < len(pages) {
+			links = append(links, fmt.Sprintf("[%s →](%s)", pages[i+1].title, relativeLink(from, pages[i+1].path)))
+		}
+		if len(links) > 0 {
+			fmt.Fprintf(writer, "%s\n\n", strings.Join(links, " · "))
+		}
+		return
+	}
+}
@@ -0,0 +1,148 @@
+package docdown
+
+import (
+	Flag "flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// outputPaths returns where the documentation for each of targets is
+// written: "" for stdout, the -output of each package's directory, or with
+// -out-dir, a page in a tree under that directory that follows the import
+// paths of the packages
+func outputPaths(targets []string) ([]string, error) {
+	paths := make([]string, len(targets))
+	if *flag_outDir != "" {
+		return outDirPaths(targets)
+	}
+	if flag_output == "" || flag_output == "-" {
+		return paths, nil
+	}
+	if len(targets) == 1 {
+		paths[0] = flag_output
+		return paths, nil
+	}
+	if filepath.IsAbs(flag_output) {
+		return nil, fmt.Errorf("Cannot write %d packages to the same output: %s", len(targets), flag_output)
+	}
+	for i, target := range targets {
+		absPath, err := filepath.Abs(target)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = filepath.Join(absPath, flag_output)
+	}
+	return paths, nil
+}
+
+// outDirPaths places each package at the path of its import path below the
+// import path the packages have in common, so documenting ./... from the
+// root of a module puts the root package at the top of -out-dir
+func outDirPaths(targets []string) ([]string, error) {
+	page := flag_output
+	if page == "" || page == "-" {
+		page = "README.md"
+		if *flag_format == "docset" {
+			page = "index.html"
+		}
+	}
+	if strings.ContainsAny(page, `/\`) {
+		return nil, fmt.Errorf("Invalid -output with -out-dir, which should be a file name like README.md: %s", page)
+	}
+
+	importPaths := make([]string, len(targets))
+	for i, target := range targets {
+		importPath, absPath, err := lookupImport(target)
+		if err != nil {
+			return nil, err
+		}
+		if importPath == "" {
+			importPath = filepath.Base(absPath)
+		}
+		importPaths[i] = importPath
+	}
+	common := commonPath(importPaths)
+	outDir, err := filepath.Abs(pagesDir())
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(targets))
+	seen := map[string]string{}
+	for i, importPath := range importPaths {
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, common), "/")
+		paths[i] = filepath.Join(outDir, filepath.FromSlash(rel), page)
+		if other, ok := seen[paths[i]]; ok {
+			return nil, fmt.Errorf("Cannot write both %s and %s to %s", other, targets[i], paths[i])
+		}
+		seen[paths[i]] = targets[i]
+	}
+	return paths, nil
+}
+
+// commonPath is the longest path (in whole elements) that every one of
+// importPaths starts with, or "" if there is none or just one import path
+// in a directory of its own
+func commonPath(importPaths []string) string {
+	if len(importPaths) == 0 {
+		return ""
+	}
+	common := strings.Split(importPaths[0], "/")
+	if len(importPaths) == 1 {
+		return importPaths[0]
+	}
+	for _, importPath := range importPaths[1:] {
+		elements := strings.Split(importPath, "/")
+		n := 0
+		for n < len(common) && n < len(elements) && common[n] == elements[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return path.Join(common...)
+}
+
+// pagesDir is the directory the pages of -out-dir go in: -out-dir itself,
+// or with -format=docset, the directory of the documents within it
+func pagesDir() string {
+	if *flag_format == "docset" {
+		return docsetDocuments(*flag_outDir)
+	}
+	return *flag_outDir
+}
+
+// indexPath is where the -index page goes: index.md in the -out-dir (or
+// index.html in a docset), unless -index is given
+func indexPath() string {
+	if *flag_outDir == "" {
+		return *flag_index
+	}
+	given := false
+	flag.Visit(func(f *Flag.Flag) {
+		given = given || f.Name == "index"
+	})
+	if given {
+		return *flag_index
+	}
+	if *flag_format == "docset" {
+		return filepath.Join(pagesDir(), "index.html")
+	}
+	return filepath.Join(*flag_outDir, "index.md")
+}
+
+// makeOutputDirs creates the directories the pages of -out-dir go in
+func makeOutputDirs(paths []string) error {
+	if *flag_outDir == "" {
+		return nil
+	}
+	for _, path := range paths {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
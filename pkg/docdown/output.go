@@ -0,0 +1,439 @@
+package docdown
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// trimWriter passes writes through with leading and trailing whitespace
+// removed. Trailing whitespace is held back until more text arrives, so
+// whatever is pending when the writer is closed is dropped. Only the pending
+// whitespace is ever buffered.
+type trimWriter struct {
+	writer  io.Writer
+	started bool
+	pending []byte
+}
+
+func newTrimWriter(writer io.Writer) *trimWriter {
+	return &trimWriter{writer: writer}
+}
+
+func (self *trimWriter) Write(p []byte) (int, error) {
+	text := p
+	if !self.started {
+		text = bytes.TrimLeft(text, " \t\r\n")
+		if len(text) == 0 {
+			return len(p), nil
+		}
+		self.started = true
+	}
+
+	end := len(bytes.TrimRight(text, " \t\r\n"))
+	if end == 0 {
+		self.pending = append(self.pending, text...)
+		return len(p), nil
+	}
+	if len(self.pending) > 0 {
+		_, err := self.writer.Write(self.pending)
+		if err != nil {
+			return 0, err
+		}
+		self.pending = self.pending[:0]
+	}
+	_, err := self.writer.Write(text[:end])
+	if err != nil {
+		return 0, err
+	}
+	self.pending = append(self.pending, text[end:]...)
+	return len(p), nil
+}
+
+// Close drops any trailing whitespace. It does not close the underlying
+// writer.
+func (self *trimWriter) Close() error {
+	self.pending = self.pending[:0]
+	return nil
+}
+
+// lineEndingWriter normalizes line endings to either "\n" or "\r\n"
+type lineEndingWriter struct {
+	writer io.Writer
+	crlf   bool
+	cr     bool // A "\r" ended the previous write
+}
+
+func (self *lineEndingWriter) Write(p []byte) (int, error) {
+	var buffer bytes.Buffer
+	for _, c := range p {
+		if self.cr && c != '\n' {
+			buffer.WriteByte('\r')
+		}
+		self.cr = c == '\r'
+		switch {
+		case self.cr:
+		case c == '\n' && self.crlf:
+			buffer.WriteString("\r\n")
+		default:
+			buffer.WriteByte(c)
+		}
+	}
+	_, err := self.writer.Write(buffer.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// headingWriter rewrites the headings written through it: with
+// -numbered-headings, every heading but the title (the first heading, at
+// level 1) is numbered like 2.1.3, those of the package comment too, and
+// with -heading-style=setext, level 1 and 2 headings are underlined
+// ("Title\n=====") rather than written with "#". Code blocks are left
+// alone. It buffers at most a line.
+type headingWriter struct {
+	writer   io.Writer
+	setext   bool
+	numbered bool
+	line     []byte
+	fence    string // The fence of the code block the line is in, if any
+	headings int    // The number of headings so far
+
+	// counts are the numbers of the last heading at each level, counted
+	// since the last heading above it, and used the levels with headings
+	counts [7]int
+	used   [7]bool
+}
+
+func newHeadingWriter(writer io.Writer, style Style) *headingWriter {
+	return &headingWriter{
+		writer:   writer,
+		setext:   style.HeadingStyle == "setext",
+		numbered: style.NumberedHeadings,
+	}
+}
+
+func (self *headingWriter) Write(p []byte) (int, error) {
+	if !self.setext && !self.numbered {
+		return self.writer.Write(p)
+	}
+	rest := p
+	for {
+		newline := bytes.IndexByte(rest, '\n')
+		if newline < 0 {
+			self.line = append(self.line, rest...)
+			return len(p), nil
+		}
+		self.line = append(self.line, rest[:newline+1]...)
+		rest = rest[newline+1:]
+		err := self.flush()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// flush writes the buffered line, as a setext heading if it is a level 1
+// or 2 ATX heading outside of a code block
+func (self *headingWriter) flush() error {
+	line := string(self.line)
+	self.line = self.line[:0]
+	text := strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimLeft(text, " ")
+	switch {
+	case self.fence != "":
+		if strings.HasPrefix(trimmed, self.fence) {
+			self.fence = ""
+		}
+	case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+		self.fence = trimmed[:3]
+	case len(text)-len(trimmed) < 4:
+		heading := atxHeading_Regexp.FindStringSubmatch(trimmed)
+		if heading == nil {
+			break
+		}
+		level, title := len(heading[1]), heading[2]
+		self.headings++
+		if self.numbered && (level > 1 || self.headings > 1) {
+			title = self.number(level) + " " + title
+		}
+		if self.setext && level <= 2 {
+			underline := "="
+			if level == 2 {
+				underline = "-"
+			}
+			// "1. Title" on a line of its own would start a list
+			title = listItem_Regexp.ReplaceAllString(title, `$1\.`)
+			line = title + "\n" + strings.Repeat(underline, utf8.RuneCountInString(title)) + line[len(text):]
+		} else {
+			line = heading[1] + " " + title + line[len(text):]
+		}
+	}
+	_, err := io.WriteString(self.writer, line)
+	return err
+}
+
+// number counts a heading at level and returns its number, with a part for
+// each level down to its own that has had a heading: "2." for one at the
+// top, and "2.1" or "2.1.3" for those at the levels below it, so that the
+// headings of a level are numbered alike however deep the page starts
+func (self *headingWriter) number(level int) string {
+	self.used[level] = true
+	self.counts[level]++
+	for deeper := level + 1; deeper < len(self.counts); deeper++ {
+		self.counts[deeper] = 0
+	}
+	var parts []string
+	for above := 1; above <= level; above++ {
+		if self.used[above] {
+			parts = append(parts, strconv.Itoa(self.counts[above]))
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0] + "."
+	}
+	return strings.Join(parts, ".")
+}
+
+// listItem_Regexp matches text that starts an ordered list
+var listItem_Regexp = regexp.MustCompile(`^(\d+)\.`)
+
+// atxHeading_Regexp matches an ATX heading with some text, without the
+// optional closing "#"s
+var atxHeading_Regexp = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?[^ \t#].*?)(?:[ \t]+#+)?[ \t]*$`)
+
+// Close writes the last line, if it was not ended. It does not close the
+// underlying writer.
+func (self *headingWriter) Close() error {
+	if len(self.line) == 0 {
+		return nil
+	}
+	return self.flush()
+}
+
+// outputWriter applies the output formatting options (-eol, -final-newline)
+// to everything written through it
+type outputWriter struct {
+	*trimWriter
+	lineEnding   *lineEndingWriter
+	finalNewline bool
+}
+
+func newOutputWriter(writer io.Writer, eol string, finalNewline bool) (*outputWriter, error) {
+	lineEnding := &lineEndingWriter{writer: writer}
+	switch eol {
+	case "", "lf":
+	case "crlf":
+		lineEnding.crlf = true
+	default:
+		return nil, fmt.Errorf("Invalid line ending \"%s\": expected lf or crlf", eol)
+	}
+	return &outputWriter{
+		trimWriter:   newTrimWriter(lineEnding),
+		lineEnding:   lineEnding,
+		finalNewline: finalNewline,
+	}, nil
+}
+
+func (self *outputWriter) Close() error {
+	self.trimWriter.Close()
+	if self.finalNewline {
+		_, err := self.lineEnding.Write([]byte("\n"))
+		return err
+	}
+	return nil
+}
+
+// writeOutputTo calls fn with a writer for the given destination. An empty
+// path (or -) writes to stdout. Files are replaced only once fn succeeds.
+func writeOutputTo(path string, stdout io.Writer, fn func(io.Writer) error) error {
+	if path == "" || path == "-" {
+		buffer := bufio.NewWriter(stdout)
+		err := fn(buffer)
+		if err != nil {
+			return err
+		}
+		err = buffer.Flush()
+		if err == nil {
+			logger.Debug("wrote output", "path", "-")
+		}
+		return err
+	}
+
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		// Replacing a symlink, device, or pipe (e.g. /dev/stdout) would
+		// replace the file itself rather than write to what it refers to
+		return writeSpecialTo(path, fn)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("Error writing output: %v", err)
+	}
+	buffer := bufio.NewWriter(file)
+	err = fn(buffer)
+	if err == nil {
+		err = buffer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		mode := os.FileMode(0644)
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		err = os.Chmod(file.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("Error writing output: %v", err)
+	}
+	logger.Debug("wrote output", "path", path)
+	return nil
+}
+
+// shellCommand runs command with the shell of the platform, killing it if
+// ctx is canceled
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// postProcess pipes documentation through the -post-process command,
+// returning what it writes. The command learns which page it is processing
+// from GODOCDOWN_OUTPUT (the path of the page, or "" for stdout).
+func postProcess(ctx context.Context, command, path string, documentation []byte) ([]byte, error) {
+	process := shellCommand(ctx, command)
+	process.Stdin = bytes.NewReader(documentation)
+	process.Env = append(os.Environ(), "GODOCDOWN_OUTPUT="+path)
+	var stderr bytes.Buffer
+	process.Stderr = &stderr
+	output, err := process.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if ctx.Err() != nil {
+			message = canceled(ctx).Error()
+		} else if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("Error running -post-process \"%s\": %s", command, message)
+	}
+	return output, nil
+}
+
+// readmeStart and readmeEnd mark where the documentation goes in a file
+// with more than the documentation, like a README written by hand
+const (
+	readmeStart = "<!-- godocdown:start -->"
+	readmeEnd   = "<!-- godocdown:end -->"
+)
+
+// spliceDocumentation puts documentation between the markers of current,
+// replacing what is there, or reports that current has no markers
+func spliceDocumentation(current, documentation []byte) ([]byte, bool) {
+	start := bytes.Index(current, []byte(readmeStart))
+	if start < 0 {
+		return nil, false
+	}
+	start += len(readmeStart)
+	end := bytes.Index(current[start:], []byte(readmeEnd))
+	if end < 0 {
+		return nil, false
+	}
+	end += start
+	newline := "\n"
+	if bytes.Contains(documentation, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	var result bytes.Buffer
+	result.Write(current[:start])
+	result.WriteString(newline)
+	result.Write(documentation)
+	if len(documentation) > 0 && !bytes.HasSuffix(documentation, []byte("\n")) {
+		result.WriteString(newline)
+	}
+	result.Write(current[end:])
+	return result.Bytes(), true
+}
+
+// writeDocumentTo is writeOutputTo for documentation, which goes through
+// the -post-process command, if there is one, before it is written. A file
+// with the README markers keeps all but what is between them.
+func writeDocumentTo(ctx context.Context, style Style, path string, stdout io.Writer, fn func(io.Writer) error) error {
+	var current []byte
+	if path != "" && path != "-" {
+		if contents, err := os.ReadFile(path); err == nil && bytes.Contains(contents, []byte(readmeStart)) {
+			current = contents
+		}
+	}
+	page := style.Format == "docset" && strings.HasSuffix(path, ".html")
+	if style.PostProcess == "" && current == nil && !page {
+		return writeOutputTo(path, stdout, fn)
+	}
+	var documentation bytes.Buffer
+	err := fn(&documentation)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		path = ""
+	}
+	processed := documentation.Bytes()
+	if page {
+		// A page of a docset
+		processed = []byte(htmlPage(string(processed)))
+	}
+	if style.PostProcess != "" {
+		processed, err = postProcess(ctx, style.PostProcess, path, processed)
+		if err != nil {
+			return err
+		}
+	}
+	if spliced, ok := spliceDocumentation(current, processed); ok {
+		processed = spliced
+	}
+	return writeOutputTo(path, stdout, func(writer io.Writer) error {
+		_, err := writer.Write(processed)
+		return err
+	})
+}
+
+// writeSpecialTo writes to the file at path in place, for files that aren't
+// regular files
+func writeSpecialTo(path string, fn func(io.Writer) error) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("Error writing output: %v", err)
+	}
+	buffer := bufio.NewWriter(file)
+	err = fn(buffer)
+	if err == nil {
+		err = buffer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing output: %v", err)
+	}
+	logger.Debug("wrote output", "path", path)
+	return nil
+}
@@ -0,0 +1,202 @@
+package docdown
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeInChunks writes text through writer in chunks of size bytes, as
+// rendering does, in pieces that split lines (and line endings) anywhere
+func writeInChunks(t *testing.T, writer io.Writer, text string, size int) {
+	t.Helper()
+	for len(text) > 0 {
+		n := size
+		if n > len(text) {
+			n = len(text)
+		}
+		written, err := writer.Write([]byte(text[:n]))
+		if err != nil || written != n {
+			t.Fatalf("wrote %d of %d bytes: %v", written, n, err)
+		}
+		text = text[n:]
+	}
+}
+
+func TestTrimWriter(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{"", ""},
+		{" \n\t\n", ""},
+		{"text", "text"},
+		{"\n\n  # Title\n\nText.\n\n", "# Title\n\nText."},
+		{"a  \n\n  b\r\n", "a  \n\n  b"},
+	} {
+		for _, size := range []int{1, 2, 3, 1000} {
+			var output bytes.Buffer
+			writer := newTrimWriter(&output)
+			writeInChunks(t, writer, test.text, size)
+			writer.Close()
+			if output.String() != test.expected {
+				t.Errorf("%q in chunks of %d: wrote %q, expected %q", test.text, size, output.String(), test.expected)
+			}
+		}
+	}
+}
+
+func TestLineEndingWriter(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		crlf     bool
+		expected string
+	}{
+		{"a\nb\n", false, "a\nb\n"},
+		{"a\r\nb\r\n", false, "a\nb\n"},
+		{"a\nb\r\n", true, "a\r\nb\r\n"},
+		{"a\r\nb\n", true, "a\r\nb\r\n"},
+		{"a\rb\n", false, "a\rb\n"},
+	} {
+		for _, size := range []int{1, 2, 1000} {
+			var output bytes.Buffer
+			writeInChunks(t, &lineEndingWriter{writer: &output, crlf: test.crlf}, test.text, size)
+			if output.String() != test.expected {
+				t.Errorf("%q (crlf %v) in chunks of %d: wrote %q, expected %q", test.text, test.crlf, size, output.String(), test.expected)
+			}
+		}
+	}
+}
+
+func TestOutputWriter(t *testing.T) {
+	for _, test := range []struct {
+		eol          string
+		finalNewline bool
+		expected     string
+	}{
+		{"lf", true, "# a\n\nText.\n"},
+		{"lf", false, "# a\n\nText."},
+		{"crlf", true, "# a\r\n\r\nText.\r\n"},
+	} {
+		var output bytes.Buffer
+		writer, err := newOutputWriter(&output, test.eol, test.finalNewline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeInChunks(t, writer, "\n# a\r\n\nText.\n\n\n", 3)
+		writer.Close()
+		if output.String() != test.expected {
+			t.Errorf("-eol=%s -final-newline=%v: wrote %q, expected %q", test.eol, test.finalNewline, output.String(), test.expected)
+		}
+	}
+	if _, err := newOutputWriter(io.Discard, "cr", true); err == nil {
+		t.Errorf("-eol=cr is not an error")
+	}
+}
+
+func TestHeadingWriterNumbers(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{"# Title\n\n## a\n\n## b\n", "# Title\n\n## 1. a\n\n## 2. b\n"},
+		{
+			"# Title\n\n# FAQ\n\n# Details\n\n#### Index\n\n#### func F\n",
+			"# Title\n\n# 1. FAQ\n\n# 2. Details\n\n#### 2.1 Index\n\n#### 2.2 func F\n",
+		},
+		{
+			"# Title\n\n## a\n\n### a.a\n\n#### a.a.a\n\n### a.b\n\n## b\n\n### b.a\n",
+			"# Title\n\n## 1. a\n\n### 1.1 a.a\n\n#### 1.1.1 a.a.a\n\n### 1.2 a.b\n\n## 2. b\n\n### 2.1 b.a\n",
+		},
+		{"## a\n\n## b\n", "## 1. a\n\n## 2. b\n"},
+		{"# Title\n\n```\n# comment\n```\n\n## a\n", "# Title\n\n```\n# comment\n```\n\n## 1. a\n"},
+	} {
+		for _, size := range []int{1, 3, 1000} {
+			var output bytes.Buffer
+			writer := newHeadingWriter(&output, Style{NumberedHeadings: true})
+			writeInChunks(t, writer, test.text, size)
+			writer.Close()
+			if output.String() != test.expected {
+				t.Errorf("%q in chunks of %d: wrote %q, expected %q", test.text, size, output.String(), test.expected)
+			}
+		}
+	}
+}
+
+func TestWriteOutputTo(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, text string) error {
+		return writeOutputTo(path, nil, func(writer io.Writer) error {
+			_, err := io.WriteString(writer, text)
+			return err
+		})
+	}
+	read := func(path string) string {
+		t.Helper()
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(contents)
+	}
+
+	// A file keeps its permissions when it is replaced
+	path := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(path, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if text := read(path); text != "new" {
+		t.Errorf("wrote %q, expected \"new\"", text)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("the mode of the file is %v (%v), expected -rw-------", info.Mode(), err)
+	}
+
+	// A symlink is written through, not replaced
+	target := filepath.Join(dir, "docs.md")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.md")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("no symlinks:", err)
+	}
+	if err := write(link, "through"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("the symlink was replaced")
+	}
+	if text := read(target); text != "through" {
+		t.Errorf("wrote %q through the symlink, expected \"through\"", text)
+	}
+
+	// A failure leaves the file, and no temporary file, behind
+	failed := writeOutputTo(path, nil, func(writer io.Writer) error {
+		io.WriteString(writer, "partial")
+		return os.ErrInvalid
+	})
+	if failed == nil {
+		t.Errorf("a failure to render is not an error")
+	}
+	if text := read(path); text != "new" {
+		t.Errorf("a failure left %q, expected \"new\"", text)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("%d files are left, expected 3", len(entries))
+	}
+
+	// Stdout
+	var stdout bytes.Buffer
+	err := writeOutputTo("-", &stdout, func(writer io.Writer) error {
+		_, err := io.WriteString(writer, "to stdout")
+		return err
+	})
+	if err != nil || stdout.String() != "to stdout" {
+		t.Errorf("wrote %q to stdout (%v)", stdout.String(), err)
+	}
+}
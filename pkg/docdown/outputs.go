@@ -0,0 +1,142 @@
+package docdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// namedOutput is one of the outputs the config defines, which are written
+// for each package along with its documentation, from the same parse of it
+type namedOutput struct {
+	name     string
+	path     string // Relative to the directory of the package
+	template string
+	flavor   string
+	format   string // markdown, or json for the model plugins are sent
+
+	// style is the style of the run, but for the flavor and the template
+	// of the output, set by buildOutputs
+	style Style
+}
+
+// configOutputs are the outputs of the config, in its order
+var configOutputs []namedOutput
+
+// parseOutputs reads the "outputs" of the config at path, a mapping of
+// their names to their options:
+//
+//	outputs:
+//	  api:
+//	    output: docs/API.md
+//	  json:
+//	    output: docs/api.json
+//	    format: json
+func parseOutputs(path string, node *yaml.Node) ([]namedOutput, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected a mapping of outputs by their names", path, node.Line)
+	}
+	var outputs []namedOutput
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, options := node.Content[i], node.Content[i+1]
+		if options.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d: expected a mapping of options for output \"%s\"", path, options.Line, name.Value)
+		}
+		output := namedOutput{name: name.Value, format: "markdown"}
+		for j := 0; j+1 < len(options.Content); j += 2 {
+			key, value := options.Content[j], options.Content[j+1]
+			if value.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s:%d: expected a value for \"%s\" of output \"%s\"", path, value.Line, key.Value, output.name)
+			}
+			switch key.Value {
+			case "output":
+				output.path = value.Value
+			case "template":
+				output.template = value.Value
+			case "flavor":
+				output.flavor = value.Value
+			case "format":
+				switch value.Value {
+				case "markdown", "json":
+				default:
+					return nil, fmt.Errorf("%s:%d: invalid format \"%s\" of output \"%s\": expected markdown or json", path, value.Line, value.Value, output.name)
+				}
+				output.format = value.Value
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown option \"%s\" of output \"%s\"", path, key.Line, key.Value, output.name)
+			}
+		}
+		if output.path == "" || output.path == "-" {
+			return nil, fmt.Errorf("%s:%d: expected an output file for output \"%s\"", path, name.Line, output.name)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// buildOutputs builds the style of each output of the config, from the
+// options of the run and its own flavor and template
+func buildOutputs() error {
+	for i := range configOutputs {
+		output := &configOutputs[i]
+		flavor, plain := *flag_flavor, *flag_plain
+		if output.flavor != "" {
+			*flag_flavor, *flag_plain = output.flavor, false
+		}
+		style, err := buildStyle()
+		*flag_flavor, *flag_plain = flavor, plain
+		if err != nil {
+			return fmt.Errorf("Invalid output \"%s\": %v", output.name, err)
+		}
+		if output.template != "" {
+			style.Template = output.template
+		}
+		output.style = style
+	}
+	return nil
+}
+
+// writeOutputs writes the outputs of the config for the document, each
+// rendered from a copy of it with the style of the output
+func writeOutputs(ctx context.Context, document *Document) error {
+	for _, output := range configOutputs {
+		if ctx.Err() != nil {
+			return canceled(ctx)
+		}
+		path := output.path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(document.absPath, path)
+		}
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		copy := *document
+		copy.style = output.style
+		copy.page = path
+		copy.footerEmitted = false
+		copy.sectionsEmitted = nil
+		document.style.log().Debug("writing output", "package", document.absPath, "output", output.name, "path", path)
+		if output.format == "json" {
+			err = writeOutputTo(path, nil, func(writer io.Writer) error {
+				encoder := json.NewEncoder(writer)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(copy.pluginModel())
+			})
+		} else {
+			err = writeDocumentTo(ctx, copy.style, path, nil, func(writer io.Writer) error {
+				return renderDocumentTo(ctx, writer, &copy)
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("Could not write output \"%s\": %v", output.name, err)
+		}
+	}
+	return nil
+}
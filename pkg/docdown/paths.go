@@ -0,0 +1,41 @@
+package docdown
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// canonicalPath returns path made absolute, cleaned, and with symlinks
+// resolved, so the same directory compares equal however it was reached
+func canonicalPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	resolved, err := filepath.EvalSymlinks(absPath)
+	if err == nil {
+		absPath = resolved
+	}
+	return normalizeVolume(absPath)
+}
+
+// normalizeVolume rewrites the volume of a Windows path to its usual form:
+// extended-length paths (\\?\C:\ and \\?\UNC\server\share) lose their
+// prefix, and drive letters are upper case, as go.mod and templates are
+// found by comparing paths. Other paths are returned unchanged.
+func normalizeVolume(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	switch {
+	case strings.HasPrefix(path, `\\?\UNC\`):
+		path = `\\` + path[len(`\\?\UNC\`):]
+	case strings.HasPrefix(path, `\\?\`) && len(path) > 5 && path[5] == ':':
+		path = path[len(`\\?\`):]
+	}
+	if len(path) > 1 && path[1] == ':' {
+		path = strings.ToUpper(path[:1]) + path[1:]
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,242 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// buildContext is the build context for a platform, like "linux" or
+// "linux/arm64", which reads the files of a package from fsys
+func buildContext(fsys fs.FS, platform string) build.Context {
+	context := build.Default
+	goos, goarch, ok := strings.Cut(platform, "/")
+	context.GOOS = goos
+	if ok {
+		context.GOARCH = goarch
+	}
+	context.OpenFile = func(path string) (io.ReadCloser, error) {
+		return fsys.Open(filepath.Base(path))
+	}
+	return context
+}
+
+// filterPlatforms leaves the files of pkgSet that do not build on any of the
+// platforms out (by their names, like _windows.go, and their //go:build
+// lines), and maps the files that only build on some of them to those
+func filterPlatforms(fsys fs.FS, absPath string, pkgSet map[string]*ast.Package, platforms []string, log *slog.Logger) (map[string][]string, error) {
+	result := map[string][]string{}
+	for _, pkg := range pkgSet {
+		for filename := range pkg.Files {
+			var matched []string
+			for _, platform := range platforms {
+				context := buildContext(fsys, platform)
+				match, err := context.MatchFile(absPath, filepath.Base(filename))
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					matched = append(matched, platform)
+				}
+			}
+			switch len(matched) {
+			case 0:
+				log.Debug("skipping file for other platforms", "file", filename)
+				delete(pkg.Files, filename)
+			case len(platforms):
+			default:
+				result[filename] = matched
+			}
+		}
+	}
+	return result, nil
+}
+
+// receiverName is the name of the type of a method's receiver
+func receiverName(expression ast.Expr) string {
+	switch expression := expression.(type) {
+	case *ast.StarExpr:
+		return receiverName(expression.X)
+	case *ast.IndexExpr:
+		return receiverName(expression.X)
+	case *ast.IndexListExpr:
+		return receiverName(expression.X)
+	case *ast.Ident:
+		return expression.Name
+	}
+	return ""
+}
+
+// platformKey names a declaration the same way in the files for every
+// platform, like "func T.M" or "const A" (for a group, by its first name)
+func platformKey(node ast.Node) string {
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		if node.Recv != nil && len(node.Recv.List) == 1 {
+			return "func " + receiverName(node.Recv.List[0].Type) + "." + node.Name.Name
+		}
+		return "func " + node.Name.Name
+	case *ast.GenDecl:
+		if len(node.Specs) == 0 {
+			return ""
+		}
+		switch spec := node.Specs[0].(type) {
+		case *ast.TypeSpec:
+			return "type " + spec.Name.Name
+		case *ast.ValueSpec:
+			return node.Tok.String() + " " + spec.Names[0].Name
+		}
+	}
+	return ""
+}
+
+// withoutDoc is a copy of decl without its doc comment, which go/doc only
+// takes out of the declarations it picks
+func withoutDoc(decl ast.Decl) ast.Decl {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		copied := *decl
+		copied.Doc = nil
+		return &copied
+	case *ast.GenDecl:
+		copied := *decl
+		copied.Doc = nil
+		if len(decl.Specs) == 1 {
+			switch spec := decl.Specs[0].(type) {
+			case *ast.TypeSpec:
+				copiedSpec := *spec
+				copiedSpec.Doc = nil
+				copied.Specs = []ast.Spec{&copiedSpec}
+			case *ast.ValueSpec:
+				copiedSpec := *spec
+				copiedSpec.Doc = nil
+				copied.Specs = []ast.Spec{&copiedSpec}
+			}
+		}
+		return &copied
+	}
+	return decl
+}
+
+// platformVariant is a declaration as it is on some of the platforms
+type platformVariant struct {
+	code      string
+	platforms []string
+}
+
+// platformVariants are the declarations of node (the one go/doc picked) on
+// each of the -platforms it is declared for, with the platforms that share
+// each. It is nil for a declaration that is the same on every platform.
+func (self *Document) platformVariants(node ast.Node) []platformVariant {
+	if len(self.platforms) == 0 {
+		return nil
+	}
+	if _, ok := self.platforms[self.fset.Position(node.Pos()).Filename]; !ok {
+		return nil
+	}
+	key := platformKey(node)
+	var names []string
+	for name := range self.platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	found := map[string]map[string]bool{}
+	var order []string
+	for _, name := range names {
+		file := self.files[name]
+		if file == nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if platformKey(decl) != key {
+				continue
+			}
+			code := self.codeOfDecl(withoutDoc(decl))
+			if found[code] == nil {
+				found[code] = map[string]bool{}
+				order = append(order, code)
+			}
+			for _, platform := range self.platforms[name] {
+				found[code][platform] = true
+			}
+		}
+	}
+
+	var variants []platformVariant
+	for _, code := range order {
+		variant := platformVariant{code: code}
+		for _, platform := range self.style.Platforms {
+			if found[code][platform] {
+				variant.platforms = append(variant.platforms, platform)
+			}
+		}
+		variants = append(variants, variant)
+	}
+	sort.SliceStable(variants, func(i, j int) bool {
+		return platformIndex(self.style.Platforms, variants[i].platforms[0]) < platformIndex(self.style.Platforms, variants[j].platforms[0])
+	})
+	return variants
+}
+
+// platformIndex is the position of platform in the list of platforms
+func platformIndex(platforms []string, platform string) int {
+	for i, entry := range platforms {
+		if entry == platform {
+			return i
+		}
+	}
+	return len(platforms)
+}
+
+// platformCode is the code of a declaration that is not the same on every
+// platform: a sub-section for each variant, labeled with its platforms, or
+// one labeled with the only platforms it is declared for
+func (self *Document) platformCode(variants []platformVariant) string {
+	var sections []string
+	for _, variant := range variants {
+		label := strings.Join(variant.platforms, ", ")
+		if len(variants) == 1 {
+			label = self.style.text("Only on") + " " + label
+		}
+		sections = append(sections, fmt.Sprintf("*%s*\n\n%s", label, variant.code))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// dropPlatformDuplicates leaves one of the value groups that are declared
+// for several platforms, as go/doc lists each (while keeping one of the
+// functions and types), and the variants are rendered together
+func (self *Document) dropPlatformDuplicates() {
+	if len(self.platforms) == 0 {
+		return
+	}
+	values := func(list []*doc.Value) []*doc.Value {
+		seen := map[string]bool{}
+		var result []*doc.Value
+		for _, value := range list {
+			key := platformKey(value.Decl)
+			if _, ok := self.platforms[self.fset.Position(value.Decl.Pos()).Filename]; ok {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			result = append(result, value)
+		}
+		return result
+	}
+	self.pkg.Consts = values(self.pkg.Consts)
+	self.pkg.Vars = values(self.pkg.Vars)
+	for _, entry := range self.pkg.Types {
+		entry.Consts = values(entry.Consts)
+		entry.Vars = values(entry.Vars)
+	}
+}
@@ -0,0 +1,313 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/doc"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// pluginVersion is the version of the plugin protocol, sent to plugins so
+// they can tell if they understand the model
+const pluginVersion = 1
+
+// pluginValue is a group of constants or variables in the plugin model
+type pluginValue struct {
+	Names []string `json:"names"`
+	Doc   string   `json:"doc"`
+	Code  string   `json:"code"`
+}
+
+// pluginFunc is a function or method in the plugin model
+type pluginFunc struct {
+	Name     string `json:"name"`
+	Receiver string `json:"receiver,omitempty"`
+	Doc      string `json:"doc"`
+	Code     string `json:"code"`
+	Anchor   string `json:"anchor"`
+}
+
+// pluginType is a type in the plugin model, with its values, constructors,
+// and methods
+type pluginType struct {
+	Name      string        `json:"name"`
+	Doc       string        `json:"doc"`
+	Code      string        `json:"code"`
+	Anchor    string        `json:"anchor"`
+	Constants []pluginValue `json:"constants"`
+	Variables []pluginValue `json:"variables"`
+	Functions []pluginFunc  `json:"functions"`
+	Methods   []pluginFunc  `json:"methods"`
+}
+
+// pluginExample is an example in the plugin model
+type pluginExample struct {
+	Name   string `json:"name"`
+	Doc    string `json:"doc"`
+	Code   string `json:"code"`
+	Output string `json:"output"`
+}
+
+// pluginRequest is what a plugin reads on stdin: the model of the package
+type pluginRequest struct {
+	Version    int             `json:"version"`
+	Name       string          `json:"name"`
+	ImportPath string          `json:"importPath"`
+	Dir        string          `json:"dir"`
+	IsCommand  bool            `json:"isCommand"`
+	Doc        string          `json:"doc"`
+	Flavor     string          `json:"flavor"`
+	Constants  []pluginValue   `json:"constants"`
+	Variables  []pluginValue   `json:"variables"`
+	Functions  []pluginFunc    `json:"functions"`
+	Types      []pluginType    `json:"types"`
+	Examples   []pluginExample `json:"examples"`
+}
+
+// pluginSection is a section a plugin adds to the documentation
+type pluginSection struct {
+	Title    string `json:"title"`
+	Markdown string `json:"markdown"`
+}
+
+// pluginResponse is what a plugin writes to stdout. Every field is optional.
+type pluginResponse struct {
+	// Docs replaces the doc comments of symbols (named like F, T, or T.M, or
+	// "" for the package)
+	Docs map[string]string `json:"docs"`
+
+	// Ignore leaves symbols out, like "//godocdown:ignore"
+	Ignore []string `json:"ignore"`
+
+	// Sections are added after the index
+	Sections []pluginSection `json:"sections"`
+
+	// Markdown replaces the whole documentation, for plugins that render it
+	// (in whatever format) themselves
+	Markdown *string `json:"markdown"`
+}
+
+// pluginModel is the model of the document sent to plugins
+func (self *Document) pluginModel() pluginRequest {
+	code := func(node interface{}) string {
+		return stripDirectives(unexportedFields(sourceOfNode(self.fset, node), self.style))
+	}
+	values := func(list []*doc.Value) []pluginValue {
+		result := []pluginValue{}
+		for _, value := range list {
+			result = append(result, pluginValue{value.Names, value.Doc, code(value.Decl)})
+		}
+		return result
+	}
+	funcs := func(list []*doc.Func) []pluginFunc {
+		result := []pluginFunc{}
+		for _, entry := range list {
+			result = append(result, pluginFunc{entry.Name, entry.Recv, entry.Doc, code(entry.Decl), symbolAnchor(self, entry)})
+		}
+		return result
+	}
+
+	request := pluginRequest{
+		Version:    pluginVersion,
+		Name:       self.Name,
+		ImportPath: self.ImportPath,
+		Dir:        self.absPath,
+		IsCommand:  self.IsCommand,
+		Doc:        self.pkg.Doc,
+		Flavor:     self.style.Flavor,
+		Constants:  values(self.pkg.Consts),
+		Variables:  values(self.pkg.Vars),
+		Functions:  funcs(self.pkg.Funcs),
+		Types:      []pluginType{},
+		Examples:   []pluginExample{},
+	}
+	for _, entry := range self.pkg.Types {
+		request.Types = append(request.Types, pluginType{
+			Name:      entry.Name,
+			Doc:       entry.Doc,
+			Code:      code(entry.Decl),
+			Anchor:    typeAnchor(self, entry.Name),
+			Constants: values(entry.Consts),
+			Variables: values(entry.Vars),
+			Functions: funcs(entry.Funcs),
+			Methods:   funcs(entry.Methods),
+		})
+	}
+	for _, example := range self.Examples {
+		request.Examples = append(request.Examples, pluginExample{
+			Name:   example.Name,
+			Doc:    example.Doc,
+			Code:   sourceOfNode(self.fset, example.Code),
+			Output: example.Output,
+		})
+	}
+	return request
+}
+
+// isWASMPlugin reports whether a plugin is a WebAssembly module, which is
+// run in-process rather than by the shell
+func isWASMPlugin(command string) bool {
+	return strings.HasSuffix(strings.ToLower(command), ".wasm")
+}
+
+// wasmCache keeps the modules of WASM plugins compiled across the packages
+// of a run
+var wasmCache = wazero.NewCompilationCache()
+
+// runWASMPlugin runs a WebAssembly module built for WASI in-process. The
+// module is given its stdin, stdout, and stderr, and nothing else: no
+// files, network, environment, or clock.
+func runWASMPlugin(ctx context.Context, path string, stdin io.Reader, stdout, stderr io.Writer) error {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(wasmCache).
+		WithCloseOnContextDone(true))
+	defer runtime.Close(ctx)
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+	config := wazero.NewModuleConfig().
+		WithArgs(filepath.Base(path)).
+		WithStdin(stdin).
+		WithStdout(stdout).
+		WithStderr(stderr)
+	module, err := runtime.InstantiateWithConfig(ctx, code, config)
+	if module != nil {
+		module.Close(ctx)
+	}
+	return err
+}
+
+// runPlugin runs the plugin, sending it the request on stdin and reading its
+// response from stdout
+func runPlugin(ctx context.Context, command string, style Style, request pluginRequest) (pluginResponse, error) {
+	var response pluginResponse
+	input, err := json.Marshal(request)
+	if err != nil {
+		return response, err
+	}
+	var stdout, stderr bytes.Buffer
+	if isWASMPlugin(command) {
+		err = runWASMPlugin(ctx, command, bytes.NewReader(input), &stdout, &stderr)
+	} else {
+		process := shellCommand(ctx, command)
+		process.Stdin = bytes.NewReader(input)
+		process.Stdout = &stdout
+		process.Stderr = &stderr
+		err = process.Run()
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if ctx.Err() != nil {
+			message = canceled(ctx).Error()
+		} else if message == "" {
+			message = err.Error()
+		}
+		return response, fmt.Errorf("Error running -plugin \"%s\": %s", command, message)
+	}
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return response, fmt.Errorf("Invalid response from -plugin \"%s\": %v", command, err)
+	}
+	return response, nil
+}
+
+// applyPlugins runs the style's plugins in order, each seeing the changes
+// of the ones before it, and applies their responses to the document
+func (self *Document) applyPlugins(ctx context.Context) error {
+	for _, command := range self.style.Plugins {
+		response, err := runPlugin(ctx, command, self.style, self.pluginModel())
+		if err != nil {
+			return err
+		}
+		for symbol, text := range response.Docs {
+			// go/doc ends doc comments with a newline, and rendering counts on it
+			if text != "" && !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			if !self.setDoc(symbol, text) {
+				return fmt.Errorf("Invalid response from -plugin \"%s\": unknown symbol %s", command, symbol)
+			}
+		}
+		for _, symbol := range response.Ignore {
+			node := self.symbolNode(symbol)
+			if node == nil {
+				return fmt.Errorf("Invalid response from -plugin \"%s\": unknown symbol %s", command, symbol)
+			}
+			if self.directives == nil {
+				self.directives = map[token.Pos]map[string]string{}
+			}
+			if self.directives[node.Pos()] == nil {
+				self.directives[node.Pos()] = map[string]string{}
+			}
+			self.directives[node.Pos()]["ignore"] = ""
+		}
+		if len(response.Ignore) > 0 {
+			self.dropIgnored()
+		}
+		self.pluginSections = append(self.pluginSections, response.Sections...)
+		if response.Markdown != nil {
+			self.pluginMarkdown = response.Markdown
+		}
+	}
+	return nil
+}
+
+// setDoc replaces the doc comment of a symbol, named like symbolNode, or of
+// the package for "", reporting whether the symbol exists
+func (self *Document) setDoc(symbol, text string) bool {
+	if symbol == "" {
+		self.pkg.Doc = text
+		return true
+	}
+	if typeName, method, ok := strings.Cut(symbol, "."); ok {
+		if entry := self.findMethod(typeName, method); entry != nil {
+			entry.Doc = text
+			return true
+		}
+		return false
+	}
+	if entry := self.findFunc(symbol); entry != nil {
+		entry.Doc = text
+		return true
+	}
+	if entry := self.findType(symbol); entry != nil {
+		entry.Doc = text
+		return true
+	}
+	found := false
+	values := func(list []*doc.Value) {
+		for _, value := range list {
+			for _, name := range value.Names {
+				if name == symbol {
+					value.Doc = text
+					found = true
+				}
+			}
+		}
+	}
+	values(self.pkg.Consts)
+	values(self.pkg.Vars)
+	for _, entry := range self.pkg.Types {
+		values(entry.Consts)
+		values(entry.Vars)
+	}
+	return found
+}
+
+// renderPluginSectionsTo writes the sections added by plugins
+func renderPluginSectionsTo(writer io.Writer, document *Document) {
+	for _, section := range document.pluginSections {
+		fmt.Fprintf(writer, "%s %s\n\n%s\n\n", document.style.TypeHeader, section.Title, strings.TrimSpace(section.Markdown))
+	}
+}
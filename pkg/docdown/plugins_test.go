@@ -0,0 +1,65 @@
+package docdown
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWASMPlugin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a WebAssembly module")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/plugin\n",
+		// The plugin reads the package, and tries to read a file and the
+		// environment, which it has no access to
+		"plugin.go": `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var request struct{ Name string }
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fmt.Fprintln(os.Stderr, "no request:", err)
+		os.Exit(1)
+	}
+	_, err := os.ReadFile("go.mod")
+	markdown := fmt.Sprintf("package %s, file %v, environment %d", request.Name, err == nil, len(os.Environ()))
+	json.NewEncoder(os.Stdout).Encode(map[string]any{"markdown": markdown})
+}
+`,
+	})
+	plugin := filepath.Join(dir, "plugin.wasm")
+	build := exec.Command("go", "build", "-o", plugin, ".")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Skipf("cannot build for wasip1: %v\n%s", err, output)
+	}
+	t.Setenv("GODOCDOWN_SECRET", "1")
+
+	response, err := runPlugin(context.Background(), plugin, DefaultStyle, pluginRequest{Name: "tp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "package tp, file false, environment 0"; response.Markdown == nil || *response.Markdown != expected {
+		t.Errorf("the plugin wrote %v, expected %q", response.Markdown, expected)
+	}
+
+	// A module that is not one is an error, not a crash
+	broken := filepath.Join(dir, "broken.wasm")
+	writeFiles(t, dir, map[string]string{"broken.wasm": "not a module"})
+	_, err = runPlugin(context.Background(), broken, DefaultStyle, pluginRequest{Name: "tp"})
+	if err == nil || !strings.Contains(err.Error(), `Error running -plugin "`+broken+`"`) {
+		t.Errorf("a broken module is %v, expected an error running it", err)
+	}
+}
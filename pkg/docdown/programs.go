@@ -0,0 +1,199 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lithammer/dedent"
+)
+
+// exampleTypes is the package type-checked with its (internal) test files,
+// for making programs of its examples
+type exampleTypes struct {
+	fset  *token.FileSet
+	pkg   *types.Package
+	info  *types.Info
+	files []*ast.File
+	src   map[string][]byte
+	err   error
+}
+
+// typeCheckExamples type-checks the package with its test files, once. The
+// files go/doc was given no longer have their unexported declarations, so
+// they are parsed again, from disk.
+func (self *Document) typeCheckExamples() *exampleTypes {
+	if self.exampleTypes != nil {
+		return self.exampleTypes
+	}
+	checked := &exampleTypes{fset: token.NewFileSet(), src: map[string][]byte{}}
+	self.exampleTypes = checked
+
+	var names []string
+	for name := range self.files {
+		names = append(names, name)
+	}
+	for name, file := range self.testFiles {
+		if file.Name.Name == self.pkg.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			checked.err = err
+			return checked
+		}
+		file, err := parser.ParseFile(checked.fset, name, src, parser.ParseComments)
+		if err != nil {
+			checked.err = err
+			return checked
+		}
+		checked.src[name] = src
+		checked.files = append(checked.files, file)
+	}
+	checked.info = &types.Info{Uses: map[*ast.Ident]types.Object{}}
+	config := types.Config{Importer: importer.ForCompiler(checked.fset, "source", nil), FakeImportC: true}
+	checked.pkg, checked.err = config.Check(self.ImportPath, checked.fset, checked.files, checked.info)
+	if checked.err != nil {
+		self.style.log().Debug("could not type-check examples", "package", self.absPath, "error", checked.err)
+	}
+	return checked
+}
+
+// exampleProgram is an example as a program that can be run as it is: its
+// body inlined into main, with the symbols of the package qualified with
+// its name, and the imports it needs (which go/types resolves). It is an
+// error for an example that does not stand on its own, as one that uses
+// unexported symbols or helpers of its file.
+func (self *Document) exampleProgram(name string) (string, error) {
+	if self.ImportPath == "" {
+		return "", fmt.Errorf("The package has no import path")
+	}
+	checked := self.typeCheckExamples()
+	if checked.err != nil {
+		return "", checked.err
+	}
+	var decl *ast.FuncDecl
+	var src []byte
+	for _, file := range checked.files {
+		for _, entry := range file.Decls {
+			if function, ok := entry.(*ast.FuncDecl); ok && function.Recv == nil && function.Name.Name == "Example"+name {
+				decl = function
+				src = checked.src[checked.fset.Position(file.Pos()).Filename]
+			}
+		}
+	}
+	if decl == nil || decl.Body == nil {
+		return "", fmt.Errorf("Could not find Example%s", name)
+	}
+	offset := func(position token.Pos) int {
+		return checked.fset.Position(position).Offset
+	}
+
+	// Where to insert the name of the package, and the imports the body uses
+	var qualify []int
+	imports := map[string]string{}
+	var problem error
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok || problem != nil {
+			return problem == nil
+		}
+		switch object := checked.info.Uses[ident].(type) {
+		case *types.PkgName:
+			imports[object.Imported().Path()] = object.Name()
+		case nil:
+		default:
+			if object.Parent() != checked.pkg.Scope() {
+				return true
+			}
+			if !object.Exported() || strings.HasSuffix(checked.fset.Position(object.Pos()).Filename, "_test.go") {
+				problem = fmt.Errorf("Example%s uses %s, which is not part of the package's API", name, object.Name())
+				return false
+			}
+			qualify = append(qualify, offset(ident.Pos()))
+		}
+		return true
+	})
+	if problem != nil {
+		return "", problem
+	}
+
+	// The body, without the expected output
+	start, end := offset(decl.Body.Lbrace)+1, offset(decl.Body.Rbrace)
+	for _, file := range checked.files {
+		for _, group := range file.Comments {
+			if decl.Body.Pos() < group.Pos() && group.End() < decl.Body.End() && exampleOutput_Regexp.MatchString(strings.TrimSpace(group.Text())) {
+				end = offset(group.Pos())
+			}
+		}
+	}
+	var body strings.Builder
+	last := start
+	for _, at := range qualify {
+		if at < start || at >= end {
+			continue
+		}
+		body.Write(src[last:at])
+		body.WriteString(checked.pkg.Name() + ".")
+		last = at
+	}
+	body.Write(src[last:end])
+	if len(qualify) > 0 {
+		imports[self.ImportPath] = checked.pkg.Name()
+	}
+
+	// The standard library first, then the rest, like goimports does
+	var paths []string
+	for importPath := range imports {
+		paths = append(paths, importPath)
+	}
+	standard := func(importPath string) bool {
+		return !strings.Contains(strings.Split(importPath, "/")[0], ".")
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if standard(paths[i]) != standard(paths[j]) {
+			return standard(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	var program strings.Builder
+	program.WriteString("package main\n\n")
+	if len(paths) > 0 {
+		program.WriteString("import (\n")
+		for i, importPath := range paths {
+			if i > 0 && standard(paths[i-1]) && !standard(importPath) {
+				program.WriteString("\n")
+			}
+			if imports[importPath] != path.Base(importPath) {
+				program.WriteString(imports[importPath] + " ")
+			}
+			program.WriteString(strconv.Quote(importPath) + "\n")
+		}
+		program.WriteString(")\n\n")
+	}
+	program.WriteString("func main() {\n")
+	program.WriteString(strings.Trim(dedent.Dedent(body.String()), "\n"))
+	program.WriteString("\n}\n")
+
+	source := program.String()
+	if !self.style.KeepDirectives {
+		source = stripDirectives(source)
+	}
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
@@ -0,0 +1,111 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/lithammer/dedent"
+)
+
+// quickstartExample is the example to show in a "Quick start" section near
+// the top: the one -quickstart names (like ExampleClient_basic), or the one
+// with a "//godocdown:quickstart" directive, or nil for none
+func (self *Document) quickstartExample() *doc.Example {
+	name := strings.TrimPrefix(self.style.Quickstart, "Example")
+	for _, example := range self.Examples {
+		if name != "" && example.Name == name {
+			return example
+		}
+		if name == "" {
+			if _, ok := self.exampleDirectives(example.Name)["quickstart"]; ok {
+				return example
+			}
+		}
+	}
+	if name != "" {
+		self.style.log().Warn("Could not find the -quickstart example", "package", self.absPath, "example", self.style.Quickstart)
+	}
+	return nil
+}
+
+// exampleImports is the import declaration for the packages the code of an
+// example uses, from the imports of its file, or "" if it uses none
+func (self *Document) exampleImports(example *doc.Example) string {
+	decl := self.exampleDecl(example.Name)
+	if decl == nil {
+		return ""
+	}
+	var file *ast.File
+	for _, entry := range self.testFiles {
+		if entry.Pos() <= decl.Pos() && decl.End() <= entry.End() {
+			file = entry
+		}
+	}
+	if file == nil {
+		return ""
+	}
+	names := map[string]string{}
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = spec.Path.Value
+	}
+	used := map[string]bool{}
+	var paths []string
+	ast.Inspect(example.Code, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok && names[ident.Name] != "" && !used[ident.Name] {
+				used[ident.Name] = true
+				importPath := names[ident.Name]
+				if path.Base(strings.Trim(importPath, `"`)) != ident.Name {
+					importPath = ident.Name + " " + importPath
+				}
+				paths = append(paths, importPath)
+			}
+		}
+		return true
+	})
+	switch len(paths) {
+	case 0:
+		return ""
+	case 1:
+		return "import " + paths[0] + "\n\n"
+	}
+	return "import (\n\t" + strings.Join(paths, "\n\t") + "\n)\n\n"
+}
+
+// renderQuickstartTo writes the code of the quick start example, with the
+// imports it needs, under QuickstartHeader
+func renderQuickstartTo(writer io.Writer, document *Document) {
+	example := document.quickstartExample()
+	if example == nil {
+		return
+	}
+	style := document.style
+	style.CodeLanguage = document.exampleLanguage(example.Name)
+	code := indentCode(document.exampleImports(example)+exampleBody(document.exampleSource(example)), style)
+	fmt.Fprintf(writer, "%s\n", style.heading(style.QuickstartHeader))
+	if example.Doc != "" {
+		fmt.Fprintf(writer, "%s\n", document.docText(example.Doc))
+	}
+	fmt.Fprintf(writer, "%s\n\n", strings.TrimRight(code, "\n"))
+}
+
+// exampleBody is the code of an example without the braces of its function
+// body, and dedented, like indentCode leaves it
+func exampleBody(source string) string {
+	source = strings.TrimSpace(source)
+	source = strings.TrimSuffix(strings.TrimPrefix(source, "{"), "}")
+	return strings.Trim(dedent.Dedent(source), "\n") + "\n"
+}
@@ -0,0 +1,216 @@
+package docdown
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+var (
+	// docReference_Regexp matches doc links to symbols: [Name], [Name.Name],
+	// [pkg.Name], and the like, optionally with a "*", but not Markdown links
+	// or link definitions, or links with a path ([example.com/pkg.Name])
+	docReference_Regexp = regexp.MustCompile(`\[\*?([A-Za-z_][A-Za-z0-9_]*)(?:\.([A-Za-z_][A-Za-z0-9_]*))?(?:\.([A-Za-z_][A-Za-z0-9_]*))?\]([(:]?)`)
+
+	// linkDefinition_Regexp matches a link definition, "[Text]: URL", which
+	// makes [Text] a link to the URL rather than to a symbol
+	linkDefinition_Regexp = regexp.MustCompile(`(?m)^\s*\[([^\]]+)\]:\s*\S+\s*$`)
+
+	// seeAlso_Regexp matches "See also" and "See" sentences, which name the
+	// functions they refer to like Name() or T.Name()
+	seeAlso_Regexp = regexp.MustCompile(`(?is)\bsee(?:\s+also)?\b(.*?)(?:\.\s|\.$|\n\n|$)`)
+
+	// seeAlsoCall_Regexp matches a function named in a "See also" sentence
+	seeAlsoCall_Regexp = regexp.MustCompile(`\b([A-Z][A-Za-z0-9_]*)(?:\.([A-Z][A-Za-z0-9_]*))?\(\)`)
+
+	// seeAlsoItem_Regexp matches an item of a "See also" list of names, like
+	// Name, Name(), or T.Name, and seeAlsoSeparator_Regexp what is between
+	// the items, like "," or "and"
+	seeAlsoItem_Regexp      = regexp.MustCompile(`^\*?([A-Z][A-Za-z0-9_]*)(?:\.([A-Z][A-Za-z0-9_]*))?(?:\(\))?$`)
+	seeAlsoSeparator_Regexp = regexp.MustCompile(`\s*,\s*(?:(?:and|or)\s+)?|\s+(?:and|or)\s+`)
+)
+
+// isExportedName reports whether name starts with an upper case letter
+func isExportedName(name string) bool {
+	return name != "" && ast.IsExported(name)
+}
+
+// linkBoundary reports whether the byte of line at index (which may be
+// outside of it) can come before or after a doc link
+func linkBoundary(line string, index int) bool {
+	if index < 0 || index >= len(line) {
+		return true
+	}
+	c := line[index]
+	return c == ' ' || c == '\t' || strings.IndexByte(".,:;?!()'\"", c) >= 0
+}
+
+// hasSymbol reports whether the package has a (documented) top level symbol
+// with the given name
+func (self *Document) hasSymbol(name string) bool {
+	return self.symbolNode(name) != nil
+}
+
+// hasMember reports whether the type has a method or field with the given
+// name
+func (self *Document) hasMember(typeName, name string) bool {
+	if self.findMethod(typeName, name) != nil {
+		return true
+	}
+	entry := self.findType(typeName)
+	if entry == nil {
+		return false
+	}
+	spec := typeSpecOf(entry)
+	if spec == nil || spec.Assign.IsValid() {
+		// An alias has the members of a type that may be in another package
+		return spec != nil
+	}
+	var fields *ast.FieldList
+	switch kind := spec.Type.(type) {
+	case *ast.StructType:
+		fields = kind.Fields
+	case *ast.InterfaceType:
+		fields = kind.Methods
+	}
+	if fields == nil {
+		// Like type T U, which has the fields of U
+		_, isName := spec.Type.(*ast.Ident)
+		_, isSelector := spec.Type.(*ast.SelectorExpr)
+		return isName || isSelector
+	}
+	for _, field := range fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return true
+			}
+		}
+		if len(field.Names) == 0 {
+			// An embedded type may promote the member, so it is not checked
+			return true
+		}
+	}
+	return false
+}
+
+// checkReference is the problem with a doc link to the symbol named by the
+// parts of a reference ([A], [A.B], or [a.B.C]), or "" if it resolves (or
+// refers to another package, which is not checked)
+func (self *Document) checkReference(parts []string) string {
+	first := parts[0]
+	switch len(parts) {
+	case 1:
+		if isExportedName(first) && !self.hasSymbol(first) && self.findTerm(first) == nil {
+			return "unknown symbol " + first
+		}
+	case 2:
+		if self.findType(first) != nil {
+			if !self.hasMember(first, parts[1]) {
+				return "unknown method or field " + first + "." + parts[1]
+			}
+			return ""
+		}
+		if isExportedName(first) {
+			return "unknown type " + first
+		}
+		// [pkg.Name], where pkg is the package itself when it is not imported
+		if first == self.Name && self.imports[first] == "" && !self.hasSymbol(parts[1]) {
+			return "unknown symbol " + parts[1]
+		}
+	case 3:
+		if first == self.Name && self.imports[first] == "" && self.findType(parts[1]) != nil && !self.hasMember(parts[1], parts[2]) {
+			return "unknown method or field " + parts[1] + "." + parts[2]
+		}
+	}
+	return ""
+}
+
+// seeAlsoNames are the names a "See also" sentence refers to, as matches of
+// seeAlsoCall_Regexp: every name of a list of them ("See also NewClient and
+// Close"), when one of the list is a symbol of the package or is called,
+// and otherwise only the functions called in it ("See the Go blog on
+// Close()")
+func (self *Document) seeAlsoNames(sentence string) [][]string {
+	var list [][]string
+	isList := false
+	for _, item := range seeAlsoSeparator_Regexp.Split(strings.TrimSpace(sentence), -1) {
+		match := seeAlsoItem_Regexp.FindStringSubmatch(item)
+		if match == nil {
+			isList = false
+			break
+		}
+		if strings.HasSuffix(item, "()") || (match[2] == "" && self.hasSymbol(match[1])) || (match[2] != "" && self.findType(match[1]) != nil) {
+			isList = true
+		}
+		list = append(list, match)
+	}
+	if isList {
+		return list
+	}
+	return seeAlsoCall_Regexp.FindAllStringSubmatch(sentence, -1)
+}
+
+// checkReferences warns about doc links to symbols of the package that do
+// not exist, and about the names in "See also" sentences that do not,
+// which are usually renamed or removed since the comment was written
+func (self *Document) checkReferences() []warning {
+	var warnings []warning
+	check := func(node ast.Node, text string) {
+		position := self.docPosition(node)
+		reported := map[string]bool{}
+		defined := map[string]bool{}
+		for _, match := range linkDefinition_Regexp.FindAllStringSubmatch(text, -1) {
+			defined[match[1]] = true
+		}
+		report := func(format string, problem string) {
+			if !reported[problem] {
+				reported[problem] = true
+				warnings = append(warnings, newWarning("references", position, format, problem))
+			}
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+				// Code
+				continue
+			}
+			for _, index := range docReference_Regexp.FindAllStringSubmatchIndex(line, -1) {
+				match := line[index[0]:index[1]]
+				if index[9] > index[8] || defined[strings.Trim(match, "[]*")] {
+					// A Markdown link, a link definition, or a link to one
+					continue
+				}
+				// Like go/doc, a link is only a link between punctuation or spaces
+				// ([N]byte is not one)
+				if !linkBoundary(line, index[0]-1) || !linkBoundary(line, index[1]) {
+					continue
+				}
+				var parts []string
+				for i := 2; i < 8; i += 2 {
+					if index[i] >= 0 {
+						parts = append(parts, line[index[i]:index[i+1]])
+					}
+				}
+				if problem := self.checkReference(parts); problem != "" {
+					report("doc link to %s", problem)
+				}
+			}
+		}
+		for _, sentence := range seeAlso_Regexp.FindAllStringSubmatch(text, -1) {
+			for _, match := range self.seeAlsoNames(sentence[1]) {
+				kind := "symbol"
+				if strings.HasSuffix(match[0], "()") {
+					kind = "function"
+				}
+				switch {
+				case match[2] == "" && !self.hasSymbol(match[1]):
+					report("\"See\" refers to %s", "unknown "+kind+" "+match[1])
+				case match[2] != "" && self.findType(match[1]) != nil && !self.hasMember(match[1], match[2]):
+					report("\"See\" refers to %s", "unknown method "+match[1]+"."+match[2])
+				}
+			}
+		}
+	}
+	check(nil, self.pkg.Doc)
+	self.walkDocs(check)
+	return warnings
+}
@@ -0,0 +1,38 @@
+package docdown
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckReferences(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/refs\n",
+		"a.go":   "package refs\n\n// Client talks to the server.\ntype Client struct{}\n\n// NewClient makes a Client. See also NewClient and Gone.\nfunc NewClient() *Client { return nil }\n\n// Close closes it. See the Go Blog, or Missing().\nfunc (self *Client) Close() {}\n",
+		"b.go":   "// Package refs has references, like [Client.Close] and [Absent].\n//\n// See Client.Close, NewClient, or Client.Open.\npackage refs\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, warning := range document.checkReferences() {
+		messages = append(messages, warning.String())
+	}
+	b, a := filepath.Join(dir, "b.go"), filepath.Join(dir, "a.go")
+	expected := []string{
+		b + ":1:1: doc link to unknown symbol Absent",
+		b + ":1:1: \"See\" refers to unknown method Client.Open",
+		a + ":7:1: \"See\" refers to unknown symbol Gone",
+		a + ":10:1: \"See\" refers to unknown function Missing",
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("warnings are\n%q\nexpected\n%q", messages, expected)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("warning %d is %q, expected %q", i, messages[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package docdown
+
+import (
+	"context"
+	Flag "flag"
+	"fmt"
+	"go/doc"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// releasePackage is a package as it was at two revisions, for the release
+// notes. Either document is nil if the package did not exist then.
+type releasePackage struct {
+	importPath string
+	old, new   *Document
+	changes    []apiChange
+}
+
+// releaseNotesFlags are the flags of "godocdown release-notes": those of the
+// documentation, -timeout, -from, and -to
+func releaseNotesFlags() (*Flag.FlagSet, *string, *string) {
+	notesFlag := subcommandFlags("release-notes", "timeout")
+	from := notesFlag.String("from", "", "The revision to compare against (the tag before -to by default)")
+	to := notesFlag.String("to", "", "The revision to describe (the working tree by default)")
+	return notesFlag, from, to
+}
+
+// runReleaseNotes implements "godocdown release-notes", which compares the
+// exported API of the packages in and below the current directory against
+// the previous tag, reading it straight from the git objects, and writes an
+// "API changes" section for the release notes.
+func runReleaseNotes(arguments []string) int {
+	notesFlag, from, to := releaseNotesFlags()
+	notesFlag.Parse(arguments)
+
+	err := loadConfig(notesFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+	err = setupLogger(os.Stderr)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	if notesFlag.NArg() > 0 {
+		logger.Error("Cannot give packages to release-notes: it compares the packages in and below the current directory")
+		return 2
+	}
+	style, err := buildStyle()
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	if *from == "" {
+		*from, err = previousTag(*to)
+		if err != nil {
+			logger.Error(err.Error())
+			return 1
+		}
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	packages, err := releasePackages(ctx, *from, *to, style)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	err = writeOutputTo(flag_output, os.Stdout, func(writer io.Writer) error {
+		renderReleaseNotesTo(writer, packages, *from)
+		return nil
+	})
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// previousTag is the latest tag before the revision to (or HEAD). A tag on
+// to itself is the release being described, so it is skipped, unless the
+// working tree is described and its Go files have changed since.
+func previousTag(to string) (string, error) {
+	revision := to
+	if revision == "" {
+		revision = "HEAD"
+	}
+	tag, err := git(".", "describe", "--tags", "--abbrev=0", revision)
+	if err != nil {
+		return "", fmt.Errorf("Could not find a tag to compare against (use -from): %v", err)
+	}
+	tagged, _ := git(".", "rev-parse", strings.TrimSpace(string(tag))+"^{commit}")
+	current, _ := git(".", "rev-parse", revision+"^{commit}")
+	if string(tagged) != string(current) {
+		return strings.TrimSpace(string(tag)), nil
+	}
+	if to == "" {
+		changed, err := git(".", "status", "--porcelain", "--", "*.go")
+		if err == nil && len(changed) > 0 {
+			return strings.TrimSpace(string(tag)), nil
+		}
+	}
+	tag, err = git(".", "describe", "--tags", "--abbrev=0", revision+"^")
+	if err != nil {
+		return "", fmt.Errorf("Could not find a tag to compare against (use -from): %v", err)
+	}
+	return strings.TrimSpace(string(tag)), nil
+}
+
+// packageDirsAt lists the directories (relative to the current directory)
+// below it that had a Go package at the git revision ref
+func packageDirsAt(ref string, filter DirFilter) (map[string]bool, error) {
+	listing, err := git(".", "ls-tree", "-r", "-z", "--name-only", ref, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	dirs := map[string]bool{}
+	for _, name := range strings.Split(string(listing), "\x00") {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		dir := path.Dir(name)
+		if dir == "." || !filter.skipImport(dir) {
+			dirs[filepath.FromSlash(dir)] = true
+		}
+	}
+	return dirs, nil
+}
+
+// releasePackages loads the packages in and below the current directory as
+// they were at the revisions from and to ("" for the working tree), and
+// compares their APIs. Commands have no API, so they are left out.
+func releasePackages(ctx context.Context, from, to string, style Style) ([]releasePackage, error) {
+	dirs, err := packageDirsAt(from, style.Dirs)
+	if err != nil {
+		return nil, err
+	}
+	if to != "" {
+		now, err := packageDirsAt(to, style.Dirs)
+		if err != nil {
+			return nil, err
+		}
+		for dir := range now {
+			dirs[dir] = true
+		}
+	} else {
+		now, err := packageDirs(".", true, style.Dirs)
+		if err != nil {
+			return nil, err
+		}
+		if hasPackage(".") {
+			now = append(now, ".")
+		}
+		for _, dir := range now {
+			dirs[dir] = true
+		}
+	}
+
+	var list []string
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	sort.Strings(list)
+
+	var packages []releasePackage
+	for _, dir := range list {
+		importPath, absPath, err := buildImport(dir)
+		if err != nil {
+			return nil, err
+		}
+		entry := releasePackage{importPath: importPath}
+		if _, err := os.Stat(absPath); err == nil {
+			// git runs in the package directory, so one that is gone can
+			// only be listed
+			entry.old, err = loadDocumentAt(ctx, absPath, importPath, from, style)
+			if err != nil {
+				logger.Debug("not comparing package", "package", absPath, "error", err)
+			}
+			if to == "" {
+				if hasPackage(absPath) {
+					entry.new, err = loadDocument(ctx, absPath, style)
+				}
+			} else {
+				entry.new, err = loadDocumentAt(ctx, absPath, importPath, to, style)
+			}
+			if err != nil {
+				logger.Debug("not comparing package", "package", absPath, "error", err)
+			}
+		} else {
+			entry.old = &Document{}
+		}
+		if (entry.old != nil && entry.old.IsCommand) || (entry.new != nil && entry.new.IsCommand) {
+			continue
+		}
+		if entry.old != nil && entry.new != nil {
+			entry.changes = diffAPI(entry.old.api(), entry.new.api())
+		}
+		packages = append(packages, entry)
+	}
+	return packages, nil
+}
+
+// inlineCode quotes text as inline code, with enough backticks to hold the
+// backticks in it (like those of struct tags)
+func inlineCode(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// renderReleaseNotesTo writes the "API changes" section of the release
+// notes: the packages added and removed since from, and the breaking
+// changes, additions, and documentation changes in the others
+func renderReleaseNotesTo(writer io.Writer, packages []releasePackage, from string) {
+	fmt.Fprintf(writer, "## API changes\n\n")
+
+	var added, removed, changed []releasePackage
+	for _, entry := range packages {
+		switch {
+		case entry.old == nil && entry.new != nil:
+			added = append(added, entry)
+		case entry.old != nil && entry.new == nil:
+			removed = append(removed, entry)
+		case len(entry.changes) > 0:
+			changed = append(changed, entry)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		fmt.Fprintf(writer, "No changes to the exported API since `%s`.\n", from)
+		return
+	}
+	fmt.Fprintf(writer, "Changes to the exported API since `%s`.\n\n", from)
+
+	if len(added) > 0 {
+		fmt.Fprintf(writer, "### New packages\n\n")
+		for _, entry := range added {
+			synopsis := doc.Synopsis(entry.new.pkg.Doc)
+			if synopsis != "" {
+				synopsis = ": " + synopsis
+			}
+			fmt.Fprintf(writer, "- `%s`%s\n", entry.importPath, synopsis)
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+	if len(removed) > 0 {
+		fmt.Fprintf(writer, "### Removed packages\n\n")
+		for _, entry := range removed {
+			fmt.Fprintf(writer, "- `%s`\n", entry.importPath)
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+
+	for _, entry := range changed {
+		var breaking, additions, documented []string
+		for _, change := range entry.changes {
+			signature := inlineCode(shortSignature(change.Symbol().Signature))
+			switch change.Change {
+			case "removed":
+				breaking = append(breaking, "Removed "+signature)
+			case "changed":
+				breaking = append(breaking, fmt.Sprintf("Changed %s (was %s)", signature, inlineCode(shortSignature(change.Old.Signature))))
+			case "added":
+				additions = append(additions, signature)
+			case "documented":
+				documented = append(documented, inlineCode(change.Name))
+			}
+		}
+		fmt.Fprintf(writer, "### `%s`\n\n", entry.importPath)
+		for _, section := range []struct {
+			title string
+			items []string
+		}{
+			{"Breaking changes", breaking},
+			{"Additions", additions},
+			{"Documentation changes", documented},
+		} {
+			if len(section.items) == 0 {
+				continue
+			}
+			fmt.Fprintf(writer, "**%s**\n\n", section.title)
+			for _, item := range section.items {
+				fmt.Fprintf(writer, "- %s\n", item)
+			}
+			fmt.Fprintf(writer, "\n")
+		}
+	}
+}
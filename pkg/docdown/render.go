@@ -0,0 +1,560 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	Template "text/template"
+)
+
+func renderValueSectionTo(writer io.Writer, document *Document, header, title string, list []*doc.Value) {
+	if document.style.Flavor == "gomarkdoc" {
+		if len(list) > 0 && header != "" {
+			fmt.Fprintf(writer, "%s %s\n\n", header, title)
+		}
+		for _, entry := range list {
+			renderStabilityBadgeTo(writer, document, entry.Decl, entry.Doc)
+			renderChangeLabelTo(writer, document, entry.Decl)
+			fmt.Fprintf(writer, "%s%s\n\n%s",
+				paragraph(document, entry.Doc),
+				document.codeOf(entry.Decl),
+				document.referencesOf(entry.Decl)+document.usedByOf(entry.Decl))
+		}
+		return
+	}
+	for _, entry := range list {
+		renderStabilityBadgeTo(writer, document, entry.Decl, entry.Doc)
+		renderChangeLabelTo(writer, document, entry.Decl)
+		fmt.Fprintf(writer, "%s\n%s%s\n",
+			document.codeOf(entry.Decl),
+			document.referencesOf(entry.Decl)+document.usedByOf(entry.Decl),
+			document.docText(entry.Doc))
+	}
+}
+
+// paragraph is doc followed by a blank line, or nothing if there is no doc
+func paragraph(document *Document, doc string) string {
+	text := strings.TrimRight(document.docText(doc), "\n")
+	if text == "" {
+		return ""
+	}
+	return text + "\n\n"
+}
+
+func renderConstantSectionTo(writer io.Writer, document *Document, list []*doc.Value, inTypeSection bool) {
+	header := document.style.ConstantHeader
+	if inTypeSection {
+		header = ""
+	}
+	renderValueSectionTo(writer, document, header, document.style.text("Constants"), list)
+}
+
+func renderVariableSectionTo(writer io.Writer, document *Document, list []*doc.Value, inTypeSection bool) {
+	header := document.style.VariableHeader
+	if inTypeSection {
+		header = ""
+	}
+	renderValueSectionTo(writer, document, header, document.style.text("Variables"), list)
+}
+
+// symbolAnchor is the anchor the index uses to link to a function or method
+func symbolAnchor(document *Document, entry *doc.Func) string {
+	switch {
+	case document.style.Flavor == "commonmark" && entry.Recv != "":
+		return headingID("func (" + entry.Recv + ") " + entry.Name)
+	case document.style.Flavor == "commonmark":
+		return headingID("func " + entry.Name)
+	case entry.Recv != "" && document.style.Flavor == "gomarkdoc":
+		return baseType(entry.Recv) + "." + entry.Name
+	}
+	return entry.Name
+}
+
+// typeAnchor is the anchor the index uses to link to a type
+func typeAnchor(document *Document, name string) string {
+	if document.style.Flavor == "commonmark" {
+		return headingID("type " + name)
+	}
+	return name
+}
+
+// renderSymbolHeadingTo writes the heading for a declaration, with an anchor
+// for the index to link to
+func renderSymbolHeadingTo(writer io.Writer, document *Document, header, kind, receiver, name, anchor string, node ast.Node) {
+	if kind == "func" && document.signatureHeading(node) {
+		renderSignatureHeadingTo(writer, document, header, anchor, node)
+		return
+	}
+	if document.style.Flavor == "gomarkdoc" {
+		if link := document.sourceLink(node); link != "" {
+			name = fmt.Sprintf("[%s](<%s>)", name, link)
+		}
+		if receiver != "" {
+			receiver = fmt.Sprintf(`\(%s\) `, gomarkdocEscaper.Replace(receiver))
+		}
+		fmt.Fprintf(writer, "<a name=\"%s\"></a>\n%s %s %s%s\n\n", anchor, header, kind, receiver, name)
+		return
+	}
+	if receiver != "" {
+		receiver = fmt.Sprintf("(%s) ", receiver)
+	}
+	if document.style.Flavor == "commonmark" {
+		// The anchor comes from the text of the heading
+		fmt.Fprintf(writer, "%s %s %s%s\n\n", header, kind, receiver, name)
+		return
+	}
+	fmt.Fprintf(writer, "%s %s %s%s {#%s}\n\n", header, kind, receiver, name, anchor)
+}
+
+func renderFunctionSectionTo(writer io.Writer, document *Document, list []*doc.Func, inTypeSection bool, exs []*doc.Example) {
+
+	header := document.style.FunctionHeader
+	if inTypeSection {
+		header = document.style.TypeFunctionHeader
+	}
+
+	for _, entry := range list {
+		renderSymbolHeadingTo(writer, document, header, "func", entry.Recv, entry.Name, symbolAnchor(document, entry), entry.Decl)
+		renderStabilityBadgeTo(writer, document, entry.Decl, entry.Doc)
+		renderChangeLabelTo(writer, document, entry.Decl)
+		code := document.codeOf(entry.Decl)
+		if document.signatureHeading(entry.Decl) {
+			// The heading shows it
+			code = ""
+		}
+		if document.style.Flavor == "gomarkdoc" {
+			fmt.Fprintf(writer, "%s\n\n%s%s",
+				code,
+				document.referencesOf(entry.Decl)+document.usedByOf(entry.Decl)+document.assemblyNote(entry),
+				paragraph(document, entry.Doc))
+		} else {
+			fmt.Fprintf(writer, "%s\n%s%s\n",
+				code,
+				document.referencesOf(entry.Decl)+document.usedByOf(entry.Decl)+document.assemblyNote(entry),
+				document.docText(entry.Doc)) // use the doc as-is in markdown
+		}
+
+		for _, ex := range filterExamples(exs, entry.Name) {
+			renderExample(writer, document, ex)
+		}
+	}
+}
+
+func renderExample(w io.Writer, document *Document, ex *doc.Example) {
+	style := document.style
+	style.CodeLanguage = document.exampleLanguage(ex.Name)
+	code := indentCode(document.exampleSource(ex), style)
+	if style.ExamplePrograms {
+		if program, err := document.exampleProgram(ex.Name); err == nil {
+			code = indentCode(program, style)
+		} else {
+			document.style.log().Debug("showing the example as it is", "example", ex.Name, "error", err)
+		}
+	}
+
+	if style.Compact {
+		renderCompactExampleTo(w, document, ex, code)
+		return
+	}
+	title := document.exampleHeading(ex.Name)
+	language := document.exampleOutputLanguage(ex.Name)
+	if document.style.Flavor == "gomarkdoc" {
+		fmt.Fprintf(w, "<details><summary>%s</summary>\n<p>\n\n%s%s\n\n#### %s\n\n```%s\n%s```\n\n</p>\n</details>\n\n",
+			title,
+			paragraph(document, ex.Doc),
+			code,
+			document.style.text("Output"),
+			language,
+			ex.Output)
+		return
+	}
+	if document.style.Flavor == "commonmark" {
+		fmt.Fprintf(w, "%s# %s\n\n%s%s\n\n%s:\n\n```%s\n%s```\n\n",
+			document.style.TypeFunctionHeader,
+			document.commonmarkExampleHeading(ex.Name),
+			paragraph(document, ex.Doc),
+			code,
+			document.style.text("Output"),
+			language,
+			ex.Output)
+		return
+	}
+	fmt.Fprintf(w, "<a name='Example%s'></a><details><summary>%s</summary><p>\n\n%s\n%s\n\n%s:\n```%s\n%s```\n</p></details>\n\n",
+		ex.Name,
+		title,
+		document.docText(ex.Doc),
+		code,
+		document.style.text("Output"),
+		language,
+		ex.Output)
+}
+
+func renderTypeSectionTo(writer io.Writer, document *Document, list []*doc.Type, exs []*doc.Example) {
+	header := document.style.TypeHeader
+
+	for _, entry := range list {
+		renderSymbolHeadingTo(writer, document, header, "type", "", entry.Name, entry.Name, entry.Decl)
+		renderStabilityBadgeTo(writer, document, entry.Decl, entry.Doc)
+		renderChangeLabelTo(writer, document, entry.Decl)
+		if document.style.Flavor == "gomarkdoc" {
+			fmt.Fprintf(writer, "%s%s\n\n%s",
+				paragraph(document, entry.Doc),
+				document.codeOf(entry.Decl),
+				document.referencesOf(entry.Decl)+document.usedByOf(entry.Decl))
+		} else {
+			fmt.Fprintf(writer, "%s\n\n%s%s\n",
+				document.codeOf(entry.Decl),
+				document.referencesOf(entry.Decl)+document.usedByOf(entry.Decl),
+				document.docText(entry.Doc))
+		}
+
+		for _, ex := range filterExamples(exs, entry.Name) {
+			renderExample(writer, document, ex)
+		}
+
+		renderConstantSectionTo(writer, document, entry.Consts, true)
+		renderVariableSectionTo(writer, document, entry.Vars, true)
+		renderFunctionSectionTo(writer, document, entry.Funcs, true, exs)
+		renderMethodsTo(writer, document, entry)
+	}
+}
+
+// inline executes a small template from the style with the document, or is
+// fallback if there is no template (or it fails)
+func (self *Document) inline(template *Template.Template, fallback string) string {
+	if template == nil {
+		return fallback
+	}
+	var result strings.Builder
+	err := template.Execute(&result, self)
+	if err != nil {
+		self.style.log().Warn(fmt.Sprintf("Could not execute -%s", template.Name()), "package", self.absPath, "error", err)
+		return fallback
+	}
+	return result.String()
+}
+
+func renderHeaderTo(writer io.Writer, document *Document) {
+	if document.style.Flavor == "gomarkdoc" {
+		fmt.Fprintf(writer, "<!-- Code generated by gomarkdoc. DO NOT EDIT -->\n\n")
+	}
+	title := document.Name
+	if directive, ok := document.packageDirectives()["title"]; ok {
+		title = directive
+	}
+	fmt.Fprintf(writer, "# %s\n\n", document.inline(document.style.Title, title))
+	var badges []string
+	if level := document.packageStability(); level != "" {
+		badges = append(badges, document.stabilityBadge(level))
+	}
+	if badge := document.testCoverageBadge(); badge != "" {
+		badges = append(badges, badge)
+	}
+	if len(badges) > 0 {
+		fmt.Fprintf(writer, "%s\n\n", strings.Join(badges, " "))
+	}
+	if header := document.inline(document.style.Header, ""); header != "" {
+		fmt.Fprintf(writer, "%s\n\n", strings.TrimRight(header, "\n"))
+	}
+
+	if !document.IsCommand {
+		// Import
+		if document.style.IncludeImport {
+			if document.ImportPath != "" {
+				code := fmt.Sprintf(`import "%s"`, document.ImportPath)
+				code = indentCode(code, document.style)
+				fmt.Fprintf(writer, "%s\n\n", code)
+			}
+		}
+	}
+}
+
+func renderSynopsisTo(writer io.Writer, document *Document) {
+	fmt.Fprintf(writer, "%s\n", document.packageDoc())
+}
+
+func renderUsageTo(writer io.Writer, document *Document) {
+
+	exs := document.Examples
+
+	// Usage
+	fmt.Fprintf(writer, "%s\n", document.style.heading(document.style.UsageHeader))
+
+	// render index, which the headings stand in for when compact
+	if !document.style.Compact {
+		renderIndex(writer, document, exs)
+	}
+
+	// Type diagram
+	renderDiagramTo(writer, document)
+
+	// The examples of the package itself, which the index links to like
+	// those of its symbols
+	for _, ex := range filterExamples(exs, "") {
+		renderExample(writer, document, ex)
+	}
+
+	// Constant Section
+	renderConstantSectionTo(writer, document, document.pkg.Consts, false)
+
+	// Variable Section
+	renderVariableSectionTo(writer, document, document.pkg.Vars, false)
+
+	// Function Section
+	renderFunctionSectionTo(writer, document, document.pkg.Funcs, false, exs)
+
+	// Type Section, without the types on pages of their own
+	renderTypeSectionTo(writer, document, document.unsplitTypes(), exs)
+}
+
+func renderSubpackagesTo(writer io.Writer, document *Document) error {
+	if !document.style.IncludeSubpackages {
+		return nil
+	}
+	list, err := document.subpackages()
+	if err != nil || len(list) == 0 {
+		return err
+	}
+
+	fmt.Fprintf(writer, "%s\n", document.style.heading(document.style.SubpackagesHeader))
+	if document.style.tables() {
+		fmt.Fprintf(writer, "| %s | %s |\n| --- | --- |\n", document.style.text("Package"), document.style.text("Synopsis"))
+	}
+	for _, entry := range list {
+		link := document.linkTo(path.Join(document.ImportPath, entry.Path), "")
+		if link == "" {
+			link = entry.Path
+		}
+		if !document.style.tables() {
+			fmt.Fprintf(writer, " - [%s](%s): %s\n", entry.Path, link, entry.Synopsis)
+		} else {
+			fmt.Fprintf(writer, "| [%s](%s) | %s |\n", entry.Path, link, escapeTableCell(entry.Synopsis))
+		}
+	}
+	fmt.Fprintf(writer, "\n")
+	return nil
+}
+
+func renderFooterTo(writer io.Writer, document *Document) {
+	if footer := document.inline(document.style.Footer, ""); footer != "" {
+		fmt.Fprintf(writer, "\n\n%s\n", strings.TrimRight(footer, "\n"))
+	}
+}
+
+var gomarkdocEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"(", `\(`,
+	")", `\)`,
+	"[", `\[`,
+	"]", `\]`,
+	"*", `\*`,
+	"_", `\_`,
+	"<", `\<`,
+)
+
+// renderIndexEntryTo writes an entry of the index, linking to anchor
+func renderIndexEntryTo(w io.Writer, document *Document, inType bool, text, href string) {
+	if document.style.Flavor == "gomarkdoc" {
+		prefix := ""
+		if inType {
+			prefix = "  "
+		}
+		fmt.Fprintf(w, "%s- [%s](<%s>)\n", prefix, gomarkdocEscaper.Replace(text), href)
+		return
+	}
+	prefix := ""
+	if inType {
+		prefix = "    "
+	}
+	fmt.Fprintf(w, "%s - [%s](%s)\n", prefix, text, href)
+}
+
+// renderFunctionIndexTo lists functions, or the functions and methods of
+// the type typeName
+func renderFunctionIndexTo(w io.Writer, document *Document, list []*doc.Func, typeName string) {
+	for _, e := range list {
+		decl := sourceOfNode(document.fset, e.Decl)
+		renderIndexEntryTo(w, document, typeName != "", decl, document.href(typeName, symbolAnchor(document, e)))
+	}
+}
+
+func renderTypeIndexTo(w io.Writer, document *Document, list []*doc.Type) {
+	for _, e := range list {
+		renderIndexEntryTo(w, document, false, "type "+e.Name, document.href(e.Name, typeAnchor(document, e.Name)))
+		renderFunctionIndexTo(w, document, e.Funcs, e.Name)
+		if document.style.Flavor == "gomarkdoc" {
+			renderFunctionIndexTo(w, document, e.Methods, e.Name)
+		}
+	}
+}
+
+func renderExampleIndexTo(w io.Writer, document *Document, list []*doc.Example) {
+	if len(list) == 0 || document.style.Flavor == "gomarkdoc" {
+		return
+	}
+
+	switch document.style.ExampleIndex {
+	case "off":
+		return
+	case "grouped":
+		renderGroupedExampleIndexTo(w, document, list)
+		return
+	}
+
+	fmt.Fprintf(w, "\n#### %s\n\n", document.style.text("Examples"))
+	for _, e := range list {
+		title := ""
+		if document.style.ExampleTitle != nil {
+			title = document.exampleHeading(e.Name)
+		} else {
+			name, sub := exampleNames(e.Name)
+			if name == "" {
+				name = document.style.text("Package")
+			}
+			title = name + sub
+		}
+		fmt.Fprintf(w, " - [%s](%s)\n", title, document.exampleHref(e.Name))
+	}
+}
+
+// exampleTitleData is what the -example-title template is executed with
+type exampleTitleData struct {
+	Name   string // The name of the example function, without "Example"
+	Symbol string // "F", "T", "T.M", or "" for the package
+	Suffix string // The suffix of the name, with underscores as spaces
+}
+
+// exampleHeading is the title of the example with the given name, from the
+// ExampleTitle template, or "Example (sub name)" without one
+func (self *Document) exampleHeading(name string) string {
+	if title, ok := self.exampleDirectives(name)["title"]; ok {
+		return title
+	}
+	if self.style.ExampleTitle != nil {
+		_, _, suffix := exampleTarget(name)
+		data := exampleTitleData{
+			Name:   name,
+			Symbol: exampleSymbol(name),
+			Suffix: strings.Replace(suffix, "_", " ", -1),
+		}
+		var title strings.Builder
+		err := self.style.ExampleTitle.Execute(&title, data)
+		if err == nil {
+			return title.String()
+		}
+		self.style.log().Warn("Could not execute -example-title", "example", name, "error", err)
+	}
+	_, sub := exampleNames(name)
+	return self.style.text("Example") + sub
+}
+
+// exampleSymbol is the symbol an example is for, as "F", "T", or "T.M", or
+// "" for the package
+func exampleSymbol(name string) string {
+	symbol, method, _ := exampleTarget(name)
+	if method != "" {
+		return symbol + "." + method
+	}
+	return symbol
+}
+
+// exampleTitle names an example like godoc does, e.g. "T.M (Suffix)"
+func exampleTitle(name string, style Style) string {
+	_, _, suffix := exampleTarget(name)
+	title := exampleSymbol(name)
+	if title == "" {
+		title = style.text("Package")
+	}
+	if suffix != "" {
+		title += " (" + strings.Replace(suffix, "_", " ", -1) + ")"
+	}
+	return title
+}
+
+// renderGroupedExampleIndexTo lists the examples under the symbols they are
+// for, with the package's own examples first
+func renderGroupedExampleIndexTo(w io.Writer, document *Document, list []*doc.Example) {
+	var symbols []string
+	grouped := map[string][]*doc.Example{}
+	for _, e := range list {
+		symbol := exampleSymbol(e.Name)
+		if grouped[symbol] == nil {
+			symbols = append(symbols, symbol)
+		}
+		grouped[symbol] = append(grouped[symbol], e)
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i] == "" && symbols[j] != ""
+	})
+
+	fmt.Fprintf(w, "\n#### %s\n\n", document.style.text("Examples"))
+	for _, symbol := range symbols {
+		if symbol == "" {
+			fmt.Fprintf(w, " - %s\n", document.style.text("Package"))
+		} else if document.anchorOf(symbol) == "" {
+			fmt.Fprintf(w, " - %s\n", symbol)
+		} else {
+			fmt.Fprintf(w, " - [%s](%s)\n", symbol, document.href(exampleType(symbol), document.anchorOf(symbol)))
+		}
+		for _, e := range grouped[symbol] {
+			title := exampleTitle(e.Name, document.style)
+			if document.style.ExampleTitle != nil {
+				title = document.exampleHeading(e.Name)
+			}
+			fmt.Fprintf(w, "     - [%s](%s)\n", title, document.exampleHref(e.Name))
+		}
+	}
+}
+
+func renderIndex(w io.Writer, d *Document, exs []*doc.Example) {
+	if d.style.Flavor == "gomarkdoc" {
+		if len(d.pkg.Consts) > 0 {
+			renderIndexEntryTo(w, d, false, d.style.text("Constants"), "#constants")
+		}
+		if len(d.pkg.Vars) > 0 {
+			renderIndexEntryTo(w, d, false, d.style.text("Variables"), "#variables")
+		}
+	}
+	funcs, types, categories := d.categorize()
+	renderFunctionIndexTo(w, d, funcs[""], "")
+	renderTypeIndexTo(w, d, types[""])
+	for _, category := range categories {
+		fmt.Fprintf(w, "\n**%s**\n\n", category)
+		renderFunctionIndexTo(w, d, funcs[category], "")
+		renderTypeIndexTo(w, d, types[category])
+	}
+	renderExampleIndexTo(w, d, exs)
+	fmt.Fprintf(w, "\n")
+}
+
+// categorize groups the functions and types of the package by their
+// "//godocdown:category" directives, with "" for those without one, and
+// lists the categories in order
+func (self *Document) categorize() (map[string][]*doc.Func, map[string][]*doc.Type, []string) {
+	funcs := map[string][]*doc.Func{}
+	types := map[string][]*doc.Type{}
+	seen := map[string]bool{"": true}
+	var categories []string
+	add := func(category string) {
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	for _, entry := range self.pkg.Funcs {
+		category := self.category(entry.Decl)
+		funcs[category] = append(funcs[category], entry)
+		add(category)
+	}
+	for _, entry := range self.pkg.Types {
+		category := self.category(entry.Decl)
+		types[category] = append(types[category], entry)
+		add(category)
+	}
+	sort.Strings(categories)
+	return funcs, types, categories
+}
@@ -0,0 +1,124 @@
+package docdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGomarkdocStyle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/tp\n",
+		"tp.go":  "// Package tp is a test package.\npackage tp\n\n// Client is a client.\ntype Client struct{}\n\n// NewClient makes a Client.\nfunc NewClient() *Client { return nil }\n\n// Close closes it.\nfunc (self *Client) Close() {}\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	style := GomarkdocStyle
+	style.NoTemplate = true
+	style.RepositoryURL = "https://github.com/o/tp"
+	output, err := document.Render(style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"<!-- Code generated by gomarkdoc. DO NOT EDIT -->\n\n# tp\n",
+		"## Index\n\n- [type Client](<#Client>)\n  - [func NewClient\\(\\) \\*Client](<#NewClient>)\n  - [func \\(self \\*Client\\) Close\\(\\)](<#Client.Close>)\n",
+		"<a name=\"Client\"></a>\n## type [Client](<https://github.com/o/tp/blob/main/tp.go#L5>)\n",
+		"<a name=\"NewClient\"></a>\n### func [NewClient](<https://github.com/o/tp/blob/main/tp.go#L8>)\n",
+		"<a name=\"Client.Close\"></a>\n### func \\(\\*Client\\) [Close](<https://github.com/o/tp/blob/main/tp.go#L11>)\n",
+		"\nGenerated by [gomarkdoc](<https://github.com/princjef/gomarkdoc>)\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("the output is missing\n%s\nin\n%s", expected, output)
+		}
+	}
+}
+
+func TestPackageExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/tp\n",
+		"tp.go":      "// Package tp is a test package.\npackage tp\n\n// F does.\nfunc F() {}\n",
+		"tp_test.go": "package tp\n\nfunc Example() { F() }\n\nfunc Example_second() { F() }\n\nfunc ExampleF() { F() }\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		flavor   string
+		expected []string
+	}{
+		{"github", []string{" - [Package](#Example)\n", " - [Package (second)](#Example_second)\n", "<a name='Example'></a>", "<a name='Example_second'></a>"}},
+		{"commonmark", []string{"[Package](#example-package)", "[Package (second)](#example-package-second)"}},
+	} {
+		style := DefaultStyle
+		style.Flavor = test.flavor
+		style.NoTemplate = true
+		output, err := document.Render(style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range test.expected {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%s: the output is missing %q:\n%s", test.flavor, expected, output)
+			}
+		}
+
+		// As -check-links=strict checks them
+		page := filepath.Join(dir, test.flavor+".md")
+		if err := os.WriteFile(page, []byte(output), 0644); err != nil {
+			t.Fatal(err)
+		}
+		broken, err := checkOutputLinks([]string{page})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, link := range broken {
+			t.Errorf("%s: broken link %+v", test.flavor, link)
+		}
+	}
+}
+
+func TestEnvCommandOnly(t *testing.T) {
+	for _, test := range []struct {
+		pkg     string
+		table   bool
+		warning bool
+	}{
+		{"main", true, false},
+		{"tp", false, true},
+	} {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"go.mod": "module example.com/tp\n",
+			"tp.go":  "// Package " + test.pkg + " reads the environment.\n//\n//godocdown:env API_TOKEN The token to use\npackage " + test.pkg + "\n\nfunc main() {}\n",
+		})
+		style := DefaultStyle
+		style.NoTemplate = true
+		document, err := loadDocument(context.Background(), dir, style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := document.Render(style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if table := strings.Contains(output, "API_TOKEN"); table != test.table {
+			t.Errorf("package %s: the table is there %v, expected %v:\n%s", test.pkg, table, test.table, output)
+		}
+		_, warnings := document.check()
+		warned := false
+		for _, warning := range warnings {
+			warned = warned || strings.Contains(warning.Message, "only documented for commands")
+		}
+		if warned != test.warning {
+			t.Errorf("package %s: warned %v, expected %v: %v", test.pkg, warned, test.warning, warnings)
+		}
+	}
+}
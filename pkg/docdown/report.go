@@ -0,0 +1,121 @@
+package docdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	Time "time"
+)
+
+// packageReport is the -report entry for a single package
+type packageReport struct {
+	Target     string    `json:"target"`
+	ImportPath string    `json:"importPath,omitempty"`
+	Output     string    `json:"output"`
+	Bytes      int64     `json:"bytes"`
+	Cached     bool      `json:"cached"`
+	DurationMs float64   `json:"durationMs"`
+	Coverage   *coverage `json:"coverage,omitempty"`
+	Stats      *stats    `json:"stats,omitempty"`
+	Warnings   []warning `json:"warnings"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// report summarizes a run so pipelines can post summaries or detect partial
+// failures
+type report struct {
+	Packages   []packageReport `json:"packages"`
+	Failed     int             `json:"failed"`
+	Warnings   int             `json:"warnings"`
+	DurationMs float64         `json:"durationMs"`
+}
+
+func milliseconds(duration Time.Duration) float64 {
+	return float64(duration) / float64(Time.Millisecond)
+}
+
+func newReport(jobs []*job, elapsed Time.Duration) *report {
+	result := &report{
+		Packages:   []packageReport{},
+		DurationMs: milliseconds(elapsed),
+	}
+	for _, job := range jobs {
+		entry := packageReport{
+			Target:     job.target,
+			ImportPath: job.info.ImportPath,
+			Output:     job.path,
+			Bytes:      job.written,
+			Cached:     job.cached,
+			DurationMs: milliseconds(job.duration),
+			Warnings:   job.info.Warnings,
+		}
+		if entry.Output == "" {
+			entry.Output = "-"
+		}
+		if entry.Warnings == nil {
+			entry.Warnings = []warning{}
+		}
+		if job.info.Coverage.Total > 0 {
+			entry.Coverage = &job.info.Coverage
+		}
+		if job.err == nil {
+			entry.Stats = &job.info.Stats
+		}
+		if job.err != nil {
+			entry.Error = job.err.Error()
+			result.Failed++
+		}
+		result.Warnings += len(entry.Warnings)
+		result.Packages = append(result.Packages, entry)
+	}
+	return result
+}
+
+// writeReport writes the -report file, if one was requested
+func writeReport(jobs []*job, elapsed Time.Duration) error {
+	if *flag_report == "" {
+		return nil
+	}
+	return writeOutputTo(*flag_report, os.Stdout, func(writer io.Writer) error {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newReport(jobs, elapsed))
+	})
+}
+
+// shieldsEndpoint is the JSON that a shields.io endpoint badge reads
+// (https://shields.io/badges/endpoint-badge)
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// newCoverageBadge is the badge for the documentation coverage of all the
+// packages of a run, counting every exported symbol alike
+func newCoverageBadge(jobs []*job) shieldsEndpoint {
+	var total coverage
+	for _, job := range jobs {
+		total.Documented += job.info.Coverage.Documented
+		total.Total += job.info.Coverage.Total
+	}
+	badge := shieldsEndpoint{SchemaVersion: 1, Label: "docs", Message: "n/a", Color: "lightgrey"}
+	if total.Total > 0 {
+		total.Percent = 100 * float64(total.Documented) / float64(total.Total)
+		badge.Message = fmt.Sprintf("%.0f%%", total.Percent)
+		badge.Color = coverageColor(total.Percent)
+	}
+	return badge
+}
+
+// writeCoverageBadge writes the -coverage-badge file, if one was requested
+func writeCoverageBadge(jobs []*job) error {
+	if *flag_coverageBadge == "" {
+		return nil
+	}
+	return writeOutputTo(*flag_coverageBadge, os.Stdout, func(writer io.Writer) error {
+		return json.NewEncoder(writer).Encode(newCoverageBadge(jobs))
+	})
+}
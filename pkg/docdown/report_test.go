@@ -0,0 +1,67 @@
+package docdown
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	Time "time"
+)
+
+// captureStdout runs fn with os.Stdout going to a file, and returns what it
+// wrote there
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	stdout := os.Stdout
+	os.Stdout = file
+	defer func() {
+		os.Stdout = stdout
+	}()
+	fn()
+	contents, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contents
+}
+
+func TestWriteReportToStdout(t *testing.T) {
+	given := *flag_report
+	defer func() {
+		*flag_report = given
+	}()
+
+	jobs := []*job{
+		{target: "./a", path: "a/README.md"},
+		{target: "./b", err: errors.New("Could not parse")},
+	}
+	for _, path := range []string{"-", ""} {
+		*flag_report = path
+		var err error
+		output := captureStdout(t, func() {
+			err = writeReport(jobs, Time.Second)
+		})
+		if err != nil {
+			t.Fatalf("-report=%q: %v", path, err)
+		}
+		if path == "" {
+			if len(output) != 0 {
+				t.Errorf("-report=\"\" wrote %q, expected nothing", output)
+			}
+			continue
+		}
+		var result report
+		if err := json.Unmarshal(output, &result); err != nil {
+			t.Fatalf("-report=-: %v in %q", err, output)
+		}
+		if len(result.Packages) != 2 || result.Failed != 1 || result.Packages[1].Error != "Could not parse" {
+			t.Errorf("-report=- wrote %+v", result)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package docdown
+
+import (
+	"bytes"
+	Flag "flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateFile is the template "godocdown init" writes, one of those
+// godocdown looks for in the directory of a package
+const templateFile = ".godocdown.template"
+
+// starterTemplate is the template "godocdown init" writes: the parts of the
+// standard documentation, in the order EmitTo writes them, to rearrange.
+// As each part is trimmed, those that are not empty are followed by a blank
+// line.
+const starterTemplate = `{{- /*
+This template renders the documentation of the package. As it is, it renders
+the parts of what godocdown writes without one, in the same order: move, take
+out, or add to them. See "godocdown -h" for what templates can use, and
+"godocdown -schema" for a JSON Schema of it, for editors.
+*/ -}}
+{{ with .EmitHeader }}{{ . }}
+
+{{ end -}}
+{{ with .EmitSynopsis }}{{ . }}
+
+{{ end -}}
+{{ with .EmitQuickstart }}{{ . }}
+
+{{ end -}}
+{{- /* Commands leave out their usage, unless -command-api */ -}}
+{{ if not .IsCommand }}{{ with .EmitUsage }}{{ . }}
+
+{{ end }}{{ end -}}
+{{ with .EmitSections }}{{ . }}
+
+{{ end -}}
+{{ with .EmitEnv }}{{ . }}
+
+{{ end -}}
+{{ with .EmitExitCodes }}{{ . }}
+
+{{ end -}}
+{{ with .EmitConfiguration }}{{ . }}
+
+{{ end -}}
+{{ with .EmitGlossary }}{{ . }}
+
+{{ end -}}
+{{ with .EmitPlugins }}{{ . }}
+
+{{ end -}}
+{{ with .EmitStability }}{{ . }}
+
+{{ end -}}
+{{ with .EmitStats }}{{ . }}
+
+{{ end -}}
+{{ with .EmitBenchmarks }}{{ . }}
+
+{{ end -}}
+{{ with .EmitImports }}{{ . }}
+
+{{ end -}}
+{{ with .EmitEmbeds }}{{ . }}
+
+{{ end -}}
+{{ with .EmitSubpackages }}{{ . }}
+
+{{ end -}}
+`
+
+// initFlags are the flags of "godocdown init": all of those of godocdown,
+// which it writes the configuration of, -force, and -readme
+func initFlags() (*Flag.FlagSet, *bool, *bool) {
+	initFlag := subcommandFlags("init", runFlags...)
+	force := initFlag.Bool("force", false, "Overwrite the configuration and the template if they exist")
+	readme := initFlag.Bool("readme", false, "Add markers for the documentation to the end of -output (README.md by default)")
+	return initFlag, force, readme
+}
+
+// runInit implements "godocdown init", which writes a configuration file
+// with every option, commented out with its default but for those given on
+// the command line, and a template that renders the standard documentation,
+// to start customizing them from. With -readme, it adds markers for the
+// documentation to the README, so that the rest of it is kept.
+func runInit(arguments []string) int {
+	initFlag, force, readme := initFlags()
+	initFlag.Parse(arguments)
+
+	err := setupLogger(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+	if initFlag.NArg() > 0 {
+		logger.Error("Cannot give packages to init: it sets up the current directory")
+		return 2
+	}
+	given := map[string]bool{}
+	initFlag.Visit(func(f *Flag.Flag) {
+		given[f.Name] = true
+	})
+	config := *flag_config
+	if config == "" {
+		config = configFile
+	}
+	if !*force {
+		for _, path := range []string{config, templateFile} {
+			if _, err := os.Stat(path); err == nil {
+				logger.Error("Would overwrite a file; use -force to overwrite it", "path", path)
+				return 1
+			}
+		}
+	}
+
+	err = writeOutputTo(config, os.Stdout, func(writer io.Writer) error {
+		return writeStarterConfig(writer, given)
+	})
+	if err == nil {
+		err = writeOutputTo(templateFile, os.Stdout, func(writer io.Writer) error {
+			_, err := io.WriteString(writer, starterTemplate)
+			return err
+		})
+	}
+	if err == nil && *readme {
+		err = addReadmeMarkers()
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	logger.Info("wrote the configuration and the template", "config", config, "template", templateFile)
+	return 0
+}
+
+// writeStarterConfig writes a configuration file with the options, with
+// their usage, each commented out with its default unless it is given
+func writeStarterConfig(writer io.Writer, given map[string]bool) error {
+	var config bytes.Buffer
+	config.WriteString("# The options of godocdown (see \"godocdown -h\"), which the command line\n")
+	config.WriteString("# takes precedence over. Those commented out are at their defaults.\n")
+	flag.VisitAll(func(f *Flag.Flag) {
+		if f.Usage == string(rune(0)) || f.Name == "config" {
+			return
+		}
+		config.WriteString("\n# " + f.Usage + "\n")
+		prefix := "# "
+		if given[f.Name] {
+			prefix = ""
+		}
+		if list, ok := f.Value.(*stringList); ok {
+			if !given[f.Name] || len(*list) == 0 {
+				config.WriteString(prefix + f.Name + ": []\n")
+				return
+			}
+			config.WriteString(f.Name + ":\n")
+			for _, value := range *list {
+				config.WriteString("  - " + yamlScalar(value) + "\n")
+			}
+			return
+		}
+		value := f.DefValue
+		if given[f.Name] {
+			value = f.Value.String()
+		}
+		config.WriteString(prefix + f.Name + ": " + yamlScalar(value) + "\n")
+	})
+	_, err := writer.Write(config.Bytes())
+	return err
+}
+
+// yamlScalar is value as YAML, as it is for numbers and booleans, and quoted
+// as YAML needs it otherwise
+func yamlScalar(value string) string {
+	if _, err := strconv.ParseBool(value); err == nil {
+		return value
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return strconv.Quote(value)
+	}
+	return strings.TrimSuffix(string(encoded), "\n")
+}
+
+// addReadmeMarkers adds the markers for the documentation to the end of the
+// output file (README.md by default), unless it has them
+func addReadmeMarkers() error {
+	path := flag_output
+	if path == "" || path == "-" {
+		path = "README.md"
+	}
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if bytes.Contains(current, []byte(readmeStart)) {
+		return nil
+	}
+	if len(current) > 0 && !bytes.HasSuffix(current, []byte("\n")) {
+		current = append(current, '\n')
+	}
+	if len(current) > 0 {
+		current = append(current, '\n')
+	}
+	return writeOutputTo(path, os.Stdout, func(writer io.Writer) error {
+		_, err := fmt.Fprintf(writer, "%s%s\n%s\n", current, readmeStart, readmeEnd)
+		return err
+	})
+}
@@ -0,0 +1,150 @@
+package docdown
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// schemaURL is the JSON Schema dialect of templateSchema
+const schemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// templateDescriptions describe what templates can use of the document
+// they are executed with, for templateSchema
+var templateDescriptions = map[string]string{
+	"Name":              "The name of the package, or of the command's directory",
+	"ImportPath":        "The import path of the package, or \"\" if godocdown could not tell it",
+	"IsCommand":         "Whether the package is a command (package main, or package documentation)",
+	"Examples":          "The examples of the package, in the order they are documented in",
+	"Directive":         "The argument of a directive (the second argument) of a symbol like F, T, or T.M (the first), or of the package comment (\"\")",
+	"Emit":              "The standard documentation, what godocdown writes without a template",
+	"EmitHeader":        "The title of the package and its import line",
+	"EmitSynopsis":      "The package comment",
+	"EmitQuickstart":    "The code of the quick start example (with -quickstart)",
+	"EmitUsage":         "The index, and the constants, variables, functions, and types of the package",
+	"EmitStats":         "The counts of the package's types, functions, methods, examples, files, and lines (with -stats)",
+	"Stats":             "The counts of the package's types, functions, methods, examples, files, and lines",
+	"EmitSections":      "The sections taken out of the package comment with -section",
+	"EmitSection":       "The section of the package comment with the given heading, which is then left out of EmitSections and Emit",
+	"EmitEnv":           "The environment variables documented with \"//godocdown:env\" directives",
+	"EmitExitCodes":     "The exit codes of a command",
+	"EmitConfiguration": "The configuration references of structs (with -config-struct or \"//godocdown:config\")",
+	"EmitGlossary":      "The terms defined with \"//godocdown:term\" directives",
+	"EmitPlugins":       "The sections added by plugins (with -plugin)",
+	"EmitStability":     "The experimental and beta symbols (with -stability-summary)",
+	"EmitBenchmarks":    "The benchmark results of the package (with -run-benchmarks)",
+	"EmitImports":       "The packages imported from outside the module (with -imports)",
+	"EmitEmbeds":        "The files embedded with //go:embed (with -embeds)",
+	"EmitDiagram":       "The Mermaid diagram of the package's types (with -diagram)",
+	"EmitSubpackages":   "The packages in the directories below this one (with -subpackages)",
+	"EmitFooter":        "The footer (see -footer), which is added at the end otherwise",
+	"EmitSignature":     "The old name of EmitFooter",
+	"Badge":             "A Markdown badge linking to godocdown",
+	"ToCode":            "Its argument as a code block of the style",
+	"Synopsis":          "The package comment, as Markdown",
+	"Import":            "The import declaration of the package",
+	"Funcs":             "The functions of the package that are not constructors of its types",
+	"Types":             "The types of the package, with their constants, variables, constructors, and methods",
+	"Consts":            "The constants of the package that are not of its types",
+	"Vars":              "The variables of the package that are not of its types",
+	"TestCoverage":      "The test coverage of the package (with -test-coverage)",
+}
+
+// templateSchema is a JSON Schema of the data templates are executed with:
+// the exported fields of the document, and its methods that take only
+// strings (their number is "x-arguments") and return one value. It is made
+// from the types themselves, so that it is always the data templates get.
+func templateSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	properties := map[string]interface{}{}
+	kind := reflect.TypeOf(&Document{})
+	for i := 0; i < kind.Elem().NumField(); i++ {
+		field := kind.Elem().Field(i)
+		if field.IsExported() {
+			properties[field.Name] = describe(schemaOf(field.Type, defs), templateDescriptions[field.Name])
+		}
+	}
+	for i := 0; i < kind.NumMethod(); i++ {
+		method := kind.Method(i)
+		arguments := method.Type.NumIn() - 1
+		simple := method.Type.NumOut() == 1 || (method.Type.NumOut() == 2 && method.Type.Out(1).Implements(reflect.TypeOf((*error)(nil)).Elem()))
+		for j := 1; j < method.Type.NumIn(); j++ {
+			simple = simple && method.Type.In(j).Kind() == reflect.String
+		}
+		if !simple {
+			continue
+		}
+		schema := describe(schemaOf(method.Type.Out(0), defs), templateDescriptions[method.Name])
+		if arguments > 0 {
+			schema["x-arguments"] = arguments
+		}
+		properties[method.Name] = schema
+	}
+	return map[string]interface{}{
+		"$schema":     schemaURL,
+		"title":       "godocdown template data",
+		"description": "What templates (see -template) are executed with: {{ .Name }}, {{ .EmitUsage }}, {{ .Directive \"T.M\" \"weight\" }}, and the like",
+		"type":        "object",
+		"properties":  properties,
+		"$defs":       defs,
+	}
+}
+
+// describe adds a description to schema, unless it is ""
+func describe(schema map[string]interface{}, description string) map[string]interface{} {
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
+
+// schemaOf is the JSON Schema of a Go type, with the structs it refers to
+// added to defs (by their qualified names, like doc.Func). The nodes of
+// go/ast and go/token are left opaque.
+func schemaOf(kind reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	for kind.Kind() == reflect.Pointer {
+		kind = kind.Elem()
+	}
+	switch kind.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(kind.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(kind.Elem(), defs)}
+	case reflect.Struct:
+		name := strings.TrimPrefix(kind.String(), "main.")
+		if kind.PkgPath() == "go/ast" || kind.PkgPath() == "go/token" {
+			return map[string]interface{}{"type": "object", "description": "The " + name + " node (see https://pkg.go.dev/" + kind.PkgPath() + ")"}
+		}
+		ref := map[string]interface{}{"$ref": "#/$defs/" + name}
+		if _, ok := defs[name]; ok {
+			return ref
+		}
+		properties := map[string]interface{}{}
+		defs[name] = map[string]interface{}{"type": "object", "properties": properties}
+		for i := 0; i < kind.NumField(); i++ {
+			if field := kind.Field(i); field.IsExported() {
+				properties[field.Name] = schemaOf(field.Type, defs)
+			}
+		}
+		return ref
+	}
+	// An interface, like an ast.Node
+	return map[string]interface{}{}
+}
+
+// writeTemplateSchema writes the JSON Schema of the template data
+func writeTemplateSchema(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(templateSchema())
+}
@@ -0,0 +1,242 @@
+package docdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// symbol is a package or one of its symbols, as the indexes of the
+// documentation (-format=docset and -search-index) list it
+type symbol struct {
+	name string // Like "Client" or "Client.Close", or the import path of the package
+	kind string // As Dash names them: Package, Type, Struct, Function, Method, ...
+	href string // The link to it from the page of the package, or "" for the page
+	doc  string
+}
+
+// symbols are the package and its symbols. Constants and variables have no
+// anchors of their own, so they link to the page, or the type they are
+// declared with.
+func (self *Document) symbols() []symbol {
+	name := self.ImportPath
+	if name == "" {
+		name = self.Name
+	}
+	symbols := []symbol{{name, "Package", "", self.pkg.Doc}}
+	values := func(list []*doc.Value, href string) {
+		for _, value := range list {
+			kind := "Variable"
+			if value.Decl.Tok == token.CONST {
+				kind = "Constant"
+			}
+			for _, name := range value.Names {
+				symbols = append(symbols, symbol{name, kind, href, value.Doc})
+			}
+		}
+	}
+	funcs := func(list []*doc.Func, typeName string) {
+		for _, function := range list {
+			name, kind := function.Name, "Function"
+			if function.Recv != "" {
+				name, kind = typeName+"."+function.Name, "Method"
+			}
+			symbols = append(symbols, symbol{name, kind, self.href(typeName, symbolAnchor(self, function)), function.Doc})
+		}
+	}
+
+	values(self.pkg.Consts, "")
+	values(self.pkg.Vars, "")
+	funcs(self.pkg.Funcs, "")
+	for _, entry := range self.pkg.Types {
+		kind := "Type"
+		if spec := typeSpecOf(entry); spec != nil {
+			switch spec.Type.(type) {
+			case *ast.InterfaceType:
+				kind = "Interface"
+			case *ast.StructType:
+				kind = "Struct"
+			}
+		}
+		href := self.href(entry.Name, typeAnchor(self, entry.Name))
+		symbols = append(symbols, symbol{entry.Name, kind, href, entry.Doc})
+		values(entry.Consts, href)
+		values(entry.Vars, href)
+		funcs(entry.Funcs, entry.Name)
+		funcs(entry.Methods, entry.Name)
+	}
+	return symbols
+}
+
+// link is the link to the symbol, documented in the package at page
+func (self symbol) link(page string) string {
+	switch {
+	case self.href == "":
+		return page
+	case strings.HasPrefix(self.href, "#"):
+		return page + self.href
+	}
+	return path.Join(path.Dir(page), self.href)
+}
+
+// searchEntry is a document of the search index of -search-index, for lunr
+// or elasticlunr to index
+type searchEntry struct {
+	ID       string `json:"id"`   // The page, with the name of the symbol after a #
+	Href     string `json:"href"` // The link to it, relative to the index
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Package  string `json:"package"`
+	Synopsis string `json:"synopsis"`
+	Text     string `json:"text"`
+}
+
+// searchIndex is the name of the search index of -search-index, and
+// searchScript of the script that searches it
+const (
+	searchIndex  = "search.json"
+	searchScript = "search.js"
+)
+
+// searchEntries are the entries of the search index for the package, and
+// its symbols, documented at page. Their ids are unique, even for the
+// symbols that link to the page itself (like constants) and so share an
+// href.
+func (self *Document) searchEntries(page, importPath string) []searchEntry {
+	var entries []searchEntry
+	for _, symbol := range self.symbols() {
+		id := page
+		if symbol.kind != "Package" {
+			id += "#" + symbol.name
+		}
+		entries = append(entries, searchEntry{
+			ID:       id,
+			Href:     symbol.link(page),
+			Name:     symbol.name,
+			Kind:     symbol.kind,
+			Package:  importPath,
+			Synopsis: doc.Synopsis(symbol.doc),
+			Text:     strings.TrimSpace(symbol.doc),
+		})
+	}
+	return entries
+}
+
+// writeSearchIndex writes the search index of the packages of jobs, with
+// -search-index: a JSON list of the packages and their symbols, with their
+// synopses and doc text, for lunr or elasticlunr to index on a statically
+// hosted site, and a script that does that, next to it in the -out-dir
+func writeSearchIndex(jobs []*job) error {
+	dir, err := filepath.Abs(pagesDir())
+	if err != nil {
+		return err
+	}
+	entries := []searchEntry{}
+	for _, job := range jobs {
+		if job.err != nil || job.document == nil {
+			continue
+		}
+		page, err := filepath.Rel(dir, job.path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, job.document.searchEntries(filepath.ToSlash(page), job.info.ImportPath)...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+
+	err = writeOutputTo(filepath.Join(dir, searchIndex), os.Stdout, func(writer io.Writer) error {
+		encoder := json.NewEncoder(writer)
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(entries)
+	})
+	if err == nil {
+		err = writeOutputTo(filepath.Join(dir, searchScript), os.Stdout, func(writer io.Writer) error {
+			_, err := io.WriteString(writer, searchSnippet)
+			return err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing the search index: %v", err)
+	}
+	logger.Info("wrote the search index", "path", filepath.Join(dir, searchIndex), "entries", len(entries))
+	return nil
+}
+
+// searchSnippet is the script -search-index writes next to the index, to
+// search it from a page of the site with lunr
+const searchSnippet = `// Searches the documentation written by godocdown, with lunr
+// (https://lunrjs.com). Add it to a page of the site, next to search.json:
+//
+//	<input id="godocdown-search" type="search" placeholder="Search">
+//	<ul id="godocdown-results"></ul>
+//	<script src="https://unpkg.com/lunr/lunr.min.js"></script>
+//	<script src="search.js" data-extension=".html"></script>
+//
+// data-extension, if given, replaces the .md of the links, for sites that
+// serve the pages as HTML.
+(function () {
+	var script = document.currentScript;
+	var base = script.src.replace(/[^/]*$/, "");
+	var extension = script.getAttribute("data-extension");
+	var input = document.getElementById("godocdown-search");
+	var results = document.getElementById("godocdown-results");
+	if (!input || !results) {
+		return;
+	}
+
+	fetch(base + "search.json").then(function (response) {
+		return response.json();
+	}).then(function (entries) {
+		var byID = {};
+		var index = lunr(function () {
+			this.ref("id");
+			this.field("name", { boost: 10 });
+			this.field("synopsis", { boost: 2 });
+			this.field("text");
+			entries.forEach(function (entry) {
+				byID[entry.id] = entry;
+				this.add(entry);
+			}, this);
+		});
+
+		input.addEventListener("input", function () {
+			results.innerHTML = "";
+			var query = input.value.trim();
+			if (query === "") {
+				return;
+			}
+			var found;
+			try {
+				found = index.search(query);
+			} catch (error) {
+				// Not a valid query, as with a trailing ":"
+				found = index.search(query.replace(/[^\w\s.]/g, " "));
+			}
+			found.slice(0, 20).forEach(function (result) {
+				var entry = byID[result.ref];
+				var href = entry.href;
+				if (extension) {
+					href = href.replace(/\.md(#|$)/, extension + "$1");
+				}
+				var item = document.createElement("li");
+				var link = document.createElement("a");
+				link.href = base + href;
+				link.textContent = entry.name;
+				item.appendChild(link);
+				item.appendChild(document.createTextNode(" " + entry.kind.toLowerCase() + " in " + entry.package + (entry.synopsis ? ": " + entry.synopsis : "")));
+				results.appendChild(item);
+			});
+		});
+	});
+})();
+`
@@ -0,0 +1,39 @@
+package docdown
+
+import (
+	"testing"
+)
+
+func TestSearchEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/tp\n",
+		"tp.go":  "// Package tp is a test package.\npackage tp\n\n// Limits.\nconst (\n\tMin = 1\n\tMax = 2\n)\n\n// Default is the default.\nvar Default = 3\n\n// Client is a client.\ntype Client struct{}\n\n// Close closes it.\nfunc (self *Client) Close() {}\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hrefs := map[string]string{}
+	for _, entry := range document.searchEntries("tp/README.md", "example.com/tp") {
+		if _, ok := hrefs[entry.ID]; ok {
+			t.Errorf("the id %q is not unique", entry.ID)
+		}
+		hrefs[entry.ID] = entry.Href
+	}
+	for id, href := range map[string]string{
+		"tp/README.md":              "tp/README.md",
+		"tp/README.md#Min":          "tp/README.md",
+		"tp/README.md#Max":          "tp/README.md",
+		"tp/README.md#Default":      "tp/README.md",
+		"tp/README.md#Client":       "tp/README.md#Client",
+		"tp/README.md#Client.Close": "tp/README.md#Close",
+	} {
+		if hrefs[id] != href {
+			t.Errorf("the href of %q is %q, expected %q", id, hrefs[id], href)
+		}
+	}
+	if len(hrefs) != 6 {
+		t.Errorf("the entries are %v", hrefs)
+	}
+}
@@ -0,0 +1,149 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// docSection is a section of the package comment, from its "# Heading" up
+// to the next heading, that -section takes out of the comment: to put it
+// after the documentation of the API, or on a page of its own
+type docSection struct {
+	Heading string
+	Text    string // The body of the section, without its heading
+	Page    string // Relative to the page of the package, or "" to move it
+}
+
+// sectionPages reports whether the style puts sections on pages of their own
+func (self Style) sectionPages() bool {
+	for _, page := range self.Sections {
+		if page != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionPage is the page the style puts the section with the given heading
+// on, and whether it takes it out of the package comment at all
+func (self Style) sectionPage(heading string) (string, bool) {
+	for name, page := range self.Sections {
+		if strings.EqualFold(name, heading) {
+			return page, true
+		}
+	}
+	return "", false
+}
+
+// packageSections splits the package comment into what stays at the top of
+// the documentation and the sections -section takes out, in order. A
+// section that goes on a page of its own leaves a link to the page in its
+// place, unless there is no page to write (as on stdout), when it is moved.
+func (self *Document) packageSections() (string, []docSection) {
+	if len(self.style.Sections) == 0 {
+		return self.pkg.Doc, nil
+	}
+	var rest strings.Builder
+	var sections []docSection
+	current := -1
+	for _, line := range strings.SplitAfter(self.pkg.Doc, "\n") {
+		if heading, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "# "); ok {
+			current = -1
+			page, ok := self.style.sectionPage(heading)
+			if ok {
+				if self.page == "" || self.page == "-" {
+					page = ""
+				}
+				sections = append(sections, docSection{Heading: heading, Page: page})
+				current = len(sections) - 1
+				if page != "" {
+					fmt.Fprintf(&rest, "%s\n%s [%s](%s).\n\n", line, self.style.text("See"), heading, filepath.ToSlash(page))
+				}
+				continue
+			}
+		}
+		if current >= 0 {
+			sections[current].Text += line
+			continue
+		}
+		rest.WriteString(line)
+	}
+	for i := range sections {
+		sections[i].Text = strings.TrimSpace(sections[i].Text) + "\n"
+	}
+	return strings.TrimRight(rest.String(), "\n") + "\n", sections
+}
+
+// sectionText is the body of a section as it should be rendered in Markdown
+func (self *Document) sectionText(section docSection) string {
+	return self.rewriteAssets(self.includeFiles(headifySynopsis(self.commentText(section.Text), self.style)))
+}
+
+// renderSectionTo writes a section taken out of the package comment, with
+// its heading
+func renderSectionTo(writer io.Writer, document *Document, section docSection) {
+	fmt.Fprintf(writer, "# %s\n\n%s\n", section.Heading, document.sectionText(section))
+}
+
+// renderSectionsTo writes the sections moved out of the package comment
+// that a template has not written already
+func renderSectionsTo(writer io.Writer, document *Document) {
+	_, sections := document.packageSections()
+	for _, section := range sections {
+		if section.Page == "" && !document.sectionsEmitted[strings.ToLower(section.Heading)] {
+			renderSectionTo(writer, document, section)
+		}
+	}
+}
+
+// writeSectionPages writes the page of each section that -section puts on a
+// page of its own, next to output
+func writeSectionPages(ctx context.Context, document *Document, output string) error {
+	_, sections := document.packageSections()
+	for _, section := range sections {
+		if section.Page == "" {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(output), section.Page)
+		index, err := filepath.Rel(filepath.Dir(path), output)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+		err = writeDocumentTo(ctx, document.style, path, nil, func(writer io.Writer) error {
+			headings := newHeadingWriter(writer, document.style)
+			renderSectionPageTo(headings, document, section, filepath.ToSlash(index), path)
+			return headings.Close()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderSectionPageTo renders the page of a section, at page, with a link
+// back to the page of its package (or breadcrumbs)
+func renderSectionPageTo(writer io.Writer, document *Document, section docSection, index, page string) {
+	// The glossary is on the page of the package
+	document.termPage = index
+	defer func() {
+		document.termPage = ""
+	}()
+	renderBreadcrumbsTo(writer, document, page, section.Heading)
+	fmt.Fprintf(writer, "# %s\n\n", section.Heading)
+	if !document.style.Breadcrumbs {
+		fmt.Fprintf(writer, "[%s](%s)\n\n", document.Name, index)
+	}
+	fmt.Fprintf(writer, "%s", document.sectionText(section))
+	if links := emitString(func(writer io.Writer) { renderPageLinksTo(writer, document, page) }); links != "" {
+		fmt.Fprintf(writer, "\n\n%s\n", links)
+	}
+}
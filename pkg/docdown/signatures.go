@@ -0,0 +1,388 @@
+package docdown
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// printerProfile is how the code of declarations is printed
+type printerProfile struct {
+	Mode     printer.Mode
+	Tabwidth int
+
+	// Width is the width of a line (with tabs as wide as Tabwidth) beyond
+	// which a signature has its parameters wrapped, with WrapParams
+	Width int
+}
+
+// defaultPrinter prints code like gofmt, with tabs 4 columns wide
+var defaultPrinter = printerProfile{
+	Mode:     printer.TabIndent | printer.UseSpaces,
+	Tabwidth: 4,
+	Width:    80,
+}
+
+// source prints target, or is "" if it cannot be printed. The zero profile
+// prints like the default one.
+func (self printerProfile) source(fset *token.FileSet, target interface{}) string {
+	if self == (printerProfile{}) {
+		self = defaultPrinter
+	}
+	var buffer bytes.Buffer
+	err := (&printer.Config{Mode: self.Mode, Tabwidth: self.Tabwidth}).Fprint(&buffer, fset, target)
+	if err != nil {
+		return ""
+	}
+	return buffer.String()
+}
+
+// unqualified stands in for the name of a package whose qualifier is left
+// out, and is taken out of the printed code with the "."
+const unqualified = "\x00"
+
+// qualify renames the package qualifiers of node (in file) as the style's Qualify
+// says: away, for the documented package itself ("short"). It returns the
+// function that puts the names back, and with "full", the imports (names to
+// paths) that node uses, for declSource to declare above it, so that the
+// code stays Go.
+func (self *Document) qualify(node ast.Node, file *ast.File) (func(), map[string]string) {
+	if file == nil || self.style.Qualify == "" || self.style.Qualify == "source" {
+		return func() {}, nil
+	}
+	imports := importsOf(map[string]*ast.File{"": file})
+	used := map[string]string{}
+	type renamed struct {
+		ident *ast.Ident
+		name  string
+	}
+	var list []renamed
+	ast.Inspect(node, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := selector.X.(*ast.Ident)
+		if !ok || imports[ident.Name] == "" {
+			return true
+		}
+		importPath := imports[ident.Name]
+		switch self.style.Qualify {
+		case "full":
+			used[ident.Name] = importPath
+		case "short":
+			if self.ImportPath != "" && importPath == self.ImportPath {
+				list = append(list, renamed{ident, ident.Name})
+				ident.Name = unqualified
+			}
+		}
+		return false
+	})
+	return func() {
+		for _, entry := range list {
+			entry.ident.Name = entry.name
+		}
+	}, used
+}
+
+// importDecl is the import declaration of imports (names to paths), sorted
+// by path, naming the packages whose names are not the last element of
+// their paths, or "" for none
+func (self printerProfile) importDecl(imports map[string]string) string {
+	var paths []string
+	names := map[string]string{}
+	for name, importPath := range imports {
+		paths = append(paths, importPath)
+		names[importPath] = name
+	}
+	sort.Strings(paths)
+	var specs []string
+	for _, importPath := range paths {
+		spec := strconv.Quote(importPath)
+		if name := names[importPath]; name != path.Base(importPath) {
+			spec = name + " " + spec
+		}
+		specs = append(specs, spec)
+	}
+	switch len(specs) {
+	case 0:
+		return ""
+	case 1:
+		return "import " + specs[0] + "\n"
+	}
+	indentation := self.indentation()
+	return "import (\n" + indentation + strings.Join(specs, "\n"+indentation) + "\n)\n"
+}
+
+// modulePath is the path of the module the package is in, or "" if it is
+// not in one
+func (self *Document) modulePath() string {
+	if self.module == nil {
+		module, _, err := findModule(self.absPath)
+		if err != nil {
+			module = ""
+		}
+		self.module = &module
+	}
+	return *self.module
+}
+
+// declSource is the code of a declaration as the style prints it: with its
+// package qualifiers as Qualify says, and its lines that are wider than
+// Printer.Width wrapped, with WrapParams or Wrap. Grouped const and var
+// declarations keep their layout (see valueBlock).
+func (self *Document) declSource(node ast.Node) string {
+	file := self.files[self.fset.Position(node.Pos()).Filename]
+	fset, target := self.fset, interface{}(node)
+	if blockFset, block := self.valueBlock(node); block != nil {
+		fset, node, target = blockFset, block.Node.(ast.Node), block
+	}
+	restore, imports := self.qualify(node, file)
+	source := self.style.Printer.source(fset, target)
+	restore()
+	source = strings.ReplaceAll(source, unqualified+".", "")
+	if self.style.Wrap {
+		source = wrapDecl(source, self.style.Printer, true)
+	} else if _, ok := node.(*ast.FuncDecl); ok && self.style.WrapParams {
+		source = wrapDecl(source, self.style.Printer, false)
+	}
+	if decl := self.style.Printer.importDecl(imports); decl != "" {
+		source = decl + "\n" + source
+	}
+	return source
+}
+
+// indentation is one level of indentation, as the profile prints it
+func (self printerProfile) indentation() string {
+	if self.Mode&printer.UseSpaces != 0 && self.Mode&printer.TabIndent == 0 && self.Tabwidth > 0 {
+		return strings.Repeat(" ", self.Tabwidth)
+	}
+	return "\t"
+}
+
+// lineWidth is how wide line is, with its tabs as wide as the profile's
+func (self printerProfile) lineWidth(line string) int {
+	tabwidth := self.Tabwidth
+	if tabwidth == 0 {
+		tabwidth = defaultPrinter.Tabwidth
+	}
+	return len([]rune(line)) + strings.Count(line, "\t")*(tabwidth-1)
+}
+
+// declList is a bracketed list of a declaration: its type parameters,
+// parameters, or results, from the index of its opening bracket to that of
+// its closing one
+type declList struct {
+	open, close int
+}
+
+// The lists of a declaration, in the order they are wrapped in
+const (
+	paramList = iota
+	typeParamList
+	resultList
+)
+
+// declLists finds the lists of the function signature (or interface
+// method) or type declaration on line, or reports that it has none
+func declLists(line string) ([3]*declList, bool) {
+	var lists [3]*declList
+	index := len(line) - len(strings.TrimLeft(line, " \t"))
+	nested := index > 0
+	identifier := func() {
+		for index < len(line) && (line[index] == '_' || unicode.IsLetter(rune(line[index])) || unicode.IsDigit(rune(line[index]))) {
+			index++
+		}
+	}
+	list := func(kind int) bool {
+		end := closingIndex(line, index)
+		if end < 0 {
+			return false
+		}
+		lists[kind] = &declList{index, end}
+		index = end + 1
+		return true
+	}
+	switch {
+	case strings.HasPrefix(line[index:], "func "):
+		index += len("func ")
+		if strings.HasPrefix(line[index:], "(") {
+			// The receiver
+			if !list(paramList) {
+				return lists, false
+			}
+			lists[paramList] = nil
+			index++
+		}
+		identifier()
+		if strings.HasPrefix(line[index:], "[") && !list(typeParamList) {
+			return lists, false
+		}
+	case strings.HasPrefix(line[index:], "type "):
+		index += len("type ")
+		identifier()
+		if !strings.HasPrefix(line[index:], "[") || !list(typeParamList) {
+			return lists, false
+		}
+		return lists, true
+	case nested:
+		// A method of an interface
+		identifier()
+	}
+	if !strings.HasPrefix(line[index:], "(") || !list(paramList) {
+		return lists, false
+	}
+	if strings.HasPrefix(line[index:], " (") {
+		index++
+		list(resultList)
+	}
+	return lists, true
+}
+
+// wrapDecl puts the parameters of the function signatures of source that
+// are wider than the profile's Width on lines of their own, as gofmt leaves
+// them when they are written that way (indented as the profile says):
+//
+//	func Dial(
+//		ctx context.Context,
+//		network, address string,
+//	) (Conn, error)
+//
+// With all, it wraps interface methods too, and then the type parameters
+// and the results of those that are still too wide, as it does the type
+// parameters of type declarations.
+func wrapDecl(source string, profile printerProfile, all bool) string {
+	width := profile.Width
+	if width == 0 {
+		width = defaultPrinter.Width
+	}
+	order := []int{paramList}
+	if all {
+		order = append(order, typeParamList, resultList)
+	}
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		if profile.lineWidth(line) <= width {
+			continue
+		}
+		lists, ok := declLists(line)
+		if !ok || (!all && (strings.HasPrefix(line, "\t") || strings.HasPrefix(line, " "))) {
+			continue
+		}
+		var wrap [3]bool
+		for _, kind := range order {
+			if lists[kind] == nil || strings.TrimSpace(line[lists[kind].open+1:lists[kind].close]) == "" {
+				continue
+			}
+			wrap[kind] = true
+			wrapped := wrapLists(line, lists, wrap, profile)
+			fits := true
+			for _, entry := range strings.Split(wrapped, "\n") {
+				fits = fits && profile.lineWidth(entry) <= width
+			}
+			lines[i] = wrapped
+			if fits {
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLists puts the items of the lists of line to wrap on lines of their
+// own, one level deeper than line is indented. Names that share a type (a,
+// b int) stay on one line.
+func wrapLists(line string, lists [3]*declList, wrap [3]bool, profile printerProfile) string {
+	indentation := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	var result strings.Builder
+	last := 0
+	for _, kind := range []int{typeParamList, paramList, resultList} {
+		list := lists[kind]
+		if list == nil || !wrap[kind] {
+			continue
+		}
+		result.WriteString(line[last : list.open+1])
+		result.WriteString("\n")
+		items := splitTopLevel(line[list.open+1 : list.close])
+		named := strings.Contains(items[len(items)-1], " ")
+		pending := ""
+		for _, item := range items {
+			if named && !strings.Contains(item, " ") {
+				pending += item + ", "
+				continue
+			}
+			result.WriteString(indentation + profile.indentation() + pending + item + ",\n")
+			pending = ""
+		}
+		result.WriteString(indentation)
+		last = list.close
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+// closingIndex is the index of the bracket that closes the one at start in
+// text, or -1
+func closingIndex(text string, start int) int {
+	depth := 0
+	quote := byte(0)
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits a list at the commas that are not inside brackets or
+// quotes, trimming the spaces around each part
+func splitTopLevel(list string) []string {
+	var parts []string
+	depth, last := 0, 0
+	quote := byte(0)
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(list[last:i]))
+			last = i + 1
+		}
+	}
+	if part := strings.TrimSpace(list[last:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
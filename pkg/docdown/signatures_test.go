@@ -0,0 +1,55 @@
+package docdown
+
+import (
+	"go/ast"
+	"strings"
+	"testing"
+)
+
+func TestQualify(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/tp\n",
+		"sub/sub.go": "package sub\n\n// Thing is a thing.\ntype Thing struct{}\n",
+		"yaml/v3.go": "package yaml\n\n// Node is a node.\ntype Node struct{}\n",
+		"tp.go":      "// Package tp is a test package.\npackage tp\n\nimport (\n\t\"net/http\"\n\n\t\"example.com/tp/sub\"\n\tyml \"example.com/tp/yaml\"\n)\n\n// Use uses things.\nfunc Use(thing sub.Thing, node *yml.Node) *http.Client { return nil }\n\n// Get gets a thing.\nfunc Get() sub.Thing { return sub.Thing{} }\n",
+	})
+	document, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := []ast.Node{document.findFunc("Use").Decl, document.findFunc("Get").Decl}
+	for _, test := range []struct {
+		qualify    string
+		importPath string
+		expected   []string
+	}{
+		{"source", "", []string{
+			"func Use(thing sub.Thing, node *yml.Node) *http.Client",
+			"func Get() sub.Thing",
+		}},
+		{"full", "", []string{
+			"import (\n\t\"example.com/tp/sub\"\n\tyml \"example.com/tp/yaml\"\n\t\"net/http\"\n)\n\nfunc Use(thing sub.Thing, node *yml.Node) *http.Client",
+			"import \"example.com/tp/sub\"\n\nfunc Get() sub.Thing",
+		}},
+		{"short", "", []string{
+			"func Use(thing sub.Thing, node *yml.Node) *http.Client",
+			"func Get() sub.Thing",
+		}},
+		// As if the package were documented as sub, like a .godocdown.import
+		// file does
+		{"short", "example.com/tp/sub", []string{
+			"func Use(thing Thing, node *yml.Node) *http.Client",
+			"func Get() Thing",
+		}},
+	} {
+		document.style.Qualify = test.qualify
+		document.ImportPath = test.importPath
+		for i, node := range nodes {
+			source := strings.TrimSpace(document.declSource(node))
+			if source != test.expected[i] {
+				t.Errorf("-qualify=%s (%s): source is\n%s\nexpected\n%s", test.qualify, test.importPath, source, test.expected[i])
+			}
+		}
+	}
+}
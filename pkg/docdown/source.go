@@ -0,0 +1,71 @@
+package docdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+)
+
+// isSourceFile reports whether target is a single Go file to document, or
+// "-" for Go source read from stdin. A ".go" path that does not exist is
+// one too, for loadSourceFile to report, rather than an import path to look
+// up.
+func isSourceFile(target string) bool {
+	if target == "-" {
+		return true
+	}
+	if !strings.HasSuffix(target, ".go") {
+		return false
+	}
+	info, err := os.Stat(target)
+	return errors.Is(err, fs.ErrNotExist) || (err == nil && info.Mode().IsRegular())
+}
+
+// sourceFileImport returns the import path and directory of the package a
+// single Go file belongs to, or ErrNoPackage if there is no such file. Source from stdin belongs to the current
+// directory, but has no import path, as it may not be from the package
+// there, unless a .godocdown.import file there gives one.
+func sourceFileImport(target string) (string, string, error) {
+	if target != "-" {
+		if _, err := os.Stat(target); errors.Is(err, fs.ErrNotExist) {
+			return "", "", fmt.Errorf("%w: %s", ErrNoPackage, target)
+		}
+		return buildImport(filepath.Dir(target))
+	}
+	absPath, err := filepath.Abs(".")
+	return "", absPath, err
+}
+
+// loadSourceFile loads just the declarations in a single Go file, for a
+// quick look at its documentation, without the rest of its package
+func loadSourceFile(ctx context.Context, target string, style Style) (*Document, error) {
+	importPath, absPath, err := sourceFileImport(target)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(target)
+	var source []byte
+	if target == "-" {
+		name = "stdin.go"
+		source, err = io.ReadAll(os.Stdin)
+	} else {
+		source, err = os.ReadFile(target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fsys := fstest.MapFS{name: &fstest.MapFile{Data: source}}
+	if target == "-" {
+		if read, err := os.ReadFile(filepath.Join(absPath, ".godocdown.import")); err == nil {
+			fsys[".godocdown.import"] = &fstest.MapFile{Data: read}
+		}
+	}
+	return parseDocument(ctx, fsys, absPath, importPath, style)
+}
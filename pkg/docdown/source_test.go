@@ -0,0 +1,60 @@
+package docdown
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSourceFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":   "module example.com/tp\n",
+		"tp.go":    "// Package tp is a test package.\npackage tp\n\n// Greet says hello.\nfunc Greet() {}\n",
+		"stdin.go": "// Package snippet is pasted.\npackage snippet\n\n// Wave waves.\nfunc Wave() {}\n",
+	})
+
+	document, err := Load(filepath.Join(dir, "tp.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.ImportPath != "example.com/tp" {
+		t.Errorf("the import path of a file is %q, expected \"example.com/tp\"", document.ImportPath)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.go")); !errors.Is(err, ErrNoPackage) {
+		t.Errorf("loading a missing file is %v, expected ErrNoPackage", err)
+	}
+
+	// Source on stdin is not guessed to be from the package of the current
+	// directory, unless .godocdown.import says so
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	for _, importPath := range []string{"", "example.com/snippet"} {
+		if importPath != "" {
+			writeFiles(t, dir, map[string]string{".godocdown.import": importPath + "\n"})
+		}
+		file, err := os.Open(filepath.Join(dir, "stdin.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stdin = file
+		document, err := Load("-")
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if document.Name != "snippet" || document.ImportPath != importPath {
+			t.Errorf("stdin is package %s (%q), expected snippet (%q)", document.Name, document.ImportPath, importPath)
+		}
+	}
+}
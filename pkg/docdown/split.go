@@ -0,0 +1,102 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"go/doc"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// splitPages names the page each exported type gets with -split=type, next
+// to output (the page for the rest of the package): T.md for README.md
+func splitPages(document *Document, output string) map[string]string {
+	pages := map[string]string{}
+	for _, entry := range document.pkg.Types {
+		page := entry.Name + filepath.Ext(output)
+		if document.style.Locale != "" {
+			page = localePath(page, document.style.Locale)
+		}
+		pages[entry.Name] = page
+	}
+	return pages
+}
+
+// href is the link to anchor, which is in the section of the type typeName
+// (or not in a type, if it is ""), and so is on the page of that type if
+// it has one
+func (self *Document) href(typeName, anchor string) string {
+	if page, ok := self.split[typeName]; ok {
+		return page + "#" + anchor
+	}
+	return "#" + anchor
+}
+
+// exampleHref is the link to the example with the given name
+func (self *Document) exampleHref(name string) string {
+	anchor := "Example" + name
+	if self.style.Flavor == "commonmark" {
+		anchor = headingID(self.commonmarkExampleHeading(name))
+	}
+	return self.href(exampleType(exampleSymbol(name)), anchor)
+}
+
+// exampleType is the type an example is for, given its symbol ("T" or "T.M")
+func exampleType(symbol string) string {
+	typeName, _, _ := strings.Cut(symbol, ".")
+	return typeName
+}
+
+// unsplitTypes are the types documented on the page of the package rather
+// than on pages of their own
+func (self *Document) unsplitTypes() []*doc.Type {
+	if len(self.split) == 0 {
+		return self.pkg.Types
+	}
+	var list []*doc.Type
+	for _, entry := range self.pkg.Types {
+		if _, ok := self.split[entry.Name]; !ok {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// writeSplitPages writes the page of each type the document was split into,
+// next to output
+func writeSplitPages(ctx context.Context, document *Document, output string) error {
+	for _, entry := range document.pkg.Types {
+		page, ok := document.split[entry.Name]
+		if !ok {
+			continue
+		}
+		err := writeDocumentTo(ctx, document.style, filepath.Join(filepath.Dir(output), page), nil, func(writer io.Writer) error {
+			headings := newHeadingWriter(writer, document.style)
+			renderTypePageTo(headings, document, entry, filepath.Base(output), filepath.Join(filepath.Dir(output), page))
+			return headings.Close()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderTypePageTo renders the page of a type, at page: its declaration,
+// examples, constructors, and methods, with a link back to the page of its
+// package (or breadcrumbs)
+func renderTypePageTo(writer io.Writer, document *Document, entry *doc.Type, index, page string) {
+	renderBreadcrumbsTo(writer, document, page, entry.Name)
+	fmt.Fprintf(writer, "# %s.%s\n\n", document.Name, entry.Name)
+	if !document.style.Breadcrumbs {
+		fmt.Fprintf(writer, "[%s](%s)\n\n", document.Name, index)
+	}
+	// The glossary is on the page of the package
+	document.termPage = index
+	defer func() {
+		document.termPage = ""
+	}()
+	renderTypeSectionTo(writer, document, []*doc.Type{entry}, document.Examples)
+	renderPageLinksTo(writer, document, page)
+}
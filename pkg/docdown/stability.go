@@ -0,0 +1,147 @@
+package docdown
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// stability_Regexp matches the stability annotation of a doc comment, a line
+// like "Stability: experimental", with the blank lines before it. The
+// annotation is shown as a badge instead.
+var stability_Regexp = regexp.MustCompile(`(?mi)(?:\n[ \t]*)*^[ \t]*Stability:[ \t]*(experimental|beta|stable)[ \t]*$`)
+
+// stabilityColors are the colors of the stability badges
+var stabilityColors = map[string]string{
+	"experimental": "orange",
+	"beta":         "yellow",
+	"stable":       "brightgreen",
+}
+
+// stripStability takes the stability annotations out of doc text
+func stripStability(text string) string {
+	return stability_Regexp.ReplaceAllString(text, "")
+}
+
+// stability is how stable a declaration is, from its "//godocdown:stability"
+// directive or the stability annotation of its doc comment, or "" if it
+// does not say
+func (self *Document) stability(node ast.Node, text string) string {
+	return stabilityOf(self.declDirectives(node), text)
+}
+
+// packageStability is how stable the package is, like stability
+func (self *Document) packageStability() string {
+	return stabilityOf(self.packageDirectives(), self.pkg.Doc)
+}
+
+// stabilityOf reads the stability from the directives or the text of a doc
+// comment. Unknown levels are left out (and warned about by checkDirectives).
+func stabilityOf(found map[string]string, text string) string {
+	level, ok := found["stability"]
+	if !ok {
+		if match := stability_Regexp.FindStringSubmatch(text); match != nil {
+			level = match[1]
+		}
+	}
+	level = strings.ToLower(level)
+	if _, ok := stabilityColors[level]; !ok {
+		return ""
+	}
+	return level
+}
+
+// stabilityBadge is a badge for a stability level: an image from
+// shields.io, or a label for the flavors that are meant to be converted to
+// other formats
+func (self *Document) stabilityBadge(level string) string {
+	label := self.style.text("Stability")
+	if !self.style.tables() {
+		return fmt.Sprintf("*%s: %s*", label, level)
+	}
+	return fmt.Sprintf("![%s: %s](https://img.shields.io/badge/%s-%s-%s)", label, level, strings.ToLower(label), level, stabilityColors[level])
+}
+
+// renderStabilityBadgeTo writes the badge for the stability of a declaration,
+// if it says
+func renderStabilityBadgeTo(writer io.Writer, document *Document, node ast.Node, text string) {
+	if level := document.stability(node, text); level != "" {
+		fmt.Fprintf(writer, "%s\n\n", document.stabilityBadge(level))
+	}
+}
+
+// unstableSymbol is a symbol that is not stable yet, for the summary
+type unstableSymbol struct {
+	Name  string
+	Href  string
+	Level string
+}
+
+// unstableSymbols lists the symbols of the package that are experimental or
+// in beta, in the order they are documented
+func (self *Document) unstableSymbols() []unstableSymbol {
+	var list []unstableSymbol
+	add := func(name, href string, node ast.Node, text string) {
+		if level := self.stability(node, text); level != "" && level != "stable" {
+			list = append(list, unstableSymbol{name, href, level})
+		}
+	}
+	values := func(entries []*doc.Value) {
+		for _, entry := range entries {
+			add(strings.Join(entry.Names, ", "), "", entry.Decl, entry.Doc)
+		}
+	}
+	funcs := func(entries []*doc.Func, typeName string) {
+		for _, entry := range entries {
+			name := entry.Name
+			if entry.Recv != "" {
+				name = baseType(entry.Recv) + "." + name
+			}
+			add(name, self.href(typeName, symbolAnchor(self, entry)), entry.Decl, entry.Doc)
+		}
+	}
+
+	values(self.pkg.Consts)
+	values(self.pkg.Vars)
+	funcs(self.pkg.Funcs, "")
+	for _, entry := range self.pkg.Types {
+		add(entry.Name, self.href(entry.Name, typeAnchor(self, entry.Name)), entry.Decl, entry.Doc)
+		values(entry.Consts)
+		values(entry.Vars)
+		funcs(entry.Funcs, entry.Name)
+		funcs(entry.Methods, entry.Name)
+	}
+	return list
+}
+
+// renderStabilityTo writes the summary of the symbols that are not stable
+// yet, under StabilityHeader (with IncludeStability)
+func renderStabilityTo(writer io.Writer, document *Document) {
+	if !document.style.IncludeStability {
+		return
+	}
+	list := document.unstableSymbols()
+	if len(list) == 0 {
+		return
+	}
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.StabilityHeader))
+	if style.tables() {
+		fmt.Fprintf(writer, "| %s | %s |\n| --- | --- |\n", style.text("Symbol"), style.text("Stability"))
+	}
+	for _, symbol := range list {
+		name := symbol.Name
+		if symbol.Href != "" {
+			name = fmt.Sprintf("[%s](%s)", name, symbol.Href)
+		}
+		if style.tables() {
+			fmt.Fprintf(writer, "| %s | %s |\n", name, symbol.Level)
+		} else {
+			fmt.Fprintf(writer, " - %s: %s\n", name, symbol.Level)
+		}
+	}
+	fmt.Fprintf(writer, "\n")
+}
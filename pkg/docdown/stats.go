@@ -0,0 +1,65 @@
+package docdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stats counts what a package has, for dashboards built on the output
+type stats struct {
+	Types     int `json:"types"`
+	Functions int `json:"functions"`
+	Methods   int `json:"methods"`
+	Examples  int `json:"examples"`
+	Files     int `json:"files"`
+	Lines     int `json:"lines"`
+}
+
+// Stats counts the exported types, functions (including constructors),
+// methods, and examples of the package, and its (non-test) Go files and
+// their lines
+func (self *Document) Stats() stats {
+	result := stats{
+		Types:     len(self.pkg.Types),
+		Functions: len(self.pkg.Funcs),
+		Examples:  len(self.Examples),
+		Files:     len(self.files),
+	}
+	for _, entry := range self.pkg.Types {
+		result.Functions += len(entry.Funcs)
+		result.Methods += len(entry.Methods)
+	}
+	for _, file := range self.files {
+		if tokenFile := self.fset.File(file.Package); tokenFile != nil {
+			result.Lines += tokenFile.LineCount()
+		}
+	}
+	return result
+}
+
+func renderStatsTo(writer io.Writer, document *Document) {
+	if !document.style.IncludeStats {
+		return
+	}
+	counts := document.Stats()
+	style := document.style
+	fmt.Fprintf(writer, "%s\n", style.heading(style.StatsHeader))
+	labels := []string{"Types", "Functions", "Methods", "Examples", "Files", "Lines"}
+	values := []int{counts.Types, counts.Functions, counts.Methods, counts.Examples, counts.Files, counts.Lines}
+	if !style.tables() {
+		for i, label := range labels {
+			fmt.Fprintf(writer, " - %s: %d\n", style.text(label), values[i])
+		}
+		fmt.Fprintf(writer, "\n")
+		return
+	}
+	for _, label := range labels {
+		fmt.Fprintf(writer, "| %s ", style.text(label))
+	}
+	fmt.Fprintf(writer, "|\n%s|\n", strings.Repeat("| ---: ", len(labels)))
+	for _, value := range values {
+		fmt.Fprintf(writer, "| %d ", value)
+	}
+	fmt.Fprintf(writer, "|\n\n")
+}
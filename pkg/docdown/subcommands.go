@@ -0,0 +1,72 @@
+package docdown
+
+import (
+	Flag "flag"
+	"slices"
+	"sort"
+)
+
+// subcommand is a command that godocdown runs, when it is given its name as
+// the first argument, instead of documenting packages
+type subcommand struct {
+	Summary string
+	Run     func(arguments []string) int
+
+	// Flags is the flag set of the subcommand: the flags of godocdown it
+	// uses, and its own
+	Flags func() *Flag.FlagSet
+}
+
+// subcommands are the subcommands of godocdown, by name. It is a function,
+// rather than a table, as "completion" refers to it.
+func subcommands() map[string]subcommand {
+	return map[string]subcommand{
+		"hook": {"Regenerate the documentation of the packages with staged changes", runHook, func() *Flag.FlagSet {
+			flags, _ := hookFlags()
+			return flags
+		}},
+		"init": {"Write a starter configuration and template", runInit, func() *Flag.FlagSet {
+			flags, _, _ := initFlags()
+			return flags
+		}},
+		"lint": {"Check doc comments against the conventions of Go", runLint, lintFlags},
+		"release-notes": {"Write the API changes since the previous tag", runReleaseNotes, func() *Flag.FlagSet {
+			flags, _, _ := releaseNotesFlags()
+			return flags
+		}},
+		"completion": {"Write a completion script for a shell", runCompletion, func() *Flag.FlagSet {
+			return Flag.NewFlagSet("completion", Flag.ExitOnError)
+		}},
+	}
+}
+
+// subcommandNames are the names of the subcommands, in order
+func subcommandNames() []string {
+	var names []string
+	for name := range subcommands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runFlags are the flags of godocdown about how a run goes, rather than what
+// the documentation is like, which a subcommand only has if it uses them
+var runFlags = []string{
+	"jobs", "timeout", "cache", "cache-dir", "index", "report", "warnings", "pr-summary", "base-ref",
+	"check-links", "coverage-badge", "tui", "go-list", "recursive", "lint-disable", "locales",
+}
+
+// subcommandFlags is a flag set for the subcommand with the given name, with
+// the flags of godocdown it shares: those of the documentation and the log,
+// which every subcommand that documents or checks packages builds a style
+// from, and the runFlags it uses
+func subcommandFlags(name string, uses ...string) *Flag.FlagSet {
+	flags := Flag.NewFlagSet(name, Flag.ExitOnError)
+	flag.VisitAll(func(f *Flag.Flag) {
+		if !slices.Contains(runFlags, f.Name) || slices.Contains(uses, f.Name) {
+			flags.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	return flags
+}
@@ -0,0 +1,152 @@
+package docdown
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// summary is what changed in a package compared to the -base-ref revision,
+// for the -pr-summary comment
+type summary struct {
+	changes      []apiChange
+	baseCoverage coverage
+	regenerated  bool
+}
+
+// fileHash returns the hash of a file, or nil if it cannot be read
+func fileHash(path string) []byte {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil
+	}
+	return hash.Sum(nil)
+}
+
+// summarize compares the job's document against the base revision
+func (self *job) summarize(ctx context.Context, style Style) error {
+	base, err := loadDocumentAt(ctx, self.absPath, self.document.ImportPath, *flag_baseRef, style)
+	if err != nil {
+		return err
+	}
+	self.summary = &summary{}
+	var baseAPI map[string]apiSymbol
+	if base != nil {
+		baseAPI = base.api()
+		self.summary.baseCoverage, _ = base.checkCoverage()
+	}
+	self.summary.changes = diffAPI(baseAPI, self.document.api())
+	return nil
+}
+
+// shortSignature is the first line of a signature, for listing it inline
+func shortSignature(signature string) string {
+	if i := strings.IndexByte(signature, '\n'); i >= 0 {
+		return strings.TrimSuffix(signature[:i], " {") + " …"
+	}
+	return signature
+}
+
+func formatDelta(delta float64) string {
+	if delta >= 0 {
+		return fmt.Sprintf("+%.1f%%", delta)
+	}
+	return fmt.Sprintf("%.1f%%", delta)
+}
+
+// renderSummaryTo renders a compact Markdown summary of the changes in the
+// documented packages, meant to be posted on a pull request by a bot
+func renderSummaryTo(writer io.Writer, jobs []*job) {
+	fmt.Fprintf(writer, "### Documentation changes\n\n")
+
+	var changed, regenerated []*job
+	for _, job := range jobs {
+		if job.summary == nil {
+			continue
+		}
+		if len(job.summary.changes) > 0 || job.summary.baseCoverage.Percent != job.info.Coverage.Percent {
+			changed = append(changed, job)
+		}
+		if job.summary.regenerated {
+			regenerated = append(regenerated, job)
+		}
+	}
+	if len(changed) == 0 && len(regenerated) == 0 {
+		fmt.Fprintf(writer, "No changes to the documented API compared to `%s`.\n", *flag_baseRef)
+		return
+	}
+
+	if len(changed) > 0 {
+		fmt.Fprintf(writer, "Compared to `%s`:\n\n", *flag_baseRef)
+		fmt.Fprintf(writer, "| Package | Added | Changed | Removed | Coverage |\n")
+		fmt.Fprintf(writer, "| --- | --- | --- | --- | --- |\n")
+		for _, job := range changed {
+			counts := map[string]int{}
+			for _, change := range job.summary.changes {
+				counts[change.Change]++
+			}
+			fmt.Fprintf(writer, "| `%s` | %d | %d | %d | %.1f%% (%s) |\n",
+				job.info.ImportPath,
+				counts["added"],
+				counts["changed"]+counts["documented"],
+				counts["removed"],
+				job.info.Coverage.Percent,
+				formatDelta(job.info.Coverage.Percent-job.summary.baseCoverage.Percent))
+		}
+		fmt.Fprintf(writer, "\n")
+
+		for _, job := range changed {
+			if len(job.summary.changes) == 0 {
+				continue
+			}
+			fmt.Fprintf(writer, "<details><summary><code>%s</code></summary>\n\n", job.info.ImportPath)
+			for _, change := range job.summary.changes {
+				signature := shortSignature(change.Symbol().Signature)
+				switch change.Change {
+				case "added":
+					fmt.Fprintf(writer, "- **Added** `%s`\n", signature)
+				case "removed":
+					fmt.Fprintf(writer, "- **Removed** `%s`\n", signature)
+				case "changed":
+					fmt.Fprintf(writer, "- **Changed** `%s` (was `%s`)\n", signature, shortSignature(change.Old.Signature))
+				case "documented":
+					fmt.Fprintf(writer, "- **Documentation changed** `%s`\n", change.Name)
+				}
+			}
+			fmt.Fprintf(writer, "\n</details>\n\n")
+		}
+	}
+
+	if len(regenerated) > 0 {
+		cwd, _ := os.Getwd()
+		var paths []string
+		for _, job := range regenerated {
+			path := job.path
+			if relative, err := filepath.Rel(cwd, path); err == nil {
+				path = filepath.ToSlash(relative)
+			}
+			paths = append(paths, "`"+path+"`")
+		}
+		fmt.Fprintf(writer, "Regenerated %s\n", strings.Join(paths, ", "))
+	}
+}
+
+// writeSummary writes the -pr-summary file, if one was requested
+func writeSummary(jobs []*job) error {
+	if *flag_prSummary == "" {
+		return nil
+	}
+	return writeOutputTo(*flag_prSummary, os.Stdout, func(writer io.Writer) error {
+		renderSummaryTo(writer, jobs)
+		return nil
+	})
+}
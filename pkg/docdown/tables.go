@@ -0,0 +1,167 @@
+package docdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	tableBorder_Regexp   = regexp.MustCompile(`^\+[-=:+]*[-=][-=:+]*\+$`)
+	tablePipeRule_Regexp = regexp.MustCompile(`^\|(?: *:?[-=]+:? *\|)+$`)
+	tableRule_Regexp     = regexp.MustCompile(`^[-=]+(?: +[-=]+)+$`)
+)
+
+// asciiTables converts the tables drawn in the preformatted blocks of doc
+// text to Markdown tables: tables boxed in with +---+ and |, tables of | with
+// a |---| rule, and columns under a line of dashes. Blocks that are not tables are left alone.
+func asciiTables(text string, style Style) string {
+	if style.ASCIITables != "markdown" || !style.tables() {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	var result strings.Builder
+	for i := 0; i < len(lines); {
+		end := i
+		for end < len(lines) && isPreformatted(lines[end]) {
+			end++
+		}
+		if end == i {
+			result.WriteString(lines[i])
+			i++
+			continue
+		}
+		if table := parseTable(lines[i:end]); table != nil {
+			result.WriteString(markdownTable(table))
+			if !strings.HasSuffix(lines[end-1], "\n") {
+				// The block ended the text
+				return strings.TrimSuffix(result.String(), "\n")
+			}
+		} else {
+			result.WriteString(strings.Join(lines[i:end], ""))
+		}
+		i = end
+	}
+	return result.String()
+}
+
+// isPreformatted reports whether line is a (non-blank) line of a
+// preformatted block
+func isPreformatted(line string) bool {
+	return (strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ")) && strings.TrimSpace(line) != ""
+}
+
+// parseTable reads the rows of the table drawn in block, the first being
+// the header, or returns nil if it is not a table
+func parseTable(block []string) [][]string {
+	lines := make([]string, len(block))
+	for i, line := range block {
+		lines[i] = strings.TrimSpace(line)
+		if strings.Contains(lines[i], "\t") {
+			// Columns can't be told apart by position
+			return nil
+		}
+	}
+	if strings.HasPrefix(lines[0], "+") || strings.HasPrefix(lines[0], "|") {
+		return parseBoxTable(lines)
+	}
+	return parseRuledTable(block, lines)
+}
+
+// parseBoxTable reads a table like
+//
+//	+------+-------+
+//	| Name | Value |
+//	+------+-------+
+//	| a    | 1     |
+//	+------+-------+
+//
+// or without the borders, with a rule like Markdown's, |------|-------|
+func parseBoxTable(lines []string) [][]string {
+	var rows [][]string
+	for _, line := range lines {
+		if tableBorder_Regexp.MatchString(line) || tablePipeRule_Regexp.MatchString(line) {
+			continue
+		}
+		if len(line) < 2 || !strings.HasPrefix(line, "|") || !strings.HasSuffix(line, "|") {
+			return nil
+		}
+		cells := strings.Split(line[1:len(line)-1], "|")
+		if len(cells) < 2 || (len(rows) > 0 && len(cells) != len(rows[0])) {
+			return nil
+		}
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) < 2 {
+		return nil
+	}
+	return rows
+}
+
+// parseRuledTable reads a table of aligned columns under a rule, like
+//
+//	Name    Value
+//	----    -----
+//	a       1
+func parseRuledTable(block, lines []string) [][]string {
+	if len(lines) < 3 || !tableRule_Regexp.MatchString(lines[1]) {
+		return nil
+	}
+	// The columns start where the dashes of the rule do, relative to the
+	// indentation of the block
+	indent := len(block[1]) - len(strings.TrimLeft(block[1], " \t"))
+	rule := strings.TrimRight(block[1], "\n")
+	var starts []int
+	for i := indent; i < len(rule); i++ {
+		if rule[i] != ' ' && (i == indent || rule[i-1] == ' ') {
+			starts = append(starts, i)
+		}
+	}
+
+	var rows [][]string
+	for j, line := range block {
+		if j == 1 {
+			continue
+		}
+		line = strings.TrimRight(line, "\n ")
+		if len(line)-len(strings.TrimLeft(line, " \t")) != indent {
+			return nil
+		}
+		cells := make([]string, len(starts))
+		for i, start := range starts {
+			if start >= len(line) {
+				break
+			}
+			if i > 0 && line[start-1] != ' ' {
+				// Text runs across the columns
+				return nil
+			}
+			end := len(line)
+			if i+1 < len(starts) && starts[i+1]-1 < end {
+				end = starts[i+1] - 1
+			}
+			cells[i] = strings.TrimSpace(line[start:end])
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+// markdownTable writes rows as a Markdown table, with the first as its
+// header
+func markdownTable(rows [][]string) string {
+	var table strings.Builder
+	for i, row := range rows {
+		table.WriteString("|")
+		for _, cell := range row {
+			table.WriteString(" " + escapeTableCell(cell) + " |")
+		}
+		table.WriteString("\n")
+		if i == 0 {
+			table.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+		}
+	}
+	return table.String()
+}
@@ -0,0 +1,87 @@
+package docdown
+
+import (
+	"testing"
+)
+
+func TestASCIITables(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			"box",
+			"Codes:\n\n\t+------+-------+\n\t| Name | Value |\n\t+======+=======+\n\t| a    | 1     |\n\t| b    | 2     |\n\t+------+-------+\n\nMore.\n",
+			"Codes:\n\n| Name | Value |\n| --- | --- |\n| a | 1 |\n| b | 2 |\n\nMore.\n",
+		},
+		{
+			"pipes with a rule",
+			"Codes:\n\n\t| Name | Value |\n\t|------|:-----:|\n\t| a    | 1     |\n\nMore.\n",
+			"Codes:\n\n| Name | Value |\n| --- | --- |\n| a | 1 |\n\nMore.\n",
+		},
+		{
+			"pipes with a compact rule",
+			"\t| a | b |\n\t|---|---|\n\t| 1 | 2 |\n",
+			"| a | b |\n| --- | --- |\n| 1 | 2 |\n",
+		},
+		{
+			"ruled",
+			"Codes:\n\n    Name    Value\n    ----    -----\n    a       1\n    long b  2\n\nMore.\n",
+			"Codes:\n\n| Name | Value |\n| --- | --- |\n| a | 1 |\n| long b | 2 |\n\nMore.\n",
+		},
+		{
+			"ruled with an empty cell",
+			"\tName  Value  Note\n\t----  -----  ----\n\ta     1\n\tb     2      x|y\n",
+			"| Name | Value | Note |\n| --- | --- | --- |\n| a | 1 |  |\n| b | 2 | x\\|y |\n",
+		},
+		{
+			"at the end of the text",
+			"Codes:\n\n\tName  Value\n\t----  -----\n\ta     1",
+			"Codes:\n\n| Name | Value |\n| --- | --- |\n| a | 1 |",
+		},
+		{
+			"code",
+			"For example:\n\n\tx := 1\n\ty := x - 1\n",
+			"For example:\n\n\tx := 1\n\ty := x - 1\n",
+		},
+		{
+			"text across columns",
+			"\tName  Value\n\t----  -----\n\ta long name 1\n",
+			"\tName  Value\n\t----  -----\n\ta long name 1\n",
+		},
+		{
+			"tabs",
+			"\tName\tValue\n\t----\t-----\n\ta\t1\n",
+			"\tName\tValue\n\t----\t-----\n\ta\t1\n",
+		},
+		{
+			"box with a ragged row",
+			"\t| a | b |\n\t| 1 | 2 | 3 |\n",
+			"\t| a | b |\n\t| 1 | 2 | 3 |\n",
+		},
+		{
+			"no preformatted block",
+			"Name Value\n---- -----\na 1\n",
+			"Name Value\n---- -----\na 1\n",
+		},
+	} {
+		if converted := asciiTables(test.text, DefaultStyle); converted != test.expected {
+			t.Errorf("%s: converted to\n%q\nexpected\n%q", test.name, converted, test.expected)
+		}
+	}
+
+	text := "\tName  Value\n\t----  -----\n\ta     1\n"
+	for _, flavor := range []string{"plain", "commonmark"} {
+		style := DefaultStyle
+		style.Flavor = flavor
+		if converted := asciiTables(text, style); converted != text {
+			t.Errorf("%s flavor converted a table to %q", flavor, converted)
+		}
+	}
+	style := DefaultStyle
+	style.ASCIITables = "pre"
+	if converted := asciiTables(text, style); converted != text {
+		t.Errorf("-ascii-tables=pre converted a table to %q", converted)
+	}
+}
@@ -0,0 +1,64 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// testCoverage_Regexp matches the statement coverage in the output of
+// "go test -cover"
+var testCoverage_Regexp = regexp.MustCompile(`coverage: ([\d.]+)% of statements`)
+
+// measureTestCoverage runs the tests of the package in absPath with -cover,
+// returning the percentage of its statements they cover, like "83.4%", or ""
+// if it has no tests or statements. go test caches the result until the
+// package or its dependencies change.
+func measureTestCoverage(ctx context.Context, absPath string) (string, error) {
+	output, err := goTest(ctx, absPath, "-cover", ".")
+	if err != nil {
+		return "", fmt.Errorf("Could not measure test coverage: %v", err)
+	}
+	match := testCoverage_Regexp.FindStringSubmatch(output)
+	if match == nil {
+		return "", nil
+	}
+	logger.Debug("measured test coverage", "package", absPath, "coverage", match[1])
+	return match[1] + "%", nil
+}
+
+// coverageColor is the color of the badge for a coverage percentage
+func coverageColor(value float64) string {
+	switch {
+	case value >= 80:
+		return "brightgreen"
+	case value >= 60:
+		return "yellow"
+	case value >= 40:
+		return "orange"
+	}
+	return "red"
+}
+
+// testCoverageBadge is the badge for the test coverage of the package (with
+// -test-coverage), like stabilityBadge, or "" if it is not known
+func (self *Document) testCoverageBadge() string {
+	if self.testCoverage == "" {
+		return ""
+	}
+	label := self.style.text("Coverage")
+	if !self.style.tables() {
+		return fmt.Sprintf("*%s: %s*", label, self.testCoverage)
+	}
+	percentage := strings.TrimSuffix(self.testCoverage, "%")
+	value, _ := strconv.ParseFloat(percentage, 64)
+	return fmt.Sprintf("![%s: %s](https://img.shields.io/badge/%s-%s%%25-%s)", label, self.testCoverage, strings.ToLower(label), percentage, coverageColor(value))
+}
+
+// TestCoverage is the statement coverage of the package's tests for
+// templates, like "83.4%" (with -test-coverage)
+func (self *Document) TestCoverage() string {
+	return self.testCoverage
+}
@@ -1,4 +1,4 @@
-package main
+package docdown
 
 const tplTxt = `
 {{with $examples := .Examples}}
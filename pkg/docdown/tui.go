@@ -0,0 +1,447 @@
+package docdown
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// The escape sequences of the terminal UI
+const (
+	tuiReset     = "\x1b[0m"
+	tuiBold      = "\x1b[1m"
+	tuiDim       = "\x1b[2m"
+	tuiReverse   = "\x1b[7m"
+	tuiHeading   = "\x1b[1;36m"
+	tuiCode      = "\x1b[33m"
+	tuiLink      = "\x1b[4;34m"
+	tuiClearLine = "\x1b[K"
+)
+
+// tuiHeading_Regexp matches an ATX heading, with the anchor godocdown may
+// give it
+var tuiHeading_Regexp = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+\{#[^}]*\})?\s*$`)
+
+// tuiCodeSpan_Regexp, tuiLink_Regexp, tuiStrong_Regexp, tuiEmphasis_Regexp,
+// and tuiEscape_Regexp match the inline Markdown the terminal UI styles
+var (
+	tuiCodeSpan_Regexp  = regexp.MustCompile("`+[^`]+`+")
+	tuiLink_Regexp      = regexp.MustCompile(`!?\[([^\]]*)\]\(<?[^)>]*>?\)`)
+	tuiStrong_Regexp    = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	tuiEmphasis_Regexp  = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	tuiEscape_Regexp    = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!<>|])")
+	tuiSGR_Regexp       = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	tuiAnchor_Regexp    = regexp.MustCompile(`^\s*<a (?:name|id)="[^"]*"></a>\s*$`)
+	tuiUnderline_Regexp = regexp.MustCompile(`^\s*(=+|-+)\s*$`)
+)
+
+// tuiEntry is a heading of the documentation, as the symbol tree lists it,
+// with the line of the rendered documentation it is on
+type tuiEntry struct {
+	Text  string
+	Level int
+	Line  int
+}
+
+// runTUI implements -tui: it renders the documentation of target and shows
+// it in the terminal, styled, beside a tree of its headings (the sections,
+// and the types, functions, and methods of the package) to jump around with
+func runTUI(ctx context.Context, target string, style Style) error {
+	document, err := loadDocument(ctx, target, style)
+	if err != nil {
+		return err
+	}
+	err = document.applyPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	var markdown bytes.Buffer
+	err = renderDocumentTo(ctx, &markdown, document)
+	if err != nil {
+		return err
+	}
+
+	terminal, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer terminal.Close()
+	view := &tuiView{name: document.Name, markdown: markdown.String()}
+	input := make([]byte, 16)
+	for {
+		rows, cols := terminal.size()
+		view.draw(rows, cols)
+		count, err := os.Stdin.Read(input)
+		if err != nil {
+			return err
+		}
+		if !view.handle(string(input[:count]), rows) {
+			return nil
+		}
+	}
+}
+
+// tuiView is the state of the terminal UI: the documentation as rendered for
+// the width of its pane, and which heading is selected and what is shown
+type tuiView struct {
+	name     string
+	markdown string
+
+	width   int // The width lines and entries were rendered for
+	lines   []string
+	entries []tuiEntry
+
+	selected int
+	treeTop  int
+	top      int
+	document bool // Whether the keys scroll the documentation, not the tree
+}
+
+// treeWidth is the width of the symbol tree for a terminal cols wide
+func treeWidth(cols int) int {
+	width := cols / 3
+	if width > 32 {
+		width = 32
+	}
+	return width
+}
+
+// draw draws the view on a terminal of the given size
+func (self *tuiView) draw(rows, cols int) {
+	left := treeWidth(cols)
+	right := cols - left - 1
+	if right < 1 {
+		right = 1
+	}
+	if right != self.width {
+		self.width = right
+		self.lines, self.entries = renderTerminal(self.markdown, right)
+		if self.selected < len(self.entries) {
+			self.top = self.entries[self.selected].Line
+		}
+	}
+	height := rows - 1
+	if height < 1 {
+		height = 1
+	}
+	self.clamp(height)
+
+	minimum := 6
+	for _, entry := range self.entries {
+		if entry.Level < minimum {
+			minimum = entry.Level
+		}
+	}
+	var frame strings.Builder
+	frame.WriteString("\x1b[H")
+	for row := 0; row < height; row++ {
+		cell := ""
+		if index := self.treeTop + row; index < len(self.entries) {
+			entry := self.entries[index]
+			cell = fitStyled(strings.Repeat("  ", entry.Level-minimum)+entry.Text, left)
+			switch {
+			case index == self.selected && !self.document:
+				cell = tuiReverse + cell + tuiReset
+			case index == self.selected:
+				cell = tuiBold + cell + tuiReset
+			}
+		} else {
+			cell = strings.Repeat(" ", left)
+		}
+		frame.WriteString(cell + tuiDim + "│" + tuiReset)
+		if line := self.top + row; line < len(self.lines) {
+			frame.WriteString(fitStyled(self.lines[line], right) + tuiReset)
+		}
+		frame.WriteString(tuiClearLine + "\r\n")
+	}
+	position := 100
+	if len(self.lines) > height {
+		position = 100 * self.top / (len(self.lines) - height)
+	}
+	status := fmt.Sprintf(" %s  %d%%  ↑↓ move  tab switch pane  pgup/pgdn page  q quit", self.name, position)
+	frame.WriteString(tuiReverse + fitStyled(status, cols) + tuiReset)
+	os.Stdout.WriteString(frame.String())
+}
+
+// clamp keeps what is shown within the documentation and the tree, with the
+// selected entry visible
+func (self *tuiView) clamp(height int) {
+	if self.top > len(self.lines)-height {
+		self.top = len(self.lines) - height
+	}
+	if self.top < 0 {
+		self.top = 0
+	}
+	if self.selected >= len(self.entries) {
+		self.selected = len(self.entries) - 1
+	}
+	if self.selected < 0 {
+		self.selected = 0
+	}
+	if self.selected < self.treeTop {
+		self.treeTop = self.selected
+	}
+	if self.selected >= self.treeTop+height {
+		self.treeTop = self.selected - height + 1
+	}
+}
+
+// handle acts on a key, and reports whether to go on
+func (self *tuiView) handle(key string, rows int) bool {
+	page := rows - 2
+	if page < 1 {
+		page = 1
+	}
+	switch key {
+	case "q", "\x03", "\x1b":
+		return false
+	case "\t":
+		self.document = !self.document
+	case "\x1b[A", "k":
+		self.move(-1)
+	case "\x1b[B", "j":
+		self.move(1)
+	case "\x1b[5~", "b":
+		self.scroll(-page)
+	case "\x1b[6~", " ":
+		self.scroll(page)
+	case "\x1b[H", "\x1b[1~", "g":
+		self.scroll(-len(self.lines))
+	case "\x1b[F", "\x1b[4~", "G":
+		self.scroll(len(self.lines))
+	case "\r", "\n":
+		self.document = true
+	}
+	return true
+}
+
+// move moves the selection in the tree by delta entries, showing the
+// heading it lands on, or scrolls the documentation by delta lines
+func (self *tuiView) move(delta int) {
+	if self.document || len(self.entries) == 0 {
+		self.scroll(delta)
+		return
+	}
+	self.selected += delta
+	if self.selected < 0 {
+		self.selected = 0
+	}
+	if self.selected >= len(self.entries) {
+		self.selected = len(self.entries) - 1
+	}
+	self.top = self.entries[self.selected].Line
+}
+
+// scroll scrolls the documentation by delta lines, selecting the heading of
+// the section at the top
+func (self *tuiView) scroll(delta int) {
+	self.top += delta
+	if self.top > len(self.lines)-1 {
+		self.top = len(self.lines) - 1
+	}
+	if self.top < 0 {
+		self.top = 0
+	}
+	for index, entry := range self.entries {
+		if entry.Line <= self.top {
+			self.selected = index
+		}
+	}
+}
+
+// renderTerminal renders Markdown for a terminal width columns wide: its
+// headings in color (without their anchors), its code in another, its
+// links underlined, and its paragraphs wrapped. It returns the lines, and
+// the headings with the lines they are on.
+func renderTerminal(markdown string, width int) ([]string, []tuiEntry) {
+	var lines []string
+	var entries []tuiEntry
+	source := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	fence := ""
+	indented := false
+	for i := 0; i < len(source); i++ {
+		line := source[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				if i+1 < len(source) && strings.TrimSpace(source[i+1]) != "" {
+					lines = append(lines, "")
+				}
+				continue
+			}
+			lines = append(lines, "  "+tuiCode+strings.ReplaceAll(line, "\t", "    ")+tuiReset)
+			continue
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+			continue
+		case tuiAnchor_Regexp.MatchString(line):
+			continue
+		}
+		if (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) && (indented || i == 0 || strings.TrimSpace(source[i-1]) == "") {
+			indented = true
+			lines = append(lines, "  "+tuiCode+strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(line, "\t"), "    "), "\t", "    ")+tuiReset)
+			continue
+		}
+		indented = false
+
+		level, text := 0, ""
+		if match := tuiHeading_Regexp.FindStringSubmatch(line); match != nil {
+			level, text = len(match[1]), match[2]
+		} else if trimmed != "" && i+1 < len(source) && tuiUnderline_Regexp.MatchString(source[i+1]) && !strings.HasPrefix(trimmed, "|") {
+			level, text = 1, trimmed
+			if strings.Contains(source[i+1], "-") {
+				level = 2
+			}
+			i++
+		}
+		if level > 0 {
+			plain := tuiSGR_Regexp.ReplaceAllString(styleInline(text), "")
+			entries = append(entries, tuiEntry{Text: plain, Level: level, Line: len(lines)})
+			lines = append(lines, wrapStyled(tuiHeading+plain+tuiReset, width, "")...)
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			lines = append(lines, wrapStyled(tuiDim+"│ "+tuiReset+styleInline(text), width, tuiDim+"│ "+tuiReset)...)
+			continue
+		}
+		indentation := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		lines = append(lines, wrapStyled(styleInline(line), width, indentation)...)
+	}
+	return lines, entries
+}
+
+// styleInline styles the code spans, links, and strong and emphasized text
+// of a line of Markdown, and takes the backslashes out of its escapes
+func styleInline(text string) string {
+	var result strings.Builder
+	last := 0
+	style := func(text string) string {
+		text = tuiLink_Regexp.ReplaceAllString(text, tuiLink+"$1"+"\x1b[24;39m")
+		text = tuiStrong_Regexp.ReplaceAllString(text, tuiBold+"$1"+"\x1b[22m")
+		text = tuiEmphasis_Regexp.ReplaceAllString(text, "\x1b[3m"+"$1"+"\x1b[23m")
+		return tuiEscape_Regexp.ReplaceAllString(text, "$1")
+	}
+	for _, span := range tuiCodeSpan_Regexp.FindAllStringIndex(text, -1) {
+		result.WriteString(style(text[last:span[0]]))
+		code := strings.Trim(text[span[0]:span[1]], "`")
+		result.WriteString(tuiCode + strings.TrimSpace(code) + "\x1b[39m")
+		last = span[1]
+	}
+	result.WriteString(style(text[last:]))
+	return result.String()
+}
+
+// visibleWidth is how many columns text takes, without its escape sequences
+func visibleWidth(text string) int {
+	return utf8.RuneCountInString(tuiSGR_Regexp.ReplaceAllString(text, ""))
+}
+
+// wrapStyled wraps a styled line at the spaces to lines at most width
+// columns wide, with continuation lines starting with indentation and
+// the styles that are still in effect
+func wrapStyled(line string, width int, indentation string) []string {
+	if visibleWidth(line) <= width {
+		return []string{line}
+	}
+	var lines []string
+	var current strings.Builder
+	currentWidth := 0
+	active := ""
+	for _, word := range strings.Split(line, " ") {
+		wordWidth := visibleWidth(word)
+		if currentWidth > 0 && currentWidth+1+wordWidth > width && visibleWidth(strings.TrimSpace(current.String())) > 0 {
+			lines = append(lines, current.String()+tuiReset)
+			current.Reset()
+			current.WriteString(indentation + active)
+			currentWidth = visibleWidth(indentation)
+		} else if current.Len() > 0 {
+			current.WriteString(" ")
+			currentWidth++
+		}
+		current.WriteString(word)
+		currentWidth += wordWidth
+		for _, sequence := range tuiSGR_Regexp.FindAllString(word, -1) {
+			if sequence == tuiReset {
+				active = ""
+				continue
+			}
+			active += sequence
+		}
+	}
+	return append(lines, current.String())
+}
+
+// fitStyled cuts a styled line to width columns, or pads it with spaces
+func fitStyled(line string, width int) string {
+	var result strings.Builder
+	columns := 0
+	for len(line) > 0 {
+		if location := tuiSGR_Regexp.FindStringIndex(line); location != nil && location[0] == 0 {
+			result.WriteString(line[:location[1]])
+			line = line[location[1]:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line)
+		line = line[size:]
+		if columns == width {
+			continue
+		}
+		if r == '\t' {
+			r = ' '
+		}
+		result.WriteRune(r)
+		columns++
+	}
+	return result.String() + strings.Repeat(" ", width-columns)
+}
+
+// terminal is the terminal the UI runs in, in raw mode on its alternate
+// screen, with the state to restore
+type terminal struct {
+	state *term.State
+}
+
+// openTerminal puts the terminal of stdin and stdout in raw mode, on its
+// alternate screen
+func openTerminal() (*terminal, error) {
+	for _, file := range []*os.File{os.Stdin, os.Stdout} {
+		if !term.IsTerminal(int(file.Fd())) {
+			return nil, fmt.Errorf("-tui needs a terminal")
+		}
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("Could not set up the terminal for -tui on %s: %w", runtime.GOOS, err)
+	}
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+	return &terminal{state: state}, nil
+}
+
+// Close restores the terminal
+func (self *terminal) Close() {
+	os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	err := term.Restore(int(os.Stdin.Fd()), self.state)
+	if err != nil {
+		logger.Warn("Could not restore the terminal", "error", err)
+	}
+}
+
+// size is the number of rows and columns of the terminal, or 24 by 80 if
+// it cannot tell
+func (self *terminal) size() (int, int) {
+	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || rows < 2 || cols < 2 {
+		return 24, 80
+	}
+	return rows, cols
+}
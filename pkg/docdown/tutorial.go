@@ -0,0 +1,139 @@
+package docdown
+
+import (
+	"context"
+	"fmt"
+	"go/doc"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stepNumber_Regexp matches the number of a step in the suffix of the name
+// of an example, like the 2 of Example_step2
+var stepNumber_Regexp = regexp.MustCompile(`[0-9]+`)
+
+// stepNumber is the number of the step an example is in a tutorial, from
+// its suffix, and whether it has one
+func stepNumber(name string) (int, bool) {
+	_, _, suffix := exampleTarget(name)
+	number, err := strconv.Atoi(stepNumber_Regexp.FindString(suffix))
+	return number, err == nil
+}
+
+// tutorialSteps are the examples of the package in the order of the steps of
+// its tutorial: heavier ones first (by -weight or their "//godocdown:weight"
+// directives), then those numbered in their suffixes (like Example_step2),
+// by their numbers, then the rest, in the order of the source
+func (self *Document) tutorialSteps() []*doc.Example {
+	steps := append([]*doc.Example(nil), self.Examples...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		if weightI, weightJ := self.exampleWeight(steps[i].Name), self.exampleWeight(steps[j].Name); weightI != weightJ {
+			return weightI > weightJ
+		}
+		numberI, okI := stepNumber(steps[i].Name)
+		numberJ, okJ := stepNumber(steps[j].Name)
+		if okI != okJ {
+			return okI
+		}
+		if numberI != numberJ {
+			return numberI < numberJ
+		}
+		return steps[i].Code.Pos() < steps[j].Code.Pos()
+	})
+	return steps
+}
+
+// stepTitle is the title of a step of the tutorial: the title of its
+// "//godocdown:title" directive, or the first sentence of its doc comment,
+// or its name, like godoc names examples
+func (self *Document) stepTitle(example *doc.Example) string {
+	if title, ok := self.exampleDirectives(example.Name)["title"]; ok {
+		return title
+	}
+	if synopsis := strings.TrimSuffix(doc.Synopsis(example.Doc), "."); synopsis != "" {
+		return synopsis
+	}
+	return exampleTitle(example.Name, self.style)
+}
+
+// tutorialPage is where the tutorial of the document is written, next to
+// output, or "" if there is none: without -tutorial, or examples
+func (self *Document) tutorialPage(output string) string {
+	if self.style.Tutorial == "" || output == "" || output == "-" || len(self.Examples) == 0 {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(output), self.style.Tutorial)
+}
+
+// writeTutorialPage writes the tutorial of the document, with -tutorial,
+// next to output
+func writeTutorialPage(ctx context.Context, document *Document, output string) error {
+	path := document.tutorialPage(output)
+	if path == "" {
+		document.style.log().Debug("no examples for a tutorial", "package", document.absPath)
+		return nil
+	}
+	index, err := filepath.Rel(filepath.Dir(path), output)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return writeDocumentTo(ctx, document.style, path, nil, func(writer io.Writer) error {
+		headings := newHeadingWriter(writer, document.style)
+		renderTutorialPageTo(headings, document, filepath.ToSlash(index), path)
+		return headings.Close()
+	})
+}
+
+// renderTutorialPageTo renders the tutorial of the package, at page: each of
+// its examples in turn (see tutorialSteps), as a step with the doc comment
+// of the example for its prose, and its code (with the imports it needs,
+// or as a whole program with -example-programs) and output
+func renderTutorialPageTo(writer io.Writer, document *Document, index, page string) {
+	// The glossary is on the page of the package
+	document.termPage = index
+	defer func() {
+		document.termPage = ""
+	}()
+	heading := document.style.text("Tutorial")
+	renderBreadcrumbsTo(writer, document, page, heading)
+	fmt.Fprintf(writer, "# %s\n\n", heading)
+	if !document.style.Breadcrumbs {
+		fmt.Fprintf(writer, "[%s](%s)\n\n", document.Name, index)
+	}
+	for i, example := range document.tutorialSteps() {
+		style := document.style
+		style.CodeLanguage = document.exampleLanguage(example.Name)
+		code := document.exampleImports(example) + exampleBody(document.exampleSource(example))
+		if style.ExamplePrograms {
+			if program, err := document.exampleProgram(example.Name); err == nil {
+				code = program
+			} else {
+				document.style.log().Debug("showing the step as it is", "example", example.Name, "error", err)
+			}
+		}
+		title := document.stepTitle(example)
+		prose := example.Doc
+		if synopsis := doc.Synopsis(prose); strings.TrimSpace(prose) == synopsis && strings.TrimSuffix(synopsis, ".") == title {
+			// The doc comment is the title
+			prose = ""
+		}
+		fmt.Fprintf(writer, "## %d. %s\n\n", i+1, title)
+		if prose != "" {
+			fmt.Fprintf(writer, "%s\n", document.docText(prose))
+		}
+		fmt.Fprintf(writer, "%s\n\n", strings.TrimRight(indentCode(code, style), "\n"))
+		if example.Output != "" {
+			fmt.Fprintf(writer, "%s:\n\n```%s\n%s```\n\n", document.style.text("Output"), document.exampleOutputLanguage(example.Name), example.Output)
+		}
+	}
+	renderPageLinksTo(writer, document, page)
+}
@@ -0,0 +1,186 @@
+package docdown
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// moduleUsers maps the import paths of the packages of a module, and the
+// names of their symbols (Name, or Type.Method), to the other packages of
+// the module that use them
+type moduleUsers map[string]map[string][]string
+
+var (
+	// usersOfModules are the users found in each module, by the directory of
+	// its root, as every package of a module shares them
+	usersOfModules = map[string]moduleUsers{}
+	usersMutex     sync.Mutex
+)
+
+// loadUsedBy finds the packages of the module that use the symbols of the
+// package, with -used-by. Outside of a module, there are none.
+func (self *Document) loadUsedBy() error {
+	module, root, err := findModule(self.absPath)
+	if err != nil {
+		self.style.log().Debug("not finding users outside of a module", "package", self.absPath, "error", err)
+		return nil
+	}
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+	users, ok := usersOfModules[root]
+	if !ok {
+		users, err = findModuleUsers(module, root)
+		if err != nil {
+			return err
+		}
+		usersOfModules[root] = users
+	}
+	self.usedBy = users[self.ImportPath]
+	return nil
+}
+
+// findModuleUsers type-checks every package of the module at root (but for
+// their tests) to find which of them use the exported symbols of the
+// others. Packages that do not type-check are used for what can be made
+// of them.
+func findModuleUsers(module, root string) (moduleUsers, error) {
+	command := exec.Command("go", "list", "-e", "-json", "./...")
+	command.Dir = root
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Could not list the packages of %s: %s", module, strings.TrimSpace(stderr.String()))
+	}
+	list, err := decodePackages(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	inModule := func(importPath string) bool {
+		return importPath == module || strings.HasPrefix(importPath, module+"/")
+	}
+	fset := token.NewFileSet()
+	imports := importer.ForCompiler(fset, "source", nil)
+	users := moduleUsers{}
+	for _, pkg := range list {
+		var files []*ast.File
+		for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+			file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.SkipObjectResolution)
+			if err == nil {
+				files = append(files, file)
+			}
+		}
+		info := &types.Info{Uses: map[*ast.Ident]types.Object{}}
+		config := types.Config{
+			Importer:    imports,
+			FakeImportC: true,
+			Error: func(err error) {
+				logger.Debug("could not type-check a user", "package", pkg.ImportPath, "error", err)
+			},
+		}
+		config.Check(pkg.ImportPath, fset, files, info)
+
+		for _, object := range info.Uses {
+			if object.Pkg() == nil || !object.Exported() || object.Pkg().Path() == pkg.ImportPath || !inModule(object.Pkg().Path()) {
+				continue
+			}
+			name := usedName(object)
+			if name == "" {
+				continue
+			}
+			symbols := users[object.Pkg().Path()]
+			if symbols == nil {
+				symbols = map[string][]string{}
+				users[object.Pkg().Path()] = symbols
+			}
+			if list := symbols[name]; len(list) == 0 || list[len(list)-1] != pkg.ImportPath {
+				symbols[name] = append(list, pkg.ImportPath)
+			}
+		}
+	}
+	for _, symbols := range users {
+		for name, list := range symbols {
+			sort.Strings(list)
+			symbols[name] = compactStrings(list)
+		}
+	}
+	return users, nil
+}
+
+// usedName is the name of a used object as declaredNames has it: Name, or
+// Type.Method for methods, or "" for what has no heading of its own, like
+// fields
+func usedName(object types.Object) string {
+	switch object := object.(type) {
+	case *types.Func:
+		signature, ok := object.Type().(*types.Signature)
+		if !ok || signature.Recv() == nil {
+			return object.Name()
+		}
+		receiver := signature.Recv().Type()
+		if pointer, ok := receiver.(*types.Pointer); ok {
+			receiver = pointer.Elem()
+		}
+		if named, ok := receiver.(*types.Named); ok {
+			return named.Obj().Name() + "." + object.Name()
+		}
+		return ""
+	case *types.Var:
+		if object.IsField() {
+			return ""
+		}
+		return object.Name()
+	case *types.Const, *types.TypeName:
+		return object.Name()
+	}
+	return ""
+}
+
+// compactStrings drops the repeats from a sorted list
+func compactStrings(list []string) []string {
+	var result []string
+	for _, entry := range list {
+		if len(result) == 0 || result[len(result)-1] != entry {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// usedByOf lists the other packages of the module that use what node (a
+// declaration) declares, with -used-by, linked to their pages if they are
+// documented in this run, followed by a blank line, or is "" if there are
+// none
+func (self *Document) usedByOf(node ast.Node) string {
+	if len(self.usedBy) == 0 || node == nil {
+		return ""
+	}
+	var packages []string
+	for _, name := range declaredNames(node) {
+		packages = append(packages, self.usedBy[name]...)
+	}
+	if len(packages) == 0 {
+		return ""
+	}
+	sort.Strings(packages)
+	var users []string
+	for _, importPath := range compactStrings(packages) {
+		if link := self.linkTo(importPath, ""); link != "" {
+			users = append(users, fmt.Sprintf("[%s](%s)", importPath, link))
+		} else {
+			users = append(users, "`"+importPath+"`")
+		}
+	}
+	return fmt.Sprintf("%s %s\n\n", self.style.text("Used by"), strings.Join(users, ", "))
+}
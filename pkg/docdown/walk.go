@@ -0,0 +1,237 @@
+package docdown
+
+import (
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirFilter decides which directories a walk for packages leaves out.
+// Hidden directories, directories starting with "_", testdata, and
+// internal packages are always left out, except that IncludeInternal
+// keeps internal packages in (e.g. for an internal documentation portal).
+type DirFilter struct {
+	IncludeInternal bool
+
+	// Skip lists more directory names to leave out
+	Skip []string
+
+	// Exclude lists glob patterns for the paths (relative to the root of the
+	// walk, with forward slashes) of directories to leave out. Besides the
+	// wildcards of path.Match, "**" matches any number of directories.
+	Exclude []string
+}
+
+// skip reports whether the directory at path (relative to the root of the
+// walk) should be left out of the walk, along with everything below it
+func (self DirFilter) skip(path string) bool {
+	name := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(name, "."), strings.HasPrefix(name, "_"):
+		return true
+	case name == "testdata":
+		return true
+	case name == "internal" && !self.IncludeInternal:
+		return true
+	}
+	for _, skip := range self.Skip {
+		if name == skip {
+			return true
+		}
+	}
+	for _, pattern := range self.Exclude {
+		if matchGlob(pattern, filepath.ToSlash(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipImport reports whether the package with the given import path should
+// be left out of a list of packages, as if each of its path elements were a
+// directory of a walk. Vendored packages are also left out.
+func (self DirFilter) skipImport(importPath string) bool {
+	elements := strings.Split(importPath, "/")
+	for i := range elements {
+		if elements[i] == "vendor" || self.skip(strings.Join(elements[:i+1], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether name matches pattern, where both are
+// slash-separated paths and "**" in pattern matches zero or more elements
+func matchGlob(pattern, name string) bool {
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validGlob reports whether pattern is a well-formed glob for matchGlob
+func validGlob(pattern string) bool {
+	for _, element := range strings.Split(pattern, "/") {
+		if _, err := path.Match(element, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// packageDirs lists the directories below root that contain a Go package:
+// the immediate children of root, or every directory in the tree if
+// recursive is set, minus those filter leaves out. The paths are sorted
+// and relative to root.
+func packageDirs(root string, recursive bool, filter DirFilter) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() || path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if filter.skip(rel) {
+			return filepath.SkipDir
+		}
+		if hasPackage(path) {
+			dirs = append(dirs, rel)
+		}
+		if !recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	sort.Strings(dirs)
+	return dirs, err
+}
+
+// recursiveTargets replaces the directories among targets with every
+// package in the tree below them (themselves included), as -recursive
+// does, minus those filter leaves out. Other targets, like package
+// patterns and source files, are kept as they are.
+func recursiveTargets(targets []string, filter DirFilter) ([]string, error) {
+	var expanded []string
+	for _, target := range targets {
+		info, err := os.Stat(target)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, target)
+			continue
+		}
+		if hasPackage(target) {
+			expanded = append(expanded, target)
+		}
+		dirs, err := packageDirs(target, true, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			expanded = append(expanded, filepath.Join(target, dir))
+		}
+	}
+	return expanded, nil
+}
+
+// hasPackage reports whether dir has any (non-test) Go files
+func hasPackage(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
+
+// subpackage is a package in a directory below the documented one
+type subpackage struct {
+	Name     string
+	Path     string // Relative to the documented directory, with forward slashes
+	Synopsis string
+}
+
+// subpackages lists the immediate child packages of the document's
+// directory, like the directory listing of godoc
+func (self *Document) subpackages() ([]subpackage, error) {
+	dirs, err := packageDirs(self.absPath, false, self.style.Dirs)
+	if err != nil {
+		return nil, err
+	}
+	var list []subpackage
+	for _, dir := range dirs {
+		name, synopsis := packageSynopsis(filepath.Join(self.absPath, dir))
+		if name == "" {
+			continue
+		}
+		list = append(list, subpackage{
+			Name:     name,
+			Path:     filepath.ToSlash(dir),
+			Synopsis: synopsis,
+		})
+	}
+	return list, nil
+}
+
+// packageSynopsis reads just enough of the package in dir to find its name
+// (the directory name, for a command) and the first sentence of its
+// documentation
+func packageSynopsis(dir string) (name, synopsis string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", ""
+	}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(filename, ".go") || strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, filename), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		if name == "" || (synopsis == "" && file.Doc != nil) {
+			name = file.Name.Name
+		}
+		if synopsis == "" && file.Doc != nil {
+			synopsis = doc.Synopsis(file.Doc.Text())
+		}
+	}
+	if name == "main" || name == "documentation" {
+		name = filepath.Base(dir)
+	}
+	return name, synopsis
+}
@@ -0,0 +1,105 @@
+package docdown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubpackages(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"root.go":                "package root\n",
+		"client/client.go":       "// Package client talks to the server.\npackage client\n",
+		"client/deep/deep.go":    "package deep\n",
+		"cmd/tool/main.go":       "// Tool does things.\npackage main\n",
+		"internal/hidden.go":     "package internal\n",
+		"testdata/data.go":       "package data\n",
+		"empty/notes.md":         "Not a package",
+		"tests/only_test.go":     "package tests\n",
+		"server/server.go":       "package server\n",
+		"server/server_test.go":  "package server\n",
+		"_scratch/scratch.go":    "package scratch\n",
+		".hidden/hidden.go":      "package hidden\n",
+		"client/.git/objects.go": "package objects\n",
+	})
+	document := &Document{absPath: root, style: DefaultStyle}
+	list, err := document.subpackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []subpackage{
+		{Name: "client", Path: "client", Synopsis: "Package client talks to the server."},
+		{Name: "server", Path: "server"},
+	}
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("subpackages are %+v, expected %+v", list, expected)
+	}
+
+	// A package added since is listed too
+	writeFiles(t, root, map[string]string{"added/added.go": "// Package added is new.\npackage added\n"})
+	list, err = document.subpackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Name != "added" || list[0].Synopsis != "Package added is new." {
+		t.Errorf("subpackages after adding one are %+v", list)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, test := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"mocks", "mocks", true},
+		{"mocks", "a/mocks", false},
+		{"*/mocks", "a/mocks", true},
+		{"*/mocks", "a/b/mocks", false},
+		{"**/mocks", "mocks", true},
+		{"**/mocks", "a/b/mocks", true},
+		{"**/mocks", "a/mocks/b", false},
+		{"a/**", "a", true},
+		{"a/**", "a/b/c", true},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/d/c", true},
+		{"a/**/c", "a/b/d", false},
+		{"**", "a/b", true},
+		{"**/gen_*", "x/gen_foo", true},
+		{"**/gen_*", "x/foo_gen", false},
+		{"third_party", "third_party/x", false},
+		{"a/[bc]", "a/c", true},
+		{"a/?", "a/bc", false},
+	} {
+		if match := matchGlob(test.pattern, test.name); match != test.match {
+			t.Errorf("matchGlob(%q, %q) is %v, expected %v", test.pattern, test.name, match, test.match)
+		}
+	}
+}
+
+func TestDirFilterSkip(t *testing.T) {
+	filter := DirFilter{Skip: []string{"mocks"}, Exclude: []string{"**/gen", "third_party"}}
+	for path, skip := range map[string]bool{
+		"client":          false,
+		".git":            true,
+		"_scratch":        true,
+		"a/testdata":      true,
+		"internal":        true,
+		"a/mocks":         true,
+		"a/b/gen":         true,
+		"third_party":     true,
+		"a/third_party":   false,
+		"generated":       false,
+		"client/internal": true,
+	} {
+		if skipped := filter.skip(path); skipped != skip {
+			t.Errorf("skip(%q) is %v, expected %v", path, skipped, skip)
+		}
+	}
+	filter.IncludeInternal = true
+	if filter.skip("client/internal") {
+		t.Errorf("skip(\"client/internal\") with IncludeInternal")
+	}
+	if !filter.skipImport("example.com/mod/vendor/x") {
+		t.Errorf("skipImport of a vendored package is false")
+	}
+}